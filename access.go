@@ -0,0 +1,112 @@
+package gologin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// Login attempt outcomes.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// AccessLogEntry describes a login callback attempt. It never includes the
+// auth code, token, or state value, so it is safe to log.
+type AccessLogEntry struct {
+	// Method is the callback request method
+	Method string
+	// Provider is the provider name (e.g. "github")
+	Provider string
+	// Outcome is OutcomeSuccess or OutcomeFailure
+	Outcome string
+	// Elapsed is the time spent handling the callback
+	Elapsed time.Duration
+	// FlowID correlates the login and callback phases of a login without
+	// exposing the state value. It is empty if the callback had no state.
+	FlowID string
+	// Scopes are the OAuth2 scopes granted (or requested) for the login, if
+	// they were added to the ctx with WithScopes
+	Scopes []string
+	// Error is the failure error message, with RedactedParams values
+	// redacted (even if RedactErrors is false). It is empty on success.
+	Error string
+}
+
+// AccessLogFunc records an AccessLogEntry.
+type AccessLogFunc func(entry AccessLogEntry)
+
+// DefaultAccessLog writes each AccessLogEntry as a single structured line to
+// the DefaultLogger.
+var DefaultAccessLog AccessLogFunc = logAccess
+
+func logAccess(entry AccessLogEntry) {
+	DefaultLogger.Printf("gologin: access method=%s provider=%s outcome=%s elapsed=%s flow_id=%q scopes=%q error=%q",
+		entry.Method, entry.Provider, entry.Outcome, entry.Elapsed, entry.FlowID, strings.Join(entry.Scopes, " "), entry.Error)
+}
+
+// AccessLogHandler wraps a provider callback handler (including its success
+// and failure handlers) and records an AccessLogEntry for each callback with
+// the AccessLogFunc, or DefaultAccessLog if it is nil. A callback is a
+// failure if an error was added to the ctx with WithError while handling it.
+func AccessLogHandler(provider string, log AccessLogFunc, handler goji.Handler) goji.Handler {
+	if log == nil {
+		log = DefaultAccessLog
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		record := &accessRecord{}
+		ctx = context.WithValue(ctx, accessKey, record)
+		handler.ServeHTTP(ctx, w, req)
+		entry := AccessLogEntry{
+			Method:   req.Method,
+			Provider: provider,
+			Outcome:  OutcomeSuccess,
+			Elapsed:  time.Since(start),
+			Scopes:   record.scopes,
+		}
+		if state := req.FormValue("state"); state != "" {
+			entry.FlowID = flowID(state)
+		}
+		if record.err != nil {
+			entry.Outcome = OutcomeFailure
+			entry.Error = RedactString(record.err.Error())
+		}
+		log(entry)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// accessRecord records the error and scopes, if any, added while handling a
+// callback.
+type accessRecord struct {
+	err    error
+	scopes []string
+}
+
+// recordAccessError records the error in the ctx accessRecord, if any.
+func recordAccessError(ctx context.Context, err error) {
+	if record, ok := ctx.Value(accessKey).(*accessRecord); ok {
+		record.err = err
+	}
+}
+
+// recordAccessScopes records the scopes in the ctx accessRecord, if any.
+func recordAccessScopes(ctx context.Context, scopes []string) {
+	if record, ok := ctx.Value(accessKey).(*accessRecord); ok {
+		record.scopes = scopes
+	}
+}
+
+// flowID returns a short identifier derived from the state value, matching
+// the oauth2 flow ID, which is safe to log.
+func flowID(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:8])
+}
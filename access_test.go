@@ -0,0 +1,107 @@
+package gologin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+const testCallbackURL = "/callback?code=secret-code&state=secret-state"
+
+func TestAccessLogHandler_Success(t *testing.T) {
+	var entries []AccessLogEntry
+	record := func(entry AccessLogEntry) {
+		entries = append(entries, entry)
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := AccessLogHandler("github", record, goji.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", testCallbackURL, nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	if assert.Len(t, entries, 1) {
+		entry := entries[0]
+		assert.Equal(t, "GET", entry.Method)
+		assert.Equal(t, "github", entry.Provider)
+		assert.Equal(t, OutcomeSuccess, entry.Outcome)
+		assert.True(t, entry.Elapsed >= 0)
+		assert.Equal(t, flowID("secret-state"), entry.FlowID)
+		assert.Equal(t, "", entry.Error)
+	}
+}
+
+func TestAccessLogHandler_Failure(t *testing.T) {
+	var entries []AccessLogEntry
+	record := func(entry AccessLogEntry) {
+		entries = append(entries, entry)
+	}
+	callback := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		ctx = WithError(ctx, errors.New("token exchange failed ?code=secret-code"))
+		DefaultFailureHandler.ServeHTTP(ctx, w, req)
+	}
+	handler := AccessLogHandler("github", record, goji.HandlerFunc(callback))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", testCallbackURL, nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	if assert.Len(t, entries, 1) {
+		entry := entries[0]
+		assert.Equal(t, "POST", entry.Method)
+		assert.Equal(t, OutcomeFailure, entry.Outcome)
+		assert.Equal(t, "token exchange failed ?code=REDACTED", entry.Error)
+	}
+}
+
+func TestAccessLogHandler_Scopes(t *testing.T) {
+	var entries []AccessLogEntry
+	record := func(entry AccessLogEntry) {
+		entries = append(entries, entry)
+	}
+	callback := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		WithScopes(ctx, []string{"read:user"})
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := AccessLogHandler("github", record, goji.HandlerFunc(callback))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", testCallbackURL, nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, []string{"read:user"}, entries[0].Scopes)
+	}
+}
+
+func TestDefaultAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	DefaultLogger = log.New(&buf, "", 0)
+	defer func() { DefaultLogger = nopLogger{} }()
+	// assert secrets are not logged, even with redaction disabled
+	RedactErrors = false
+	defer func() { RedactErrors = true }()
+
+	callback := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		ctx = WithError(ctx, errors.New("?access_token=secret-token"))
+		DefaultFailureHandler.ServeHTTP(ctx, w, req)
+	}
+	handler := AccessLogHandler("github", nil, goji.HandlerFunc(callback))
+	req, _ := http.NewRequest("GET", testCallbackURL, nil)
+	handler.ServeHTTP(context.Background(), httptest.NewRecorder(), req)
+
+	line := buf.String()
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+	assert.Contains(t, line, "gologin: access method=GET provider=github outcome=failure elapsed=")
+	assert.Contains(t, line, fmt.Sprintf("flow_id=%q", flowID("secret-state")))
+	assert.Contains(t, line, `error="?access_token=REDACTED"`)
+	for _, secret := range []string{"secret-code", "secret-state", "secret-token"} {
+		assert.NotContains(t, line, secret)
+	}
+}
@@ -0,0 +1,45 @@
+package atlassian
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	userKey key = iota
+	resourcesKey
+)
+
+// WithUser returns a copy of ctx that stores the Atlassian User.
+func WithUser(ctx context.Context, user *User) context.Context {
+	ctx = gologin.WithUser(ctx, user)
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the Atlassian User from the ctx.
+func UserFromContext(ctx context.Context) (*User, error) {
+	user, ok := ctx.Value(userKey).(*User)
+	if !ok {
+		return nil, fmt.Errorf("atlassian: Context missing Atlassian User")
+	}
+	return user, nil
+}
+
+// WithResources returns a copy of ctx that stores the accessible Resources.
+func WithResources(ctx context.Context, resources []Resource) context.Context {
+	return context.WithValue(ctx, resourcesKey, resources)
+}
+
+// ResourcesFromContext returns the accessible Resources from the ctx.
+func ResourcesFromContext(ctx context.Context) ([]Resource, error) {
+	resources, ok := ctx.Value(resourcesKey).([]Resource)
+	if !ok {
+		return nil, fmt.Errorf("atlassian: Context missing Atlassian Resources")
+	}
+	return resources, nil
+}
@@ -0,0 +1,48 @@
+package atlassian
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{AccountID: "5b10ac8d82e05b22cc7d4ef5", Name: "Ada Lovelace"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "atlassian: Context missing Atlassian User", err.Error())
+	}
+}
+
+func TestContextResources(t *testing.T) {
+	expectedResources := []Resource{{ID: "cloud-id", Name: "acme"}}
+	ctx := WithResources(context.Background(), expectedResources)
+	resources, err := ResourcesFromContext(ctx)
+	assert.Equal(t, expectedResources, resources)
+	assert.Nil(t, err)
+}
+
+func TestContextResources_Error(t *testing.T) {
+	resources, err := ResourcesFromContext(context.Background())
+	assert.Nil(t, resources)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "atlassian: Context missing Atlassian Resources", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{AccountID: "5b10ac8d82e05b22cc7d4ef5"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "atlassian", ID: "5b10ac8d82e05b22cc7d4ef5"}, identity)
+}
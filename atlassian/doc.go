@@ -0,0 +1,6 @@
+// Package atlassian provides Atlassian (Jira, Confluence) OAuth 2.0 (3LO)
+// login and callback handlers.
+//
+// Atlassian access tokens are used against the cloud sites (resources) the
+// user granted access to. ResourcesHandler lists them to find cloud IDs.
+package atlassian
@@ -0,0 +1,157 @@
+package atlassian
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"account_id": "5b10ac8d82e05b22cc7d4ef5", "email": "ada@example.com", "name": "Ada Lovelace", "picture": "https://avatar-management.example.com/ada.png"}`
+	expectedUser := &User{
+		AccountID: "5b10ac8d82e05b22cc7d4ef5",
+		Email:     "ada@example.com",
+		Name:      "Ada Lovelace",
+		Picture:   "https://avatar-management.example.com/ada.png",
+	}
+	proxyClient, server := newAtlassianTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		atlassianUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, atlassianUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler gets an access token, assert that:
+	// - the Atlassian User from the me endpoint is added to the ctx
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestResourcesHandler(t *testing.T) {
+	expectedResources := []Resource{
+		{
+			ID:        "1324a887-45db-1bf4-1e99-ef0ff456d421",
+			URL:       "https://acme.atlassian.net",
+			Name:      "acme",
+			Scopes:    []string{"read:jira-work"},
+			AvatarURL: "https://site-admin-avatar-cdn.prod.public.atl-paas.net/avatars/240/flag.png",
+		},
+	}
+	proxyClient, server := newAtlassianTestServer("")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "atlassian-token"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		resources, err := ResourcesFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedResources, resources)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// ResourcesHandler lists accessible resources, assert that:
+	// - the Resources are added to the ctx of the success handler
+	handler := ResourcesHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestResourcesHandler_Error(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Atlassian Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetAtlassianResources, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := ResourcesHandler(&oauth2.Config{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestAtlassianHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// AtlassianHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	atlassianHandler := atlassianHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	atlassianHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestAtlassianHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Atlassian Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetAtlassianUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// AtlassianHandler cannot get Atlassian User, assert that:
+	// - failure handler is called
+	// - error cannot get Atlassian User added to the failure handler ctx
+	atlassianHandler := atlassianHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	atlassianHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{AccountID: "5b10ac8d82e05b22cc7d4ef5"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetAtlassianUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetAtlassianUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetAtlassianUser, validateResponse(&User{}, validResponse, nil))
+}
@@ -0,0 +1,38 @@
+package atlassian
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newAtlassianTestServer returns a new httptest.Server which mocks the
+// Atlassian token, me, and accessible-resources endpoints. The me endpoint
+// responds with the given json data. It also returns a client which proxies
+// requests to the server. The caller must close the server.
+func newAtlassianTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "atlassian-token", "token_type": "Bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer atlassian-token" {
+			http.Error(w, `{"code": 401, "message": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	mux.HandleFunc("/oauth/token/accessible-resources", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer atlassian-token" {
+			http.Error(w, `{"code": 401, "message": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"id": "1324a887-45db-1bf4-1e99-ef0ff456d421", "url": "https://acme.atlassian.net", "name": "acme", "scopes": ["read:jira-work"], "avatarUrl": "https://site-admin-avatar-cdn.prod.public.atl-paas.net/avatars/240/flag.png"}]`)
+	})
+	return client, server
+}
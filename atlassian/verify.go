@@ -0,0 +1,90 @@
+package atlassian
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const atlassianAPI = "https://api.atlassian.com/"
+
+// Endpoint is the Atlassian OAuth 2.0 (3LO) endpoint. Atlassian requires the
+// audience authorization parameter.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://auth.atlassian.com/authorize?audience=api.atlassian.com&prompt=consent",
+	TokenURL: "https://auth.atlassian.com/oauth/token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("atlassian", Endpoint)
+}
+
+// User is an Atlassian account.
+type User struct {
+	AccountID string `json:"account_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Picture   string `json:"picture"`
+}
+
+// Identity returns the Atlassian identity keyed by the account ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.AccountID}
+}
+
+// EmailAddress returns the Atlassian account's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// PictureURL returns the Atlassian account picture URL, if any.
+func (u *User) PictureURL() string {
+	return u.Picture
+}
+
+// Resource is an Atlassian cloud site the access token can be used with.
+type Resource struct {
+	// ID is the cloud ID used in API URLs
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	AvatarURL string   `json:"avatarUrl"`
+}
+
+// client is an Atlassian client for obtaining a User and Resources.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(atlassianAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User.
+// https://developer.atlassian.com/cloud/jira/platform/oauth-2-3lo-apps/
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
+
+// AccessibleResources lists the cloud sites the access token can be used
+// with.
+func (c *client) AccessibleResources() ([]Resource, *http.Response, error) {
+	var resources []Resource
+	resp, err := c.sling.New().Get("oauth/token/accessible-resources").ReceiveSuccess(&resources)
+	return resources, resp, err
+}
@@ -0,0 +1,32 @@
+package battlenet
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: 100000001, BattleTag: "Ada#1815"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "battlenet: Context missing Battle.net User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: 100000001})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "battlenet", ID: "100000001"}, identity)
+}
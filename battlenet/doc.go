@@ -0,0 +1,7 @@
+// Package battlenet provides Battle.net (Blizzard) OAuth2 login and callback
+// handlers.
+//
+// Battle.net OAuth2 is region-scoped. Use Endpoint to get the endpoint of a
+// region, such as "us" or "eu". Users are fetched from the userinfo resource
+// of the same region.
+package battlenet
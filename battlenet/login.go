@@ -0,0 +1,97 @@
+package battlenet
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Battle.net login errors
+var (
+	ErrUnableToGetBattlenetUser = errors.New("battlenet: unable to get Battle.net User")
+)
+
+// Provider is the Battle.net OAuth2 Provider for use with oauth2
+// HandleCallback.
+var Provider = oauth2Login.Provider{Name: "battlenet", CallbackHandler: CallbackHandler}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Battle.net login requests by reading the state value
+// from the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Battle.net redirection URI requests and adds the
+// Battle.net access token and User to the ctx. The User is fetched from the
+// region of the config's Endpoint. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = battlenetHandler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// battlenetHandler is a ContextHandler that gets the OAuth2 Token from the
+// ctx to get the corresponding Battle.net User. If successful, the User is
+// added to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
+func battlenetHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		battlenetClient, err := newClient(httpClient, config.Endpoint, gologin.UserInfoURLFromContext(ctx))
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		battlenetClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		user, resp, err := battlenetClient.UserInfo()
+		err = validateResponse(user, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, user)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Battle.net User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetBattlenetUser
+	}
+	if user == nil || user.ID == 0 {
+		return ErrUnableToGetBattlenetUser
+	}
+	return nil
+}
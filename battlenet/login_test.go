@@ -0,0 +1,138 @@
+package battlenet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestEndpoint(t *testing.T) {
+	endpoint, err := Endpoint("eu")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://eu.battle.net/oauth/authorize", endpoint.AuthURL)
+	assert.Equal(t, "https://eu.battle.net/oauth/token", endpoint.TokenURL)
+
+	endpoint, err = Endpoint("cn")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://www.battlenet.com.cn/oauth/token", endpoint.TokenURL)
+
+	_, err = Endpoint("mars")
+	assert.Equal(t, ErrInvalidRegion, err)
+}
+
+func TestCallbackHandler_Regions(t *testing.T) {
+	jsonData := `{"sub": "100000001", "id": 100000001, "battletag": "Ada#1815"}`
+	expectedUser := &User{ID: 100000001, BattleTag: "Ada#1815"}
+	for _, region := range []string{"us", "eu"} {
+		endpoint, err := Endpoint(region)
+		assert.Nil(t, err)
+		proxyClient, server := newBattlenetTestServer(region+".battle.net", jsonData)
+		// oauth2 Client will use the proxy client's base Transport
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+		config := &oauth2.Config{Endpoint: endpoint}
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			battlenetUser, err := UserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedUser, battlenetUser)
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := testutils.AssertFailureNotCalled(t)
+
+		// CallbackHandler with a region Endpoint, assert that:
+		// - the User is fetched from the userinfo of the same region host
+		handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestBattlenetHandler_InvalidRegion(t *testing.T) {
+	ctx := oauth2Login.WithToken(context.Background(), &oauth2.Token{AccessToken: "battlenet-token"})
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: "https://battle.example.com/oauth/token"}}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInvalidRegion, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// BattlenetHandler with a non Battle.net endpoint, assert that:
+	// - failure handler is called without sending the token elsewhere
+	battlenetHandler := battlenetHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	battlenetHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestBattlenetHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// BattlenetHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	battlenetHandler := battlenetHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	battlenetHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestBattlenetHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Battle.net Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	endpoint, _ := Endpoint("us")
+	config := &oauth2.Config{Endpoint: endpoint}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetBattlenetUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// BattlenetHandler cannot get Battle.net User, assert that:
+	// - failure handler is called
+	// - error cannot get Battle.net User added to the failure handler ctx
+	battlenetHandler := battlenetHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	battlenetHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: 100000001}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetBattlenetUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetBattlenetUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetBattlenetUser, validateResponse(&User{}, validResponse, nil))
+}
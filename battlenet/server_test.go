@@ -0,0 +1,31 @@
+package battlenet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newBattlenetTestServer returns a new httptest.Server which mocks the
+// Battle.net token and userinfo endpoints of a region host. Userinfo requests
+// to the host respond with the given json data, while requests to other hosts
+// fail. It also returns a client which proxies requests to the server. The
+// caller must close the server.
+func newBattlenetTestServer(host, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "battlenet-token", "token_type": "bearer", "expires_in": 86399}`)
+	})
+	mux.HandleFunc("/oauth/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != host || r.Header.Get("Authorization") != "Bearer battlenet-token" {
+			http.Error(w, `{"error": "invalid_token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
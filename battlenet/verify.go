@@ -0,0 +1,94 @@
+package battlenet
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+// ErrInvalidRegion is returned for unknown Battle.net regions.
+var ErrInvalidRegion = errors.New("battlenet: invalid region")
+
+// regionHosts maps Battle.net regions to their OAuth2 hosts.
+var regionHosts = map[string]string{
+	"us": "us.battle.net",
+	"eu": "eu.battle.net",
+	"kr": "kr.battle.net",
+	"tw": "tw.battle.net",
+	"cn": "www.battlenet.com.cn",
+}
+
+// Endpoint returns the Battle.net OAuth2 endpoint of the given region ("us",
+// "eu", "kr", "tw", or "cn").
+func Endpoint(region string) (oauth2.Endpoint, error) {
+	host, ok := regionHosts[region]
+	if !ok {
+		return oauth2.Endpoint{}, ErrInvalidRegion
+	}
+	return oauth2.Endpoint{
+		AuthURL:  "https://" + host + "/oauth/authorize",
+		TokenURL: "https://" + host + "/oauth/token",
+	}, nil
+}
+
+// User is a Battle.net account.
+type User struct {
+	ID        int64  `json:"id"`
+	BattleTag string `json:"battletag"`
+}
+
+// Identity returns the Battle.net identity keyed by the account ID.
+func (u *User) Identity() gologin.Identity {
+	identity := gologin.Identity{Provider: Provider.Name}
+	if u.ID != 0 {
+		identity.ID = strconv.FormatInt(u.ID, 10)
+	}
+	return identity
+}
+
+// client is a Battle.net client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+// newClient returns a client for the region whose OAuth2 endpoint is given.
+func newClient(httpClient *http.Client, endpoint oauth2.Endpoint, userInfoURL string) (*client, error) {
+	tokenURL, err := url.Parse(endpoint.TokenURL)
+	if err != nil || !isRegionHost(tokenURL.Host) {
+		return nil, ErrInvalidRegion
+	}
+	base := sling.New().Client(httpClient).Base("https://" + tokenURL.Host + "/").ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "oauth/userinfo"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}, nil
+}
+
+// UserInfo gets the authenticated User.
+// https://develop.battle.net/documentation/battle-net/oauth-apis
+func (c *client) UserInfo() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
+
+// isRegionHost returns true if host is a Battle.net region OAuth2 host.
+func isRegionHost(host string) bool {
+	for _, regionHost := range regionHosts {
+		if host == regionHost {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package bitbucket
 import (
 	"fmt"
 
+	"github.com/quasor/gologin"
 	"golang.org/x/net/context"
 )
 
@@ -15,6 +16,7 @@ const (
 
 // WithUser returns a copy of ctx that stores the Bitbucket User.
 func WithUser(ctx context.Context, user *User) context.Context {
+	ctx = gologin.WithUser(ctx, user)
 	return context.WithValue(ctx, userKey, user)
 }
 
@@ -1,8 +1,11 @@
 package bitbucket
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/quasor/gologin"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
 )
@@ -22,3 +25,19 @@ func TestContextUser_Error(t *testing.T) {
 		assert.Equal(t, "bitbucket: Context missing Bitbucket User", err.Error())
 	}
 }
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{UUID: "{a1b2}", Username: "bitster"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "bitbucket", ID: "{a1b2}"}, identity)
+}
+
+func TestUser_AccountCreated(t *testing.T) {
+	// RFC 3339 with microseconds and an offset created at timestamps
+	user := new(User)
+	err := json.Unmarshal([]byte(`{"uuid": "{c788b2da-b7a2-404c-9e26-d3f077557007}", "created_on": "2018-11-14T19:13:22.585390+00:00"}`), user)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, time.November, 14, 19, 13, 22, 585390000, time.UTC), user.AccountCreated())
+	assert.True(t, (&User{}).AccountCreated().IsZero())
+}
@@ -3,9 +3,11 @@ package bitbucket
 import (
 	"errors"
 	"net/http"
+	"sync"
 
 	"goji.io"
 	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
 	oauth2Login "github.com/quasor/gologin/oauth2"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
@@ -16,6 +18,9 @@ var (
 	ErrUnableToGetBitbucketUser = errors.New("bitbucket: unable to get Bitbucket User")
 )
 
+// Provider is the Bitbucket OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{Name: "bitbucket", CallbackHandler: CallbackHandler}
+
 // StateHandler checks for a state cookie. If found, the state value is read
 // and added to the ctx. Otherwise, a non-guessable value is added to the ctx
 // and to a (short-lived) state cookie issued to the requester.
@@ -39,15 +44,34 @@ func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
 // handling delegates to the success handler, otherwise to the failure
 // handler.
 func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
-	success = bitbucketHandler(config, success, failure)
+	return CallbackHandlerWithOptions(config, CallbackOptions{}, success, failure)
+}
+
+// CallbackOptions configures a Bitbucket CallbackHandler.
+type CallbackOptions struct {
+	// Workspaces lists the user's workspace memberships and sets the User
+	// Workspaces slugs. Requires the "account" scope; without it, Workspaces
+	// is left empty rather than failing the login.
+	Workspaces bool
+	// Concurrent fetches the workspaces in parallel with the User, rather
+	// than after it. A workspaces error is then soft: the login succeeds
+	// with an empty Workspaces. A User error still fails the login.
+	Concurrent bool
+}
+
+// CallbackHandlerWithOptions handles Bitbucket redirection URI requests like
+// CallbackHandler, configured by the given CallbackOptions.
+func CallbackHandlerWithOptions(config *oauth2.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
+	success = bitbucketHandler(config, options, success, failure)
 	return oauth2Login.CallbackHandler(config, success, failure)
 }
 
 // bitbucketHandler is a ContextHandler that gets the OAuth2 Token from the ctx
-// to get the corresponding Bitbucket User. If successful, the User is added to
-// the ctx and the success handler is called. Otherwise, the failure handler is
+// to get the corresponding Bitbucket User (and, if enabled, its workspaces).
+// If successful, the User is added to the ctx and the success handler is
+// called. Otherwise, the failure handler is
 // called.
-func bitbucketHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+func bitbucketHandler(config *oauth2.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
@@ -59,9 +83,9 @@ func bitbucketHandler(config *oauth2.Config, success, failure goji.Handler) goji
 			return
 		}
 		httpClient := config.Client(ctx, token)
-		bitbucketClient := newClient(httpClient)
-		user, resp, err := bitbucketClient.CurrentUser()
-		err = validateResponse(user, resp, err)
+		bitbucketClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		bitbucketClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		user, err := currentUser(bitbucketClient, options)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTP(ctx, w, req)
@@ -73,6 +97,50 @@ func bitbucketHandler(config *oauth2.Config, success, failure goji.Handler) goji
 	return goji.HandlerFunc(fn)
 }
 
+// currentUser gets the current Bitbucket User and, if enabled by the options,
+// its workspaces.
+func currentUser(c *client, options CallbackOptions) (*User, error) {
+	if options.Workspaces && options.Concurrent {
+		return currentUserConcurrent(c)
+	}
+	user, resp, err := c.CurrentUser()
+	if err = validateResponse(user, resp, err); err != nil {
+		return nil, err
+	}
+	if options.Workspaces {
+		user.Workspaces, err = c.Workspaces()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// currentUserConcurrent gets the current Bitbucket User and its workspaces in
+// parallel. Only a User error is returned.
+func currentUserConcurrent(c *client) (*User, error) {
+	var (
+		wg            sync.WaitGroup
+		workspaces    []string
+		workspacesErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		workspaces, workspacesErr = c.Workspaces()
+	}()
+	user, resp, err := c.CurrentUser()
+	err = validateResponse(user, resp, err)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if workspacesErr == nil {
+		user.Workspaces = workspaces
+	}
+	return user, nil
+}
+
 // validateResponse returns an error if the given Bitbucket User, raw
 // http.Response, or error are unexpected. Returns nil if they are valid.
 func validateResponse(user *User, resp *http.Response, err error) error {
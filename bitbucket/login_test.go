@@ -39,7 +39,7 @@ func TestBitbucketHandler(t *testing.T) {
 	// - bitbucket User is obtained from the Bitbucket API
 	// - success handler is called
 	// - bitbucket User is added to the ctx of the success handler
-	bitbucketHandler := bitbucketHandler(config, goji.HandlerFunc(success), failure)
+	bitbucketHandler := bitbucketHandler(config, CallbackOptions{}, goji.HandlerFunc(success), failure)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	bitbucketHandler.ServeHTTP(ctx, w, req)
@@ -60,7 +60,7 @@ func TestBitbucketHandler_MissingCtxToken(t *testing.T) {
 	// BitbucketHandler called without Token in ctx, assert that:
 	// - failure handler is called
 	// - error about ctx missing token is added to the failure handler ctx
-	bitbucketHandler := bitbucketHandler(config, success, goji.HandlerFunc(failure))
+	bitbucketHandler := bitbucketHandler(config, CallbackOptions{}, success, goji.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	bitbucketHandler.ServeHTTP(context.Background(), w, req)
@@ -88,13 +88,50 @@ func TestBitbucketHandler_ErrorGettingUser(t *testing.T) {
 	// BitbucketHandler cannot get Bitbucket User, assert that:
 	// - failure handler is called
 	// - error cannot get Bitbucket User added to the failure handler ctx
-	bitbucketHandler := bitbucketHandler(config, success, goji.HandlerFunc(failure))
+	bitbucketHandler := bitbucketHandler(config, CallbackOptions{}, success, goji.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	bitbucketHandler.ServeHTTP(ctx, w, req)
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestBitbucketHandler_RejectSuspended(t *testing.T) {
+	cases := []struct {
+		jsonData string
+		expected string
+	}{
+		{`{"username": "bitster", "account_status": "active"}`, "success handler called"},
+		{`{"username": "bitster", "account_status": "inactive"}`, "failure handler called"},
+	}
+	for _, c := range cases {
+		proxyClient, server := newBitbucketTestServer(c.jsonData)
+		// oauth2 Client will use the proxy client's base Transport
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		anyToken := &oauth2.Token{AccessToken: "any-token"}
+		ctx = oauth2Login.WithToken(ctx, anyToken)
+
+		config := &oauth2.Config{}
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, gologin.ErrAccountSuspended, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// BitbucketHandler chained with RejectSuspended, assert that:
+		// - active accounts reach the success handler
+		// - inactive accounts reach the failure handler with ErrAccountSuspended
+		handler := gologin.RejectSuspended(goji.HandlerFunc(success), goji.HandlerFunc(failure))
+		handler = bitbucketHandler(config, CallbackOptions{}, handler, goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+		server.Close()
+	}
+}
+
 func TestValidateResponse(t *testing.T) {
 	validUser := &User{Username: "bitster"}
 	validResponse := &http.Response{StatusCode: 200}
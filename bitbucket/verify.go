@@ -2,31 +2,73 @@ package bitbucket
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
 )
 
 const bitbucketAPI = "https://bitbucket.org/api/2.0/"
 
+// Endpoint is the Bitbucket OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://bitbucket.org/site/oauth2/authorize",
+	TokenURL: "https://bitbucket.org/site/oauth2/access_token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("bitbucket", Endpoint)
+}
+
 // User is a Bitbucket user.
 type User struct {
-	Username    string `json:"username"`
-	DisplayName string `json:"display_name"`
-	Website     string `json:"website"`
-	Location    string `json:"location"`
-	Type        string `json:"type"` // user, team
+	UUID          string            `json:"uuid"`
+	Username      string            `json:"username"`
+	DisplayName   string            `json:"display_name"`
+	Website       string            `json:"website"`
+	Location      string            `json:"location"`
+	Type          string            `json:"type"`           // user, team
+	AccountStatus string            `json:"account_status"` // active, inactive, closed
+	CreatedOn     gologin.Timestamp `json:"created_on"`
+	// Workspaces are the slugs of the user's workspaces, if requested with
+	// CallbackOptions Workspaces
+	Workspaces []string `json:"-"`
+}
+
+// Suspended returns true if Bitbucket reports the account is not active.
+func (u *User) Suspended() bool {
+	return u.AccountStatus != "" && u.AccountStatus != "active"
+}
+
+// AccountCreated returns when the Bitbucket account was created.
+func (u *User) AccountCreated() time.Time {
+	return u.CreatedOn.Time
+}
+
+// Identity returns the Bitbucket identity keyed by the account UUID, which is
+// stable across username changes.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.UUID}
 }
 
 // client is a Bitbucket client for obtaining a User.
 type client struct {
 	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
 }
 
 // newClient returns a new Bitbucket client.
-func newClient(httpClient *http.Client) *client {
-	base := sling.New().Client(httpClient).Base(bitbucketAPI)
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(bitbucketAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "user"
+	}
 	return &client{
-		sling: base,
+		sling:       base,
+		userInfoURL: userInfoURL,
 	}
 }
 
@@ -34,6 +76,6 @@ func newClient(httpClient *http.Client) *client {
 // https://confluence.atlassian.com/bitbucket/users-endpoint-423626336.html
 func (c *client) CurrentUser() (*User, *http.Response, error) {
 	user := new(User)
-	resp, err := c.sling.New().Get("user").ReceiveSuccess(user)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
 	return user, resp, err
 }
@@ -0,0 +1,59 @@
+package bitbucket
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnableToGetBitbucketWorkspaces is returned when workspace memberships
+// could not be listed.
+var ErrUnableToGetBitbucketWorkspaces = errors.New("bitbucket: unable to get Bitbucket workspaces")
+
+// maxWorkspacePages bounds the number of workspace pages followed.
+const maxWorkspacePages = 10
+
+// workspacePermission is a Bitbucket workspace membership.
+type workspacePermission struct {
+	Permission string `json:"permission"` // owner, collaborator, member
+	Workspace  struct {
+		Slug string `json:"slug"`
+	} `json:"workspace"`
+}
+
+// workspacesPage is a page of Bitbucket workspace memberships.
+type workspacesPage struct {
+	Values []workspacePermission `json:"values"`
+	Next   string                `json:"next"`
+}
+
+// Workspaces lists the slugs of the workspaces the current user is a member
+// of, following pagination. If the token lacks the scope to list workspaces,
+// nil slugs and no error are returned.
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-workspaces/#api-user-permissions-workspaces-get
+func (c *client) Workspaces() ([]string, error) {
+	var slugs []string
+	req := c.sling.New().Get("user/permissions/workspaces?pagelen=100")
+	for i := 0; i < maxWorkspacePages; i++ {
+		page := new(workspacesPage)
+		resp, err := req.ReceiveSuccess(page)
+		if err != nil {
+			return nil, ErrUnableToGetBitbucketWorkspaces
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusForbidden:
+			// missing the account (read:workspace:bitbucket) scope
+			return nil, nil
+		default:
+			return nil, ErrUnableToGetBitbucketWorkspaces
+		}
+		for _, value := range page.Values {
+			slugs = append(slugs, value.Workspace.Slug)
+		}
+		if page.Next == "" {
+			break
+		}
+		req = c.sling.New().Get(page.Next)
+	}
+	return slugs, nil
+}
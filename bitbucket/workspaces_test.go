@@ -0,0 +1,154 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// newWorkspacesTestServer returns a new httptest.Server which mocks the
+// Bitbucket user and paginated workspaces endpoints and a client which
+// proxies requests to the server. The caller must close the server.
+func newWorkspacesTestServer(workspacesStatus int) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/api/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"username": "bitster"}`)
+	})
+	mux.HandleFunc("/api/2.0/user/permissions/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if workspacesStatus != http.StatusOK {
+			w.WriteHeader(workspacesStatus)
+			fmt.Fprintf(w, `{"type": "error", "error": {"message": "Your credentials lack one or more required privilege scopes."}}`)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			fmt.Fprintf(w, `{"values": [{"permission": "owner", "workspace": {"slug": "atlas"}}], "next": "https://bitbucket.org/api/2.0/user/permissions/workspaces?pagelen=100&page=2"}`)
+		case "2":
+			fmt.Fprintf(w, `{"values": [{"permission": "member", "workspace": {"slug": "ian-team"}}]}`)
+		}
+	})
+	return client, server
+}
+
+func TestBitbucketHandler_Workspaces(t *testing.T) {
+	cases := []struct {
+		workspacesStatus   int
+		expectedWorkspaces []string
+	}{
+		// paginated workspaces
+		{http.StatusOK, []string{"atlas", "ian-team"}},
+		// missing scope fails soft
+		{http.StatusForbidden, nil},
+	}
+	for _, c := range cases {
+		proxyClient, server := newWorkspacesTestServer(c.workspacesStatus)
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			user, err := UserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, "bitster", user.Username)
+			assert.Equal(t, c.expectedWorkspaces, user.Workspaces)
+			fmt.Fprintf(w, "success handler called")
+		}
+		handler := bitbucketHandler(&oauth2.Config{}, CallbackOptions{Workspaces: true}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestBitbucketHandler_WorkspacesError(t *testing.T) {
+	proxyClient, server := newWorkspacesTestServer(http.StatusInternalServerError)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetBitbucketWorkspaces, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := bitbucketHandler(&oauth2.Config{}, CallbackOptions{Workspaces: true}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestBitbucketHandler_ConcurrentWorkspaces(t *testing.T) {
+	cases := []struct {
+		workspacesStatus   int
+		expectedWorkspaces []string
+	}{
+		{http.StatusOK, []string{"atlas", "ian-team"}},
+		// workspaces errors fail soft when fetched concurrently
+		{http.StatusInternalServerError, nil},
+	}
+	for _, c := range cases {
+		proxyClient, server := newWorkspacesTestServer(c.workspacesStatus)
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			user, err := UserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, "bitster", user.Username)
+			assert.Equal(t, c.expectedWorkspaces, user.Workspaces)
+			fmt.Fprintf(w, "success handler called")
+		}
+		options := CallbackOptions{Workspaces: true, Concurrent: true}
+		handler := bitbucketHandler(&oauth2.Config{}, options, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestBitbucketHandler_ConcurrentUserError(t *testing.T) {
+	var workspacesCalled int32
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/api/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type": "error"}`, http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/api/2.0/user/permissions/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&workspacesCalled, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"values": [{"permission": "owner", "workspace": {"slug": "atlas"}}]}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetBitbucketUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// concurrent fetching with a failing user endpoint, assert that:
+	// - both endpoints are called
+	// - the failure handler is called with the user error
+	options := CallbackOptions{Workspaces: true, Concurrent: true}
+	handler := bitbucketHandler(&oauth2.Config{}, options, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&workspacesCalled))
+}
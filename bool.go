@@ -0,0 +1,43 @@
+package gologin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// FlexibleBool is a provider profile boolean which also decodes from the
+// numbers (e.g. "verified": 1) and strings (e.g. "verified": "true") some
+// providers send instead of JSON booleans.
+type FlexibleBool bool
+
+// UnmarshalJSON decodes a JSON boolean, number, or string. Non-zero numbers
+// are true and strings are parsed with strconv.ParseBool. A null leaves the
+// value unchanged.
+func (b *FlexibleBool) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = FlexibleBool(value)
+		return nil
+	}
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*b = number != 0
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("gologin: invalid boolean %s", data)
+	}
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("gologin: invalid boolean %q", s)
+	}
+	*b = FlexibleBool(value)
+	return nil
+}
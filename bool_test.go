@@ -0,0 +1,48 @@
+package gologin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlexibleBool_UnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		json     string
+		expected FlexibleBool
+	}{
+		// booleans
+		{`{"verified": true}`, true},
+		{`{"verified": false}`, false},
+		// numbers
+		{`{"verified": 1}`, true},
+		{`{"verified": 0}`, false},
+		{`{"verified": 1.0}`, true},
+		// strings
+		{`{"verified": "true"}`, true},
+		{`{"verified": "false"}`, false},
+		{`{"verified": "1"}`, true},
+		{`{"verified": "0"}`, false},
+		// null and absent
+		{`{"verified": null}`, false},
+		{`{}`, false},
+	}
+	for _, c := range cases {
+		var user struct {
+			Verified FlexibleBool `json:"verified"`
+		}
+		err := json.Unmarshal([]byte(c.json), &user)
+		assert.Nil(t, err, c.json)
+		assert.Equal(t, c.expected, user.Verified, c.json)
+	}
+}
+
+func TestFlexibleBool_UnmarshalJSONError(t *testing.T) {
+	for _, data := range []string{`{"verified": "yes please"}`, `{"verified": []}`, `{"verified": {}}`} {
+		var user struct {
+			Verified FlexibleBool `json:"verified"`
+		}
+		assert.NotNil(t, json.Unmarshal([]byte(data), &user), data)
+	}
+}
@@ -0,0 +1,30 @@
+package box
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	userKey key = iota
+)
+
+// WithUser returns a copy of ctx that stores the Box User.
+func WithUser(ctx context.Context, user *User) context.Context {
+	ctx = gologin.WithUser(ctx, user)
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the Box User from the ctx.
+func UserFromContext(ctx context.Context) (*User, error) {
+	user, ok := ctx.Value(userKey).(*User)
+	if !ok {
+		return nil, fmt.Errorf("box: Context missing Box User")
+	}
+	return user, nil
+}
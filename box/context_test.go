@@ -0,0 +1,32 @@
+package box
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "11446498", Name: "Aaron Levie"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "box: Context missing Box User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "11446498"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "box", ID: "11446498"}, identity)
+}
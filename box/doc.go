@@ -0,0 +1,4 @@
+// Package box provides Box OAuth2 login and callback handlers.
+//
+// Box requires client credentials be sent in the token request body.
+package box
@@ -0,0 +1,111 @@
+package box
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"type": "user", "id": "11446498", "name": "Aaron Levie", "login": "ceo@example.com"}`
+	expectedUser := &User{
+		ID:    "11446498",
+		Name:  "Aaron Levie",
+		Login: "ceo@example.com",
+	}
+	proxyClient, server := newBoxTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	// Endpoint without an AuthStyle, so the Box default must be applied
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: Endpoint.AuthURL, TokenURL: Endpoint.TokenURL},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		boxUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, boxUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler exchanges the code with body credentials, assert that:
+	// - the Box User is added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestBoxHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// BoxHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	boxHandler := boxHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	boxHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestBoxHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Box Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetBoxUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// BoxHandler cannot get Box User, assert that:
+	// - failure handler is called
+	// - error cannot get Box User added to the failure handler ctx
+	boxHandler := boxHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	boxHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "11446498"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetBoxUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetBoxUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetBoxUser, validateResponse(&User{}, validResponse, nil))
+}
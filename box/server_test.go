@@ -0,0 +1,35 @@
+package box
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newBoxTestServer returns a new httptest.Server which mocks the Box token
+// endpoint, requiring client credentials in the body, and the users/me endpoint,
+// which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newBoxTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": "invalid_client", "error_description": "The client credentials are invalid"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "box-token", "token_type": "bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/2.0/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer box-token" {
+			http.Error(w, `{"type": "error", "status": 401, "code": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
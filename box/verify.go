@@ -0,0 +1,66 @@
+package box
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const boxAPI = "https://api.box.com/2.0/"
+
+// Endpoint is the Box OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://account.box.com/api/oauth2/authorize",
+	TokenURL:  "https://api.box.com/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("box", Endpoint)
+}
+
+// User is a Box user.
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Login string `json:"login"` // email address
+}
+
+// Identity returns the Box identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Box user's login, which is an email address.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Login, u.Login != ""
+}
+
+// client is a Box client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(boxAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User.
+// https://developer.box.com/reference/get-users-me/
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
@@ -0,0 +1,70 @@
+package gologin
+
+import (
+	"net/http"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// Chain returns a handler which runs the handlers in order, such as success
+// steps which persist a session, emit an event, and redirect.
+//
+// Chain short-circuits: once a handler writes a response (calls Write or
+// WriteHeader) or calls Abort, the remaining handlers are not run. If a
+// handler aborts without writing a response, the DefaultFailureHandler
+// responds with the abort error. If the ctx already has an error, no
+// handlers run and the DefaultFailureHandler responds.
+func Chain(handlers ...goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if _, ok := ctx.Value(errorKey).(error); ok {
+			DefaultFailureHandler.ServeHTTP(ctx, w, req)
+			return
+		}
+		state := &chainState{}
+		ctx = context.WithValue(ctx, chainKey, state)
+		cw := &chainWriter{ResponseWriter: w}
+		for _, handler := range handlers {
+			handler.ServeHTTP(ctx, cw, req)
+			if state.err != nil {
+				if !cw.written {
+					DefaultFailureHandler.ServeHTTP(WithError(ctx, state.err), w, req)
+				}
+				return
+			}
+			if cw.written {
+				return
+			}
+		}
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// Abort stops the Chain running the handler with the given ctx after the
+// handler returns. Abort has no effect outside of a Chain.
+func Abort(ctx context.Context, err error) {
+	if state, ok := ctx.Value(chainKey).(*chainState); ok {
+		state.err = err
+	}
+}
+
+// chainState records whether a Chain handler aborted.
+type chainState struct {
+	err error
+}
+
+// chainWriter records whether a Chain handler wrote a response.
+type chainWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *chainWriter) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *chainWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
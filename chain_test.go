@@ -0,0 +1,83 @@
+package gologin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// stepHandler returns a handler which records that the named step ran.
+func stepHandler(steps *[]string, name string) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		*steps = append(*steps, name)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+func TestChain(t *testing.T) {
+	steps := []string{}
+	redirect := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		steps = append(steps, "redirect")
+		http.Redirect(w, req, "/profile", http.StatusFound)
+	}
+	handler := Chain(stepHandler(&steps, "session"), stepHandler(&steps, "event"), goji.HandlerFunc(redirect))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	// assert the handlers ran in order
+	assert.Equal(t, []string{"session", "event", "redirect"}, steps)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/profile", w.HeaderMap.Get("Location"))
+}
+
+func TestChain_StopsAfterResponse(t *testing.T) {
+	steps := []string{}
+	respond := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		steps = append(steps, "respond")
+		fmt.Fprintf(w, "responded")
+	}
+	handler := Chain(goji.HandlerFunc(respond), stepHandler(&steps, "event"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, []string{"respond"}, steps)
+	assert.Equal(t, "responded", w.Body.String())
+}
+
+func TestChain_StopsOnAbort(t *testing.T) {
+	steps := []string{}
+	abort := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		steps = append(steps, "session")
+		Abort(ctx, errors.New("unable to save session"))
+	}
+	handler := Chain(goji.HandlerFunc(abort), stepHandler(&steps, "event"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	// assert later handlers are skipped and the failure handler responds
+	assert.Equal(t, []string{"session"}, steps)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "unable to save session\n", w.Body.String())
+}
+
+func TestChain_ContextError(t *testing.T) {
+	steps := []string{}
+	handler := Chain(stepHandler(&steps, "session"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	ctx := WithError(context.Background(), errors.New("some error"))
+	handler.ServeHTTP(ctx, w, req)
+	assert.Empty(t, steps)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAbort_OutsideChain(t *testing.T) {
+	// assert Abort outside a Chain has no effect
+	Abort(context.Background(), errors.New("some error"))
+}
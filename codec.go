@@ -0,0 +1,41 @@
+package gologin
+
+import (
+	"errors"
+)
+
+// ErrNoCodecs is returned by an empty MultiCodec.
+var ErrNoCodecs = errors.New("gologin: no codecs")
+
+// Codec encodes and decodes cookie values, for example to sign or encrypt
+// them. It is compatible with the gorilla/securecookie Codec interface, so a
+// *securecookie.SecureCookie may be used as a Codec directly.
+type Codec interface {
+	Encode(name string, value interface{}) (string, error)
+	Decode(name, value string, dst interface{}) error
+}
+
+// MultiCodec adapts a list of Codecs (e.g. from securecookie.CodecsFromPairs)
+// to a Codec. Values are encoded with the first Codec and decoded with the
+// first Codec which succeeds, which allows keys to be rotated.
+type MultiCodec []Codec
+
+// Encode encodes the value with the first Codec.
+func (m MultiCodec) Encode(name string, value interface{}) (string, error) {
+	if len(m) == 0 {
+		return "", ErrNoCodecs
+	}
+	return m[0].Encode(name, value)
+}
+
+// Decode decodes the value with the first Codec which succeeds or returns the
+// last error.
+func (m MultiCodec) Decode(name, value string, dst interface{}) error {
+	err := ErrNoCodecs
+	for _, codec := range m {
+		if err = codec.Decode(name, value, dst); err == nil {
+			return nil
+		}
+	}
+	return err
+}
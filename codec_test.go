@@ -0,0 +1,48 @@
+package gologin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prefixCodec is a fake Codec which "signs" string values with a prefix.
+type prefixCodec string
+
+func (c prefixCodec) Encode(name string, value interface{}) (string, error) {
+	return string(c) + name + ":" + value.(string), nil
+}
+
+func (c prefixCodec) Decode(name, value string, dst interface{}) error {
+	prefix := string(c) + name + ":"
+	if !strings.HasPrefix(value, prefix) {
+		return errors.New("invalid value")
+	}
+	*dst.(*string) = strings.TrimPrefix(value, prefix)
+	return nil
+}
+
+func TestMultiCodec(t *testing.T) {
+	codec := MultiCodec{prefixCodec("new."), prefixCodec("old.")}
+	encoded, err := codec.Encode("name", "value")
+	assert.Nil(t, err)
+	assert.Equal(t, "new.name:value", encoded)
+
+	// values encoded by any of the codecs can be decoded
+	for _, value := range []string{"new.name:value", "old.name:value"} {
+		var decoded string
+		assert.Nil(t, codec.Decode("name", value, &decoded))
+		assert.Equal(t, "value", decoded)
+	}
+	var decoded string
+	assert.NotNil(t, codec.Decode("name", "forged", &decoded))
+}
+
+func TestMultiCodec_Empty(t *testing.T) {
+	_, err := MultiCodec{}.Encode("name", "value")
+	assert.Equal(t, ErrNoCodecs, err)
+	var decoded string
+	assert.Equal(t, ErrNoCodecs, MultiCodec{}.Decode("name", "value", &decoded))
+}
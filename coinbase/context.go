@@ -0,0 +1,30 @@
+package coinbase
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	userKey key = iota
+)
+
+// WithUser returns a copy of ctx that stores the Coinbase User.
+func WithUser(ctx context.Context, user *User) context.Context {
+	ctx = gologin.WithUser(ctx, user)
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the Coinbase User from the ctx.
+func UserFromContext(ctx context.Context) (*User, error) {
+	user, ok := ctx.Value(userKey).(*User)
+	if !ok {
+		return nil, fmt.Errorf("coinbase: Context missing Coinbase User")
+	}
+	return user, nil
+}
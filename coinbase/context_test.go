@@ -0,0 +1,32 @@
+package coinbase
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "9da7a204-544e-5fd1-9a12-61176c5d4cd8"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "coinbase: Context missing Coinbase User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "9da7a204-544e-5fd1-9a12-61176c5d4cd8"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "coinbase", ID: "9da7a204-544e-5fd1-9a12-61176c5d4cd8"}, identity)
+}
@@ -0,0 +1,4 @@
+// Package coinbase provides Coinbase OAuth2 login and callback handlers.
+//
+// Coinbase requires client credentials be sent in the token request body.
+package coinbase
@@ -0,0 +1,40 @@
+package coinbase
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newCoinbaseTestServer returns a new httptest.Server which mocks the Coinbase
+// token endpoint, requiring client credentials in the body, and the user
+// endpoint, requiring the CB-VERSION header, which responds with the given
+// json data. It also returns a client which proxies requests to the server.
+// The caller must close the server.
+func newCoinbaseTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": "invalid_client", "error_description": "Client authentication failed"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "coinbase-token", "token_type": "bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/v2/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("CB-VERSION") != apiVersion {
+			http.Error(w, `{"errors": [{"id": "invalid_request", "message": "CB-VERSION header required"}]}`, http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer coinbase-token" {
+			http.Error(w, `{"errors": [{"id": "invalid_token", "message": "The access token is invalid"}]}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,76 @@
+package coinbase
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const (
+	coinbaseAPI = "https://api.coinbase.com/"
+	// apiVersion is the CB-VERSION date the API responses are pinned to
+	apiVersion = "2024-05-01"
+)
+
+// Endpoint is the Coinbase OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://login.coinbase.com/oauth2/auth",
+	TokenURL:  "https://login.coinbase.com/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("coinbase", Endpoint)
+}
+
+// User is a Coinbase user. Email is only present with the
+// wallet:user:email scope.
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Identity returns the Coinbase identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Coinbase user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// userResponse is a Coinbase API response, which wraps the User in data.
+type userResponse struct {
+	Data User `json:"data"`
+}
+
+// client is a Coinbase client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(coinbaseAPI).Set("CB-VERSION", apiVersion).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "v2/user"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// CurrentUser gets the authenticated User.
+// https://docs.cdp.coinbase.com/coinbase-app/docs/api-users#show-current-user
+func (c *client) CurrentUser() (*userResponse, *http.Response, error) {
+	userResp := new(userResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(userResp)
+	return userResp, resp, err
+}
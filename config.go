@@ -1,5 +1,19 @@
 package gologin
 
+import (
+	"errors"
+)
+
+// HostPrefix is the cookie name prefix browsers reserve for cookies which
+// are Secure, have Path "/", and have no Domain.
+const HostPrefix = "__Host-"
+
+// Errors which may occur validating a CookieConfig.
+var (
+	ErrHostPrefixDomain = errors.New("gologin: __Host- prefixed cookies must not set a Domain")
+	ErrMarkerCodec      = errors.New("gologin: SignedMarker cookies require a Codec")
+)
+
 // CookieConfig configures http.Cookie creation.
 type CookieConfig struct {
 	// Name is the desired cookie name.
@@ -21,6 +35,34 @@ type CookieConfig struct {
 	// Secure flag indicating to the browser that the cookie should only be
 	// transmitted over a TLS HTTPS connection. Recommended true in production.
 	Secure bool
+	// AutoSecure sets the Secure flag only for requests received over TLS
+	// (or forwarded with "X-Forwarded-Proto: https"), overriding the Secure
+	// field. Useful when the same config serves local HTTP development.
+	AutoSecure bool
+	// UseHostPrefix prefixes the cookie Name with "__Host-" for the strictest
+	// browser cookie rules. Secure and Path "/" are then enforced regardless
+	// of the Secure and Path fields and Domain must be left zero valued.
+	UseHostPrefix bool
+	// Codec optionally encodes cookie values, for example to sign or encrypt
+	// them. Cookie values are not encoded when left nil.
+	Codec Codec
+	// SignedMarker issues a companion HttpOnly "-marker" cookie holding the
+	// Codec signed cookie value alongside state cookies. State cookies
+	// without a matching marker, such as ones injected by Javascript, are
+	// rejected as forged. Requires a Codec.
+	SignedMarker bool
+}
+
+// Validate returns an error if the CookieConfig would issue cookies browsers
+// reject.
+func (c CookieConfig) Validate() error {
+	if c.UseHostPrefix && c.Domain != "" {
+		return ErrHostPrefixDomain
+	}
+	if c.SignedMarker && c.Codec == nil {
+		return ErrMarkerCodec
+	}
+	return nil
 }
 
 // DefaultCookieConfig configures short-lived temporary http.Cookie creation.
@@ -0,0 +1,24 @@
+package gologin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieConfig_Validate(t *testing.T) {
+	assert.Nil(t, DefaultCookieConfig.Validate())
+	config := DefaultCookieConfig
+	config.UseHostPrefix = true
+	assert.Nil(t, config.Validate())
+	config.Domain = "example.com"
+	assert.Equal(t, ErrHostPrefixDomain, config.Validate())
+}
+
+func TestCookieConfig_ValidateSignedMarker(t *testing.T) {
+	config := DefaultCookieConfig
+	config.SignedMarker = true
+	assert.Equal(t, ErrMarkerCodec, config.Validate())
+	config.Codec = MultiCodec{}
+	assert.Nil(t, config.Validate())
+}
@@ -0,0 +1,113 @@
+package gologin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"goji.io"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// ErrMissingConfig is returned when no Config is registered for the
+// provider a request is for.
+var ErrMissingConfig = errors.New("gologin: no config registered for provider")
+
+// Configs is a registry of OAuth2 Configs keyed by provider name (e.g.
+// "github"), for apps which route several providers through the same
+// handlers. Register configs at startup, Configs is not safe for concurrent
+// writes.
+type Configs map[string]*oauth2.Config
+
+// Register adds the config for the named provider, replacing any config
+// already registered for it.
+func (c Configs) Register(provider string, config *oauth2.Config) {
+	c[provider] = config
+}
+
+// Lookup returns the config registered for the named provider or
+// ErrMissingConfig.
+func (c Configs) Lookup(provider string) (*oauth2.Config, error) {
+	config, ok := c[provider]
+	if !ok || config == nil {
+		return nil, ErrMissingConfig
+	}
+	return config, nil
+}
+
+// ProviderFunc returns the name of the provider a request is for.
+type ProviderFunc func(req *http.Request) string
+
+// PathSegment returns a ProviderFunc which reads the provider name from the
+// path segment at index. For example, PathSegment(1) reads "github" from
+// "/auth/github/callback". Returns "" if the path has too few segments.
+func PathSegment(index int) ProviderFunc {
+	return func(req *http.Request) string {
+		segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		if index < 0 || index >= len(segments) {
+			return ""
+		}
+		return segments[index]
+	}
+}
+
+// Handler is a ContextHandler that looks up the config for the provider
+// named by the request and adds it to the ctx with WithConfig. If a config
+// is registered, the success handler is called. Otherwise, the failure
+// handler is called with ErrMissingConfig.
+//
+//	configs := gologin.Configs{}
+//	configs.Register("github", githubConfig)
+//	configs.Register("google", googleConfig)
+//	// serve /auth/github/login, /auth/google/login, etc.
+//	handler := configs.Handler(gologin.PathSegment(1), login, nil)
+func (c Configs) Handler(provider ProviderFunc, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		config, err := c.Lookup(provider(req))
+		if err != nil {
+			ctx = WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithConfig(ctx, config)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// ConfiguredHandler returns a goji.Handler which builds the next handler with
+// the config from the ctx, so handler constructors which take a config
+// (e.g. github.StateHandler and github.LoginHandler) can be chained after
+// Configs.Handler. If the ctx has no config, the failure handler is called.
+//
+// The next handler is built once per config and reused. Registering a new
+// config for a provider builds a new handler on its first request.
+func ConfiguredHandler(build func(config *oauth2.Config) goji.Handler, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	var mu sync.Mutex
+	handlers := make(map[*oauth2.Config]goji.Handler)
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		config, err := ConfigFromContext(ctx)
+		if err != nil {
+			ctx = WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		mu.Lock()
+		handler, ok := handlers[config]
+		if !ok {
+			handler = build(config)
+			handlers[config] = handler
+		}
+		mu.Unlock()
+		handler.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
@@ -0,0 +1,146 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestConfigs_Lookup(t *testing.T) {
+	githubConfig := &oauth2.Config{ClientID: "github_client_id"}
+	configs := Configs{}
+	configs.Register("github", githubConfig)
+
+	config, err := configs.Lookup("github")
+	assert.Nil(t, err)
+	assert.Equal(t, githubConfig, config)
+
+	config, err = configs.Lookup("google")
+	assert.Nil(t, config)
+	assert.Equal(t, ErrMissingConfig, err)
+}
+
+func TestPathSegment(t *testing.T) {
+	cases := []struct {
+		index    int
+		path     string
+		expected string
+	}{
+		{1, "/auth/github/callback", "github"},
+		{1, "/auth/google/login/", "google"},
+		{0, "/github", "github"},
+		{2, "/auth/github", ""},
+		{-1, "/auth/github", ""},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", c.path, nil)
+		assert.Equal(t, c.expected, PathSegment(c.index)(req), c.path)
+	}
+}
+
+func TestConfigs_Handler(t *testing.T) {
+	configs := Configs{}
+	configs.Register("github", &oauth2.Config{ClientID: "github_client_id"})
+	configs.Register("google", &oauth2.Config{ClientID: "google_client_id"})
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		config, err := ConfigFromContext(ctx)
+		assert.Nil(t, err)
+		fmt.Fprintf(w, config.ClientID)
+	}
+	handler := configs.Handler(PathSegment(1), goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+
+	// Configs.Handler assert that:
+	// - the config for the provider in the path is added to the ctx
+	for _, provider := range []string{"github", "google"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/auth/"+provider+"/login", nil)
+		handler.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, provider+"_client_id", w.Body.String())
+	}
+}
+
+func TestConfigs_Handler_MissingConfig(t *testing.T) {
+	configs := Configs{}
+	configs.Register("github", &oauth2.Config{ClientID: "github_client_id"})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrMissingConfig, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := configs.Handler(PathSegment(1), testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+
+	// Configs.Handler for an unregistered provider calls the failure handler
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/gitlab/login", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestConfiguredHandler(t *testing.T) {
+	configs := Configs{}
+	configs.Register("github", &oauth2.Config{ClientID: "github_client_id"})
+	build := func(config *oauth2.Config) goji.Handler {
+		fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "built with "+config.ClientID)
+		}
+		return goji.HandlerFunc(fn)
+	}
+	handler := configs.Handler(PathSegment(1), ConfiguredHandler(build, testutils.AssertFailureNotCalled(t)), testutils.AssertFailureNotCalled(t))
+
+	// ConfiguredHandler builds the next handler with the config from the ctx
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/github/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "built with github_client_id", w.Body.String())
+}
+
+func TestConfiguredHandler_BuildsOncePerConfig(t *testing.T) {
+	configs := Configs{}
+	configs.Register("github", &oauth2.Config{ClientID: "github_client_id"})
+	builds := 0
+	build := func(config *oauth2.Config) goji.Handler {
+		builds++
+		fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "built with "+config.ClientID)
+		}
+		return goji.HandlerFunc(fn)
+	}
+	handler := configs.Handler(PathSegment(1), ConfiguredHandler(build, testutils.AssertFailureNotCalled(t)), testutils.AssertFailureNotCalled(t))
+	serve := func() string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/auth/github/callback", nil)
+		handler.ServeHTTP(context.Background(), w, req)
+		return w.Body.String()
+	}
+
+	// ConfiguredHandler assert that:
+	// - the next handler is reused across requests for the same config
+	// - a newly registered config gets a newly built handler
+	assert.Equal(t, "built with github_client_id", serve())
+	assert.Equal(t, "built with github_client_id", serve())
+	assert.Equal(t, 1, builds)
+	configs.Register("github", &oauth2.Config{ClientID: "rotated_client_id"})
+	assert.Equal(t, "built with rotated_client_id", serve())
+	assert.Equal(t, 2, builds)
+}
+
+func TestConfiguredHandler_MissingConfig(t *testing.T) {
+	build := func(config *oauth2.Config) goji.Handler {
+		t.Errorf("unexpected call to build")
+		return nil
+	}
+	handler := ConfiguredHandler(build, nil)
+
+	// ConfiguredHandler without a config in the ctx calls the failure handler
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/github/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Context missing OAuth2 Config\n", w.Body.String())
+}
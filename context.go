@@ -2,8 +2,10 @@ package gologin
 
 import (
 	"fmt"
+	"io"
 
 	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 )
 
 // unexported key type prevents collisions
@@ -11,10 +13,22 @@ type key int
 
 const (
 	errorKey key = iota
+	userKey
+	acceptLanguageKey
+	chainKey
+	accessKey
+	userInfoURLKey
+	scopesKey
+	userDecoderKey
+	configKey
 )
 
-// WithError returns a copy of ctx that stores the given error value.
+// WithError returns a copy of ctx that stores the given error value. The
+// error is stored as is; secret query parameter values in its message are
+// redacted where gologin renders it (see RedactErrors). Within an
+// AccessLogHandler, adding an error marks the callback as a failure.
 func WithError(ctx context.Context, err error) context.Context {
+	recordAccessError(ctx, err)
 	return context.WithValue(ctx, errorKey, err)
 }
 
@@ -27,3 +41,102 @@ func ErrorFromContext(ctx context.Context) error {
 	}
 	return err
 }
+
+// WithUser returns a copy of ctx that stores a provider user. Provider
+// packages add their User here too, so provider-agnostic handlers can inspect
+// it. Users whose types are defined by third-party API libraries are stored
+// wrapped in a provider type which embeds them.
+func WithUser(ctx context.Context, user interface{}) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the provider user from the ctx.
+func UserFromContext(ctx context.Context) (interface{}, error) {
+	user := ctx.Value(userKey)
+	if user == nil {
+		return nil, fmt.Errorf("Context missing provider user")
+	}
+	return user, nil
+}
+
+// WithAcceptLanguage returns a copy of ctx that stores the Accept-Language
+// header value (e.g. "de-DE,de;q=0.9,en;q=0.8") to send when fetching
+// provider profiles which localize names.
+func WithAcceptLanguage(ctx context.Context, acceptLanguage string) context.Context {
+	return context.WithValue(ctx, acceptLanguageKey, acceptLanguage)
+}
+
+// AcceptLanguageFromContext returns the Accept-Language header value from
+// the ctx or "" if none was set.
+func AcceptLanguageFromContext(ctx context.Context) string {
+	acceptLanguage, _ := ctx.Value(acceptLanguageKey).(string)
+	return acceptLanguage
+}
+
+// WithUserInfoURL returns a copy of ctx that stores a URL which provider
+// handlers fetch the provider user from instead of the standard userinfo
+// endpoint, such as a mock server, a regional host, or a pinned API version.
+// Providers whose users are fetched with third-party API libraries (github,
+// google, twitter, digits) ignore it.
+func WithUserInfoURL(ctx context.Context, userInfoURL string) context.Context {
+	return context.WithValue(ctx, userInfoURLKey, userInfoURL)
+}
+
+// UserInfoURLFromContext returns the userinfo URL override from the ctx or ""
+// if none was set.
+func UserInfoURLFromContext(ctx context.Context) string {
+	userInfoURL, _ := ctx.Value(userInfoURLKey).(string)
+	return userInfoURL
+}
+
+// UserDecoder decodes a provider API response body from r into v, a pointer
+// to the provider's response value (usually the provider user). Decoders may
+// read fields the provider user does not model, but must still populate v,
+// since provider handlers validate it as usual.
+type UserDecoder func(r io.Reader, v interface{}) error
+
+// WithUserDecoder returns a copy of ctx that stores a UserDecoder which
+// provider handlers decode provider API responses with instead of the
+// default JSON decoding. Like WithUserInfoURL, providers whose users are
+// fetched with third-party API libraries ignore it.
+func WithUserDecoder(ctx context.Context, decoder UserDecoder) context.Context {
+	return context.WithValue(ctx, userDecoderKey, decoder)
+}
+
+// UserDecoderFromContext returns the UserDecoder from the ctx or nil if none
+// was set.
+func UserDecoderFromContext(ctx context.Context) UserDecoder {
+	decoder, _ := ctx.Value(userDecoderKey).(UserDecoder)
+	return decoder
+}
+
+// WithScopes returns a copy of ctx that stores OAuth2 scopes. Set before an
+// oauth2 LoginHandler, they are the scopes requested for that login instead
+// of the Config Scopes. An oauth2 CallbackHandler sets the scopes granted to
+// the token. Within an AccessLogHandler, the scopes are logged too.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	recordAccessScopes(ctx, scopes)
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// ScopesFromContext returns the OAuth2 scopes from the ctx or nil if none
+// were set.
+func ScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopesKey).([]string)
+	return scopes
+}
+
+// WithConfig returns a copy of ctx that stores the OAuth2 Config for the
+// provider handling the request, as resolved by Configs.Handler.
+func WithConfig(ctx context.Context, config *oauth2.Config) context.Context {
+	return context.WithValue(ctx, configKey, config)
+}
+
+// ConfigFromContext returns the OAuth2 Config from the ctx.
+func ConfigFromContext(ctx context.Context) (*oauth2.Config, error) {
+	config, ok := ctx.Value(configKey).(*oauth2.Config)
+	if !ok || config == nil {
+		return nil, fmt.Errorf("Context missing OAuth2 Config")
+	}
+	return config, nil
+}
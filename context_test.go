@@ -2,10 +2,13 @@ package gologin
 
 import (
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 )
 
 func TestContextError(t *testing.T) {
@@ -21,3 +24,67 @@ func TestErrorFromContext_Error(t *testing.T) {
 		assert.Equal(t, "Context missing error value", err.Error())
 	}
 }
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &struct{ ID string }{ID: "42"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestUserFromContext_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Context missing provider user", err.Error())
+	}
+}
+
+func TestContextAcceptLanguage(t *testing.T) {
+	assert.Equal(t, "", AcceptLanguageFromContext(context.Background()))
+	ctx := WithAcceptLanguage(context.Background(), "de-DE,de;q=0.9")
+	assert.Equal(t, "de-DE,de;q=0.9", AcceptLanguageFromContext(ctx))
+}
+
+func TestContextUserInfoURL(t *testing.T) {
+	assert.Equal(t, "", UserInfoURLFromContext(context.Background()))
+	ctx := WithUserInfoURL(context.Background(), "http://127.0.0.1:8080/me")
+	assert.Equal(t, "http://127.0.0.1:8080/me", UserInfoURLFromContext(ctx))
+}
+
+func TestContextUserDecoder(t *testing.T) {
+	assert.Nil(t, UserDecoderFromContext(context.Background()))
+	called := false
+	decoder := func(r io.Reader, v interface{}) error {
+		called = true
+		return nil
+	}
+	ctx := WithUserDecoder(context.Background(), decoder)
+	if assert.NotNil(t, UserDecoderFromContext(ctx)) {
+		UserDecoderFromContext(ctx)(strings.NewReader("{}"), nil)
+		assert.True(t, called)
+	}
+}
+
+func TestContextScopes(t *testing.T) {
+	assert.Nil(t, ScopesFromContext(context.Background()))
+	ctx := WithScopes(context.Background(), []string{"read:user", "user:email"})
+	assert.Equal(t, []string{"read:user", "user:email"}, ScopesFromContext(ctx))
+}
+
+func TestContextConfig(t *testing.T) {
+	expectedConfig := &oauth2.Config{ClientID: "client_id"}
+	ctx := WithConfig(context.Background(), expectedConfig)
+	config, err := ConfigFromContext(ctx)
+	assert.Equal(t, expectedConfig, config)
+	assert.Nil(t, err)
+}
+
+func TestContextConfig_Error(t *testing.T) {
+	config, err := ConfigFromContext(context.Background())
+	assert.Nil(t, config)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Context missing OAuth2 Config", err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+package gologin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"goji.io"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// DebugProvider is a provider config described by a DebugConfigHandler.
+type DebugProvider struct {
+	// Name identifies the provider in the debug response (e.g. "github")
+	Name string
+	// Config is the OAuth2 Config the provider's handlers use
+	Config *oauth2.Config
+}
+
+// debugConfig is the JSON description of a provider config. Client secrets
+// are never included, only whether one is set.
+type debugConfig struct {
+	Name         string   `json:"name"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	AuthURL      string   `json:"auth_url"`
+	TokenURL     string   `json:"token_url"`
+	AuthHost     string   `json:"auth_host"`
+	TokenHost    string   `json:"token_host"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+}
+
+// debugResponse is the JSON body written by a DebugConfigHandler.
+type debugResponse struct {
+	Providers []debugConfig `json:"providers"`
+}
+
+// DebugConfigHandler returns a handler which responds with JSON describing
+// each provider's effective config (auth and token URLs and hosts, redirect
+// URL, and scopes) to help verify deployment wiring. Client secrets are
+// redacted. Mount it on an internal-only route.
+func DebugConfigHandler(providers ...DebugProvider) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		body := debugResponse{Providers: make([]debugConfig, 0, len(providers))}
+		for _, provider := range providers {
+			body.Providers = append(body.Providers, describeConfig(provider))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// describeConfig returns the description of the provider's config with the
+// client secret redacted.
+func describeConfig(provider DebugProvider) debugConfig {
+	described := debugConfig{Name: provider.Name, Scopes: []string{}}
+	config := provider.Config
+	if config == nil {
+		return described
+	}
+	described.ClientID = config.ClientID
+	if config.ClientSecret != "" {
+		described.ClientSecret = redacted
+	}
+	described.AuthURL = config.Endpoint.AuthURL
+	described.TokenURL = config.Endpoint.TokenURL
+	described.AuthHost = urlHost(config.Endpoint.AuthURL)
+	described.TokenHost = urlHost(config.Endpoint.TokenURL)
+	described.RedirectURL = config.RedirectURL
+	if config.Scopes != nil {
+		described.Scopes = config.Scopes
+	}
+	return described
+}
+
+// urlHost returns the host of rawurl or "" if it cannot be parsed.
+func urlHost(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,86 @@
+package gologin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestDebugConfigHandler(t *testing.T) {
+	githubConfig := &oauth2.Config{
+		ClientID:     "github_client_id",
+		ClientSecret: "github_client_secret",
+		RedirectURL:  "https://example.com/github/callback",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+		},
+		Scopes: []string{"read:user", "user:email"},
+	}
+	googleConfig := &oauth2.Config{
+		ClientID:     "google_client_id",
+		ClientSecret: "google_client_secret",
+		RedirectURL:  "https://example.com/google/callback",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+		},
+	}
+	handler := DebugConfigHandler(
+		DebugProvider{Name: "github", Config: githubConfig},
+		DebugProvider{Name: "google", Config: googleConfig},
+	)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/debug/config", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+
+	// assert secrets are never written
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.HeaderMap.Get("Content-Type"))
+	assert.False(t, strings.Contains(w.Body.String(), "github_client_secret"))
+	assert.False(t, strings.Contains(w.Body.String(), "google_client_secret"))
+	// assert all non-secret fields are written
+	var body debugResponse
+	err := json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	expected := []debugConfig{
+		{
+			Name:         "github",
+			ClientID:     "github_client_id",
+			ClientSecret: "REDACTED",
+			AuthURL:      "https://github.com/login/oauth/authorize",
+			TokenURL:     "https://github.com/login/oauth/access_token",
+			AuthHost:     "github.com",
+			TokenHost:    "github.com",
+			RedirectURL:  "https://example.com/github/callback",
+			Scopes:       []string{"read:user", "user:email"},
+		},
+		{
+			Name:         "google",
+			ClientID:     "google_client_id",
+			ClientSecret: "REDACTED",
+			AuthURL:      "https://accounts.google.com/o/oauth2/auth",
+			TokenURL:     "https://oauth2.googleapis.com/token",
+			AuthHost:     "accounts.google.com",
+			TokenHost:    "oauth2.googleapis.com",
+			RedirectURL:  "https://example.com/google/callback",
+			Scopes:       []string{},
+		},
+	}
+	assert.Equal(t, expected, body.Providers)
+}
+
+func TestDebugConfigHandler_Empty(t *testing.T) {
+	handler := DebugConfigHandler(DebugProvider{Name: "github"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/debug/config", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	expected := `{"providers": [{"name": "github", "client_id": "", "client_secret": "", "auth_url": "", "token_url": "", "auth_host": "", "token_host": "", "redirect_url": "", "scopes": []}]}`
+	assert.JSONEq(t, expected, w.Body.String())
+}
@@ -0,0 +1,32 @@
+package digitalocean
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{UUID: "b6fr89dbf6d9156cace5f3c78dc9851d957381ef"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "digitalocean: Context missing DigitalOcean User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{UUID: "b6fr89dbf6d9156cace5f3c78dc9851d957381ef"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "digitalocean", ID: "b6fr89dbf6d9156cace5f3c78dc9851d957381ef"}, identity)
+}
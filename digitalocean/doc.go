@@ -0,0 +1,6 @@
+// Package digitalocean provides DigitalOcean OAuth2 login and callback
+// handlers.
+//
+// DigitalOcean requires client credentials be sent in the token request
+// parameters. Accounts which are not active are rejected.
+package digitalocean
@@ -0,0 +1,105 @@
+package digitalocean
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// DigitalOcean login errors
+var (
+	ErrUnableToGetDigitalOceanUser = errors.New("digitalocean: unable to get DigitalOcean User")
+	ErrDigitalOceanAccountInactive = errors.New("digitalocean: DigitalOcean account is not active")
+)
+
+// Provider is the DigitalOcean OAuth2 Provider for use with oauth2
+// HandleCallback. DigitalOcean requires client credentials in the token
+// request body.
+var Provider = oauth2Login.Provider{
+	Name:            "digitalocean",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInParams,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles DigitalOcean login requests by reading the state value
+// from the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles DigitalOcean redirection URI requests and adds the
+// DigitalOcean access token and User to the ctx. If authentication succeeds
+// and the account is active, handling delegates to the success handler,
+// otherwise to the failure handler.
+//
+// Configs which auto-detect the AuthStyle use AuthStyleInParams.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	config = oauth2Login.Provider{AuthStyle: oauth2.AuthStyleInParams}.Configure(config)
+	success = digitaloceanHandler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// digitaloceanHandler is a ContextHandler that gets the OAuth2 Token from the
+// ctx to get the corresponding DigitalOcean User. If successful, the User is
+// added to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
+func digitaloceanHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		digitaloceanClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		digitaloceanClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		accountResp, resp, err := digitaloceanClient.Account()
+		err = validateResponse(accountResp, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, &accountResp.Account)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given DigitalOcean account
+// response, raw http.Response, or error are unexpected, or if the account is
+// not active. Returns nil if they are valid.
+func validateResponse(accountResp *accountResponse, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetDigitalOceanUser
+	}
+	if accountResp == nil || accountResp.Account.UUID == "" {
+		return ErrUnableToGetDigitalOceanUser
+	}
+	if accountResp.Account.Status != statusActive {
+		return ErrDigitalOceanAccountInactive
+	}
+	return nil
+}
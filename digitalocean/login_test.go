@@ -0,0 +1,144 @@
+package digitalocean
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"account": {"droplet_limit": 25, "uuid": "b6fr89dbf6d9156cace5f3c78dc9851d957381ef", "email": "sammy@digitalocean.com", "email_verified": true, "status": "active"}}`
+	expectedUser := &User{
+		UUID:          "b6fr89dbf6d9156cace5f3c78dc9851d957381ef",
+		Email:         "sammy@digitalocean.com",
+		EmailVerified: true,
+		Status:        "active",
+	}
+	proxyClient, server := newDigitalOceanTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	// Endpoint without an AuthStyle, so the DigitalOcean default must be applied
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: Endpoint.AuthURL, TokenURL: Endpoint.TokenURL},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		digitaloceanUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, digitaloceanUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler exchanges the code with body credentials, assert that:
+	// - the nested DigitalOcean account User is added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_InactiveAccount(t *testing.T) {
+	for _, status := range []string{"locked", "warning", ""} {
+		jsonData := fmt.Sprintf(`{"account": {"uuid": "b6fr89dbf6d9156cace5f3c78dc9851d957381ef", "email": "sammy@digitalocean.com", "status": %q}}`, status)
+		proxyClient, server := newDigitalOceanTestServer(jsonData)
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+		config := &oauth2.Config{
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+			Endpoint:     Endpoint,
+		}
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, ErrDigitalOceanAccountInactive, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// CallbackHandler gets an account which is not active, assert that:
+		// - failure handler is called with ErrDigitalOceanAccountInactive
+		handler := CallbackHandler(config, success, goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestDigitalOceanHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// DigitalOceanHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	digitaloceanHandler := digitaloceanHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	digitaloceanHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestDigitalOceanHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("DigitalOcean Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetDigitalOceanUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// DigitalOceanHandler cannot get DigitalOcean User, assert that:
+	// - failure handler is called
+	// - error cannot get DigitalOcean User added to the failure handler ctx
+	digitaloceanHandler := digitaloceanHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	digitaloceanHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validAccount := &accountResponse{Account: User{UUID: "b6fr89dbf6d9156cace5f3c78dc9851d957381ef", Status: "active"}}
+	lockedAccount := &accountResponse{Account: User{UUID: "b6fr89dbf6d9156cace5f3c78dc9851d957381ef", Status: "locked"}}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validAccount, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetDigitalOceanUser, validateResponse(validAccount, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetDigitalOceanUser, validateResponse(validAccount, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetDigitalOceanUser, validateResponse(&accountResponse{}, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetDigitalOceanUser, validateResponse(&accountResponse{Account: User{Status: "active"}}, validResponse, nil))
+	assert.Equal(t, ErrDigitalOceanAccountInactive, validateResponse(lockedAccount, validResponse, nil))
+}
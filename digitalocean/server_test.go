@@ -0,0 +1,36 @@
+package digitalocean
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newDigitalOceanTestServer returns a new httptest.Server which mocks the
+// DigitalOcean token endpoint, requiring client credentials in the body, and
+// the account endpoint, which responds with the given json data. It also
+// returns a client which proxies requests to the server. The caller must
+// close the server.
+func newDigitalOceanTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": "invalid_request", "error_description": "Client authentication failed"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "digitalocean-token", "token_type": "bearer", "expires_in": 2592000}`)
+	})
+	mux.HandleFunc("/v2/account", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer digitalocean-token" {
+			http.Error(w, `{"id": "unauthorized", "message": "Unable to authenticate you"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
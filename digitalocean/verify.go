@@ -0,0 +1,76 @@
+package digitalocean
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const digitaloceanAPI = "https://api.digitalocean.com/"
+
+// statusActive is the status of DigitalOcean accounts in good standing
+const statusActive = "active"
+
+// Endpoint is the DigitalOcean OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://cloud.digitalocean.com/v1/oauth/authorize",
+	TokenURL:  "https://cloud.digitalocean.com/v1/oauth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("digitalocean", Endpoint)
+}
+
+// User is a DigitalOcean account. Status is "active", "warning" or "locked".
+type User struct {
+	UUID          string               `json:"uuid"`
+	Email         string               `json:"email"`
+	EmailVerified gologin.FlexibleBool `json:"email_verified"`
+	Status        string               `json:"status"`
+}
+
+// Identity returns the DigitalOcean identity keyed by the account UUID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.UUID}
+}
+
+// EmailAddress returns the DigitalOcean account's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// accountResponse is a DigitalOcean API response, which wraps the User in
+// account.
+type accountResponse struct {
+	Account User `json:"account"`
+}
+
+// client is a DigitalOcean client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(digitaloceanAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "v2/account"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Account gets the authenticated account User.
+// https://docs.digitalocean.com/reference/api/api-reference/#operation/account_get
+func (c *client) Account() (*accountResponse, *http.Response, error) {
+	accountResp := new(accountResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(accountResp)
+	return accountResp, resp, err
+}
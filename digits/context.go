@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/dghubble/go-digits/digits"
+	"github.com/quasor/gologin"
 	"golang.org/x/net/context"
 )
 
@@ -18,6 +19,7 @@ const (
 
 // WithAccount returns a copy of ctx that stores the Digits Account.
 func WithAccount(ctx context.Context, account *digits.Account) context.Context {
+	ctx = gologin.WithUser(ctx, &providerAccount{account})
 	return context.WithValue(ctx, accountKey, account)
 }
 
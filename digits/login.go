@@ -47,11 +47,6 @@ func LoginHandler(config *Config, success, failure goji.Handler) goji.Handler {
 		failure = gologin.DefaultFailureHandler
 	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		if req.Method != "POST" {
-			ctx = gologin.WithError(ctx, fmt.Errorf("Method not allowed"))
-			failure.ServeHTTP(ctx, w, req)
-			return
-		}
 		req.ParseForm()
 		accountEndpoint := req.PostForm.Get(accountEndpointField)
 		accountRequestHeader := req.PostForm.Get(accountRequestHeaderField)
@@ -65,7 +60,7 @@ func LoginHandler(config *Config, success, failure goji.Handler) goji.Handler {
 		ctx = WithEcho(ctx, accountEndpoint, accountRequestHeader)
 		success.ServeHTTP(ctx, w, req)
 	}
-	return goji.HandlerFunc(fn)
+	return gologin.MethodHandler([]string{"POST"}, goji.HandlerFunc(fn), failure)
 }
 
 // getAccountViaEcho is a ContextHandler that gets the Digits Echo endpoint and
@@ -7,6 +7,7 @@ import (
 	"goji.io"
 	"github.com/dghubble/go-digits/digits"
 	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
 	oauth1Login "github.com/quasor/gologin/oauth1"
 	"github.com/dghubble/oauth1"
 	"golang.org/x/net/context"
@@ -33,11 +34,6 @@ func TokenHandler(config *oauth1.Config, success, failure goji.Handler) goji.Han
 		failure = gologin.DefaultFailureHandler
 	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		if req.Method != "POST" {
-			ctx = gologin.WithError(ctx, fmt.Errorf("Method not allowed"))
-			failure.ServeHTTP(ctx, w, req)
-			return
-		}
 		req.ParseForm()
 		accessToken := req.PostForm.Get(accessTokenField)
 		accessSecret := req.PostForm.Get(accessTokenSecretField)
@@ -48,9 +44,11 @@ func TokenHandler(config *oauth1.Config, success, failure goji.Handler) goji.Han
 			return
 		}
 		ctx = oauth1Login.WithAccessToken(ctx, accessToken, accessSecret)
+		// provider requests set the gologin User-Agent
+		ctx = internal.WithUserAgentClient(ctx, oauth1.HTTPClient)
 		success.ServeHTTP(ctx, w, req)
 	}
-	return goji.HandlerFunc(fn)
+	return gologin.MethodHandler([]string{"POST"}, goji.HandlerFunc(fn), failure)
 }
 
 // digitsHandler is a ContextHandler that gets the OAuth1 access token from the
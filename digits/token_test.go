@@ -79,8 +79,8 @@ func TestTokenHandler_NonPost(t *testing.T) {
 	assert.Nil(t, err)
 	// assert that default (nil) failure handler returns a 405 Method Not Allowed
 	if assert.NotNil(t, resp) {
-		// TODO: change to 405
-		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "POST", resp.Header.Get("Allow"))
 	}
 }
 
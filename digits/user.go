@@ -0,0 +1,19 @@
+package digits
+
+import (
+	"github.com/dghubble/go-digits/digits"
+	"github.com/quasor/gologin"
+)
+
+// providerAccount wraps a Digits Account to implement the gologin provider
+// user interfaces. It is the provider-agnostic user WithAccount adds to the
+// ctx.
+type providerAccount struct {
+	*digits.Account
+}
+
+// Identity returns the Digits identity keyed by the account ID, which is
+// stable across phone number changes.
+func (a *providerAccount) Identity() gologin.Identity {
+	return gologin.Identity{Provider: "digits", ID: a.IDStr}
+}
@@ -0,0 +1,17 @@
+package digits
+
+import (
+	"testing"
+
+	"github.com/dghubble/go-digits/digits"
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProviderAccount_Identity(t *testing.T) {
+	ctx := WithAccount(context.Background(), &digits.Account{ID: 1234, IDStr: "1234", PhoneNumber: "0123456789"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "digits", ID: "1234"}, identity)
+}
@@ -0,0 +1,38 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "80351110224678912"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "discord: Context missing Discord User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "80351110224678912"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "discord", ID: "80351110224678912"}, identity)
+}
+
+func TestUser_PictureURL(t *testing.T) {
+	user := &User{ID: "80351110224678912", Avatar: "8342729096ea3675442027381ff50dfe"}
+	assert.Equal(t, "https://cdn.discordapp.com/avatars/80351110224678912/8342729096ea3675442027381ff50dfe.png", user.PictureURL())
+	assert.Equal(t, "", (&User{ID: "80351110224678912"}).PictureURL())
+}
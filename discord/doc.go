@@ -0,0 +1,5 @@
+// Package discord provides Discord OAuth2 login and callback handlers.
+//
+// Discord rate limits API requests aggressively. A rate limited user request
+// fails the login with ErrUnableToGetDiscordUser.
+package discord
@@ -0,0 +1,177 @@
+package discord
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestDiscordHandler(t *testing.T) {
+	jsonData := `{"id": "80351110224678912", "username": "Nelly", "discriminator": "1337", "email": "nelly@discord.com", "avatar": "8342729096ea3675442027381ff50dfe", "verified": true}`
+	expectedUser := &User{
+		ID:            "80351110224678912",
+		Username:      "Nelly",
+		Discriminator: "1337",
+		Email:         "nelly@discord.com",
+		Avatar:        "8342729096ea3675442027381ff50dfe",
+	}
+	proxyClient, server := newDiscordTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		discordUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, discordUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// DiscordHandler assert that:
+	// - Token is read from the ctx and passed to the Discord API
+	// - Discord User is obtained from the Discord API
+	// - success handler is called
+	// - Discord User is added to the ctx of the success handler
+	discordHandler := discordHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	discordHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestDiscordHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// DiscordHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	discordHandler := discordHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	discordHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestDiscordHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Discord Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetDiscordUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// DiscordHandler cannot get Discord User, assert that:
+	// - failure handler is called
+	// - error cannot get Discord User added to the failure handler ctx
+	discordHandler := discordHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	discordHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestDiscordHandler_RateLimited(t *testing.T) {
+	proxyClient, server := newRateLimitedTestServer()
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetDiscordUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// DiscordHandler rate limited with a 429, assert that:
+	// - failure handler is called
+	// - error cannot get Discord User added to the failure handler ctx
+	discordHandler := discordHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	discordHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "80351110224678912"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	rateLimitedResponse := &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": {"2"}}}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetDiscordUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetDiscordUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetDiscordUser, validateResponse(&User{}, rateLimitedResponse, nil))
+	assert.Equal(t, ErrUnableToGetDiscordUser, validateResponse(&User{}, validResponse, nil))
+}
+
+func TestCallbackHandler_UserAgent(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/api/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, gologin.UserAgent, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "discord-token", "token_type": "Bearer"}`)
+	})
+	userInfoRequests := 0
+	mux.HandleFunc("/api/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		userInfoRequests++
+		assert.Equal(t, gologin.UserAgent, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "80351110224678912", "username": "Nelly"}`)
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: "client-id", Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler assert that:
+	// - the token exchange and user info request set the gologin User-Agent
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Equal(t, 1, userInfoRequests)
+}
@@ -0,0 +1,36 @@
+package discord
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newDiscordTestServer returns a new httptest.Server which mocks the Discord
+// current user endpoint and a client which proxies requests to the server.
+// The server responds with the given json data. The caller must close the
+// server.
+func newDiscordTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/api/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
+
+// newRateLimitedTestServer returns a new httptest.Server which rate limits
+// all requests with a 429 and Retry-After header, like Discord, and a client
+// which proxies requests to the server. The caller must close the server.
+func newRateLimitedTestServer() (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprintf(w, `{"message": "You are being rate limited.", "retry_after": 1.5, "global": false}`)
+	})
+	return client, server
+}
@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const (
+	discordAPI = "https://discord.com/api/"
+	discordCDN = "https://cdn.discordapp.com/"
+)
+
+// Endpoint is the Discord OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://discord.com/oauth2/authorize",
+	TokenURL: "https://discord.com/api/oauth2/token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("discord", Endpoint)
+}
+
+// User is a Discord user. Email is only present with the email scope.
+type User struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Discriminator string `json:"discriminator"`
+	Email         string `json:"email"`
+	Avatar        string `json:"avatar"`
+}
+
+// Identity returns the Discord identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Discord user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// PictureURL returns the Discord user's avatar image URL or "" if the user
+// has no avatar.
+func (u *User) PictureURL() string {
+	if u.Avatar == "" {
+		return ""
+	}
+	return discordCDN + "avatars/" + u.ID + "/" + u.Avatar + ".png"
+}
+
+// client is a Discord client for obtaining the current User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(discordAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users/@me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// CurrentUser gets the authenticated User. Error responses, such as a 429
+// rate limit response, are not decoded.
+// https://discord.com/developers/docs/resources/user#get-current-user
+func (c *client) CurrentUser() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
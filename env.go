@@ -0,0 +1,71 @@
+package gologin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// Errors which may occur reading a provider config from the environment.
+var (
+	ErrUnknownProvider = errors.New("gologin: unknown provider endpoint")
+)
+
+// endpoints maps provider names to their OAuth2 endpoints for ConfigFromEnv.
+var (
+	endpointsMu sync.RWMutex
+	endpoints   = make(map[string]oauth2.Endpoint)
+)
+
+// RegisterEndpoint makes the provider's OAuth2 endpoint available to
+// ConfigFromEnv by name. Provider packages register their Endpoint when
+// imported; call it to support other providers or to replace an endpoint.
+func RegisterEndpoint(provider string, endpoint oauth2.Endpoint) {
+	endpointsMu.Lock()
+	defer endpointsMu.Unlock()
+	endpoints[strings.ToLower(provider)] = endpoint
+}
+
+// lookupEndpoint returns the registered endpoint of the provider.
+func lookupEndpoint(provider string) (oauth2.Endpoint, bool) {
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+	endpoint, ok := endpoints[strings.ToLower(provider)]
+	return endpoint, ok
+}
+
+// ConfigFromEnv returns an OAuth2 Config for the named provider (e.g.
+// "github") with the provider's registered endpoint. Import the provider
+// package (e.g. gologin/github) to register its endpoint. The client
+// credentials, redirect URL, and comma or space separated scopes are read
+// from the {PROVIDER}_CLIENT_ID, {PROVIDER}_CLIENT_SECRET,
+// {PROVIDER}_REDIRECT_URL, and {PROVIDER}_SCOPES environment variables.
+func ConfigFromEnv(provider string) (*oauth2.Config, error) {
+	endpoint, ok := lookupEndpoint(provider)
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	prefix := strings.ToUpper(provider) + "_"
+	config := &oauth2.Config{
+		ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+		ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		Endpoint:     endpoint,
+		Scopes:       splitScopes(os.Getenv(prefix + "SCOPES")),
+	}
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("gologin: missing %sCLIENT_ID environment variable", prefix)
+	}
+	return config, nil
+}
+
+// splitScopes splits a comma or space separated list of scopes.
+func splitScopes(scopes string) []string {
+	return strings.FieldsFunc(scopes, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
@@ -0,0 +1,80 @@
+package gologin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func setenv(env map[string]string) func() {
+	for key, value := range env {
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key := range env {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+var testEndpoint = oauth2.Endpoint{
+	AuthURL:  "https://example.com/oauth2/authorize",
+	TokenURL: "https://example.com/oauth2/token",
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	RegisterEndpoint("github", testEndpoint)
+	defer setenv(map[string]string{
+		"GITHUB_CLIENT_ID":     "client-id",
+		"GITHUB_CLIENT_SECRET": "client-secret",
+		"GITHUB_REDIRECT_URL":  "http://localhost:8080/github/callback",
+		"GITHUB_SCOPES":        "read:org, user:email repo",
+	})()
+
+	expected := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/github/callback",
+		Endpoint:     testEndpoint,
+		Scopes:       []string{"read:org", "user:email", "repo"},
+	}
+	config, err := ConfigFromEnv("github")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, config)
+}
+
+func TestConfigFromEnv_NoScopes(t *testing.T) {
+	RegisterEndpoint("google", testEndpoint)
+	defer setenv(map[string]string{"GOOGLE_CLIENT_ID": "client-id"})()
+	config, err := ConfigFromEnv("google")
+	assert.Nil(t, err)
+	assert.Equal(t, "client-id", config.ClientID)
+	assert.Equal(t, testEndpoint, config.Endpoint)
+	assert.Empty(t, config.Scopes)
+}
+
+func TestConfigFromEnv_Errors(t *testing.T) {
+	_, err := ConfigFromEnv("myspace")
+	assert.Equal(t, ErrUnknownProvider, err)
+
+	RegisterEndpoint("facebook", testEndpoint)
+	os.Unsetenv("FACEBOOK_CLIENT_ID")
+	_, err = ConfigFromEnv("facebook")
+	if assert.Error(t, err) {
+		assert.Equal(t, "gologin: missing FACEBOOK_CLIENT_ID environment variable", err.Error())
+	}
+}
+
+func TestRegisterEndpoint(t *testing.T) {
+	_, err := ConfigFromEnv("myprovider")
+	assert.Equal(t, ErrUnknownProvider, err)
+
+	// provider names are case-insensitive
+	RegisterEndpoint("MyProvider", testEndpoint)
+	defer setenv(map[string]string{"MYPROVIDER_CLIENT_ID": "client-id"})()
+	config, err := ConfigFromEnv("myprovider")
+	assert.Nil(t, err)
+	assert.Equal(t, testEndpoint, config.Endpoint)
+}
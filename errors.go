@@ -8,13 +8,24 @@ import (
 )
 
 // DefaultFailureHandler responds with a 400 status code and message parsed
-// from the ctx.
+// from the ctx, a 405 status code for ErrMethodNotAllowed, or a 401 status
+// code for an InvalidTokenError. Secret query parameter values in the
+// message are redacted (see RedactErrors).
 var DefaultFailureHandler = goji.HandlerFunc(failureHandler)
 
 func failureHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) {
 	err := ErrorFromContext(ctx)
+	if err == ErrMethodNotAllowed {
+		http.Error(w, err.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+	switch err.(type) {
+	case InvalidTokenError, *InvalidTokenError:
+		http.Error(w, redactErrorMessage(err), http.StatusUnauthorized)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, redactErrorMessage(err), http.StatusBadRequest)
 		return
 	}
 	// should be unreachable, ErrorFromContext always returns some non-nil error
@@ -21,3 +21,14 @@ func TestDefaultFailureHandler(t *testing.T) {
 	// assert that error message was passed through
 	assert.Equal(t, expectedError.Error()+"\n", w.Body.String())
 }
+
+func TestDefaultFailureHandler_InvalidToken(t *testing.T) {
+	for _, err := range []error{InvalidTokenError{Err: fmt.Errorf("expired token")}, &InvalidTokenError{Err: fmt.Errorf("expired token")}} {
+		ctx := WithError(context.Background(), err)
+		req, _ := http.NewRequest("POST", "/", nil)
+		w := httptest.NewRecorder()
+		DefaultFailureHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "expired token\n", w.Body.String())
+	}
+}
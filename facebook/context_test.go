@@ -3,6 +3,7 @@ package facebook
 import (
 	"testing"
 
+	"github.com/quasor/gologin"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
 )
@@ -22,3 +23,27 @@ func TestContextUser_Error(t *testing.T) {
 		assert.Equal(t, "facebook: Context missing Facebook User", err.Error())
 	}
 }
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "54638", Name: "Ivy Crimson"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "facebook", ID: "54638"}, identity)
+}
+
+func TestUserEmail(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "12", Name: "Gopher", Email: "gopher@example.com"})
+	email, ok := gologin.UserEmail(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "gopher@example.com", email)
+}
+
+func TestUserPicture(t *testing.T) {
+	user := &User{ID: "12", Name: "Gopher"}
+	_, ok := gologin.UserPicture(WithUser(context.Background(), user))
+	assert.False(t, ok)
+	user.Picture.Data.URL = "https://example.com/gopher.jpg"
+	picture, ok := gologin.UserPicture(WithUser(context.Background(), user))
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com/gopher.jpg", picture)
+}
@@ -6,6 +6,7 @@ import (
 
 	"goji.io"
 	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
 	oauth2Login "github.com/quasor/gologin/oauth2"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
@@ -14,8 +15,39 @@ import (
 // Facebook login errors
 var (
 	ErrUnableToGetFacebookUser = errors.New("facebook: unable to get Facebook User")
+	// ErrFacebookTokenExpired is returned when the Graph API reports the
+	// access token expired (code 190, subcode 463).
+	ErrFacebookTokenExpired error = graphFailure("facebook: unable to get Facebook User, access token expired")
+	// ErrFacebookTokenInvalid is returned when the Graph API reports the
+	// access token is invalid for another reason (code 190, e.g. subcode 460
+	// when the user changed their password).
+	ErrFacebookTokenInvalid error = graphFailure("facebook: unable to get Facebook User, access token invalid")
+	// ErrFacebookPermission is returned when the Graph API reports the app
+	// lacks a permission (code 10 or 200-299).
+	ErrFacebookPermission error = graphFailure("facebook: unable to get Facebook User, permission denied")
 )
 
+// graphFailure is a refinement of ErrUnableToGetFacebookUser for well-known
+// Graph API error codes.
+type graphFailure string
+
+func (e graphFailure) Error() string {
+	return string(e)
+}
+
+// Unwrap returns ErrUnableToGetFacebookUser, which e refines.
+func (e graphFailure) Unwrap() error {
+	return ErrUnableToGetFacebookUser
+}
+
+// Provider is the Facebook OAuth2 Provider for use with oauth2 HandleCallback.
+// Facebook requires client credentials in the token request body.
+var Provider = oauth2Login.Provider{
+	Name:            "facebook",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInParams,
+}
+
 // StateHandler checks for a state cookie. If found, the state value is read
 // and added to the ctx. Otherwise, a non-guessable value is added to the ctx
 // and to a (short-lived) state cookie issued to the requester.
@@ -59,7 +91,8 @@ func facebookHandler(config *oauth2.Config, success, failure goji.Handler) goji.
 			return
 		}
 		httpClient := config.Client(ctx, token)
-		facebookService := newClient(httpClient)
+		facebookService := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		facebookService.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
 		user, resp, err := facebookService.Me()
 		err = validateResponse(user, resp, err)
 		if err != nil {
@@ -76,6 +109,9 @@ func facebookHandler(config *oauth2.Config, success, failure goji.Handler) goji.
 // validateResponse returns an error if the given Facebook User, raw
 // http.Response, or error are unexpected. Returns nil if they are valid.
 func validateResponse(user *User, resp *http.Response, err error) error {
+	if apiErr, ok := err.(*graphError); ok {
+		return graphErrorFailure(apiErr)
+	}
 	if err != nil || resp.StatusCode != http.StatusOK {
 		return ErrUnableToGetFacebookUser
 	}
@@ -84,3 +120,17 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// graphErrorFailure maps well-known Graph API error codes and subcodes to
+// typed errors. Unrecognized codes map to ErrUnableToGetFacebookUser.
+func graphErrorFailure(apiErr *graphError) error {
+	switch code, subcode := apiErr.Err.Code, apiErr.Err.Subcode; {
+	case code == 190 && subcode == 463:
+		return ErrFacebookTokenExpired
+	case code == 190:
+		return ErrFacebookTokenInvalid
+	case code == 10 || (code >= 200 && code <= 299):
+		return ErrFacebookPermission
+	}
+	return ErrUnableToGetFacebookUser
+}
@@ -1,7 +1,10 @@
 package facebook
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -46,6 +49,95 @@ func TestFacebookHandler(t *testing.T) {
 	assert.Equal(t, "success handler called", w.Body.String())
 }
 
+func TestFacebookHandler_Email(t *testing.T) {
+	proxyClient, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/v2.4/me", func(w http.ResponseWriter, r *http.Request) {
+		// assert the email and picture fields are requested
+		assert.Equal(t, "id,name,email,picture", r.URL.Query().Get("fields"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "54638001", "name": "Ivy Crimson", "email": "ivy@example.com", "picture": {"data": {"url": "https://example.com/ivy.jpg"}}}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		email, ok := gologin.UserEmail(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "ivy@example.com", email)
+		picture, ok := gologin.UserPicture(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "https://example.com/ivy.jpg", picture)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := facebookHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestFacebookHandler_UserDecoder(t *testing.T) {
+	jsonData := `{"id": "54638001", "name": "Ivy Crimson", "locale": "en_US"}`
+	proxyClient, server := newFacebookTestServer(jsonData)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	// custom decoder which captures a field the User does not model
+	var locale string
+	decoder := func(r io.Reader, v interface{}) error {
+		body, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		extra := struct {
+			Locale string `json:"locale"`
+		}{}
+		if err := json.Unmarshal(body, &extra); err != nil {
+			return err
+		}
+		locale = extra.Locale
+		return json.Unmarshal(body, v)
+	}
+	ctx = gologin.WithUserDecoder(ctx, decoder)
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		facebookUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &User{ID: "54638001", Name: "Ivy Crimson"}, facebookUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := facebookHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Equal(t, "en_US", locale)
+}
+
+func TestFacebookHandler_UserDecoderValidated(t *testing.T) {
+	proxyClient, server := newFacebookTestServer(`{"id": "54638001", "name": "Ivy Crimson"}`)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	// custom decoder which does not populate the User
+	decoder := func(r io.Reader, v interface{}) error {
+		return nil
+	}
+	ctx = gologin.WithUserDecoder(ctx, decoder)
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetFacebookUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	// assert that the decoded User is still validated
+	handler := facebookHandler(&oauth2.Config{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
 func TestFacebookHandler_MissingCtxToken(t *testing.T) {
 	config := &oauth2.Config{}
 	success := testutils.AssertSuccessNotCalled(t)
@@ -95,6 +187,105 @@ func TestFacebookHandler_ErrorGettingUser(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestFacebookHandler_GraphErrors(t *testing.T) {
+	cases := []struct {
+		jsonData string
+		expected error
+	}{
+		{`{"error": {"message": "Error validating access token", "type": "OAuthException", "code": 190, "error_subcode": 463}}`, ErrFacebookTokenExpired},
+		{`{"error": {"message": "Error validating access token: The user has changed their password", "type": "OAuthException", "code": 190, "error_subcode": 460}}`, ErrFacebookTokenInvalid},
+		{`{"error": {"message": "Invalid OAuth access token", "type": "OAuthException", "code": 190}}`, ErrFacebookTokenInvalid},
+		// API session errors are not token errors
+		{`{"error": {"message": "API Session", "type": "OAuthException", "code": 102}}`, ErrUnableToGetFacebookUser},
+		{`{"error": {"message": "Permissions error", "type": "OAuthException", "code": 200}}`, ErrFacebookPermission},
+		{`{"error": {"message": "An unknown error occurred", "type": "OAuthException", "code": 1}}`, ErrUnableToGetFacebookUser},
+	}
+	for _, c := range cases {
+		proxyClient, server := newFacebookErrorServer(http.StatusBadRequest, c.jsonData)
+		// oauth2 Client will use the proxy client's base Transport
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		anyToken := &oauth2.Token{AccessToken: "any-token"}
+		ctx = oauth2Login.WithToken(ctx, anyToken)
+
+		config := &oauth2.Config{}
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(ctx)
+			assert.Equal(t, c.expected, err)
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// FacebookHandler receives a Graph API error, assert that:
+		// - failure handler is called
+		// - the typed error for the Graph error code is added to the ctx
+		facebookHandler := facebookHandler(config, success, goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		facebookHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestGraphFailure_Unwrap(t *testing.T) {
+	for _, err := range []error{ErrFacebookTokenExpired, ErrFacebookTokenInvalid, ErrFacebookPermission} {
+		if wrapper, ok := err.(interface {
+			Unwrap() error
+		}); assert.True(t, ok) {
+			assert.Equal(t, ErrUnableToGetFacebookUser, wrapper.Unwrap())
+		}
+	}
+}
+
+func TestHandleCallback(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		// assert client credentials are sent as body params, not Basic auth
+		_, _, basic := r.BasicAuth()
+		assert.False(t, basic)
+		assert.Equal(t, "client-id", r.PostFormValue("client_id"))
+		assert.Equal(t, "client-secret", r.PostFormValue("client_secret"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "any-token", "token_type": "bearer"}`)
+	})
+	mux.HandleFunc("/v2.4/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "54638001", "name": "Ivy Crimson"}`)
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint: oauth2.Endpoint{
+			TokenURL: "https://example.com/oauth/token",
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		login, err := oauth2Login.LoginFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "facebook", login.Provider)
+		assert.Equal(t, "any-token", login.Token.AccessToken)
+		assert.Equal(t, &User{ID: "54638001", Name: "Ivy Crimson"}, login.User)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// HandleCallback with the Facebook Provider, assert that:
+	// - state is checked and the auth code is exchanged for a Token
+	// - client credentials are sent in the token request body
+	// - the Facebook User is obtained
+	// - a Login with the provider name, User, and Token is added to the ctx
+	handler := oauth2Login.HandleCallback(config, Provider, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
 func TestValidateResponse(t *testing.T) {
 	validUser := &User{ID: "54638001", Name: "Ivy Crimson"}
 	validResponse := &http.Response{StatusCode: 200}
@@ -104,3 +295,38 @@ func TestValidateResponse(t *testing.T) {
 	assert.Equal(t, ErrUnableToGetFacebookUser, validateResponse(validUser, invalidResponse, nil))
 	assert.Equal(t, ErrUnableToGetFacebookUser, validateResponse(&User{}, validResponse, nil))
 }
+
+func BenchmarkFacebookCallback(b *testing.B) {
+	proxyClient, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/v2.4/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "facebook-token", "token_type": "bearer", "expires_in": 5183944}`)
+	})
+	mux.HandleFunc("/v2.4/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "54638001", "name": "Ivy Crimson", "email": "ivy@example.com"}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://www.facebook.com/v2.4/dialog/oauth",
+			TokenURL: "https://graph.facebook.com/v2.4/oauth/access_token",
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		b.Fatalf("unexpected call to failure handler: %v", gologin.ErrorFromContext(ctx))
+	}
+	handler := CallbackHandler(config, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(ctx, httptest.NewRecorder(), req)
+	}
+}
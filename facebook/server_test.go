@@ -19,3 +19,17 @@ func newFacebookTestServer(jsonData string) (*http.Client, *httptest.Server) {
 	})
 	return client, server
 }
+
+// newFacebookErrorServer returns a new httptest.Server which mocks the
+// Facebook user endpoint responding with the given status code and Graph API
+// error json data and a client which proxies requests to the server. The
+// caller must close the server.
+func newFacebookErrorServer(code int, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v2.4/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -1,40 +1,113 @@
 package facebook
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
 )
 
 const facebookAPI = "https://graph.facebook.com/v2.4/"
 
+// Endpoint is the Facebook OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://www.facebook.com/v2.4/dialog/oauth",
+	TokenURL:  "https://graph.facebook.com/v2.4/oauth/access_token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("facebook", Endpoint)
+}
+
 // User is a Facebook user.
 //
-// Note that user ids are unique to each app.
+// Note that user ids are unique to each app. Email is only present with the
+// email permission.
 type User struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Email   string  `json:"email"`
+	Picture Picture `json:"picture"`
+}
+
+// Picture is a Facebook user's profile picture.
+type Picture struct {
+	Data struct {
+		URL string `json:"url"`
+	} `json:"data"`
+}
+
+// Identity returns the Facebook identity keyed by the app-scoped user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Facebook user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// PictureURL returns the Facebook user's profile picture URL, if any.
+func (u *User) PictureURL() string {
+	return u.Picture.Data.URL
+}
+
+// graphError is a Facebook Graph API error response.
+// https://developers.facebook.com/docs/graph-api/using-graph-api/error-handling
+type graphError struct {
+	Err struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    int    `json:"code"`
+		Subcode int    `json:"error_subcode"`
+	} `json:"error"`
+}
+
+func (e *graphError) Error() string {
+	return fmt.Sprintf("facebook: %s (code %d, subcode %d)", e.Err.Message, e.Err.Code, e.Err.Subcode)
+}
+
+// meParams are the Graph API query parameters of a current User request,
+// since Graph API only returns the requested fields besides the id and name.
+type meParams struct {
+	Fields string `url:"fields,omitempty"`
 }
 
 // client is a Facebook client for obtaining the current User.
 type client struct {
 	c     *http.Client
 	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
 }
 
-func newClient(httpClient *http.Client) *client {
-	base := sling.New().Client(httpClient).Base(facebookAPI)
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(facebookAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "me"
+	}
 	return &client{
-		c:     httpClient,
-		sling: base,
+		c:           httpClient,
+		sling:       base,
+		userInfoURL: userInfoURL,
 	}
 }
 
+// Me gets the current User. If Facebook responds with a Graph API error, it
+// is returned as the error.
 func (c *client) Me() (*User, *http.Response, error) {
 	user := new(User)
+	apiErr := new(graphError)
 	// Facebook returns JSON as Content-Type text/javascript :(
 	// Set Accept header to receive proper Content-Type application/json
 	// so Sling will decode into the struct
-	resp, err := c.sling.New().Set("Accept", "application/json").Get("me").ReceiveSuccess(user)
+	resp, err := c.sling.New().Set("Accept", "application/json").Get(c.userInfoURL).QueryStruct(meParams{Fields: "id,name,email,picture"}).Receive(user, apiErr)
+	if err == nil && apiErr.Err.Code != 0 {
+		err = apiErr
+	}
 	return user, resp, err
 }
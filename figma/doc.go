@@ -0,0 +1,4 @@
+// Package figma provides Figma OAuth2 login and callback handlers.
+//
+// Figma requires client credentials be sent in the token request body.
+package figma
@@ -0,0 +1,35 @@
+package figma
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newFigmaTestServer returns a new httptest.Server which mocks the Figma token
+// endpoint, requiring client credentials in the body, and the me endpoint,
+// which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newFigmaTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": true, "status": 400, "message": "Invalid client credentials"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "figma-token", "token_type": "bearer", "expires_in": 3599}`)
+	})
+	mux.HandleFunc("/v1/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer figma-token" {
+			http.Error(w, `{"status": 403, "err": "Invalid token"}`, http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,72 @@
+package figma
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const figmaAPI = "https://api.figma.com/v1/"
+
+// Endpoint is the Figma OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://www.figma.com/oauth",
+	TokenURL:  "https://api.figma.com/v1/oauth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("figma", Endpoint)
+}
+
+// User is a Figma user.
+type User struct {
+	ID     string `json:"id"`
+	Email  string `json:"email"`
+	Handle string `json:"handle"`
+	ImgURL string `json:"img_url"`
+}
+
+// Identity returns the Figma identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Figma user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// PictureURL returns the Figma user's profile image URL, if any.
+func (u *User) PictureURL() string {
+	return u.ImgURL
+}
+
+// client is a Figma client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(figmaAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User.
+// https://www.figma.com/developers/api#users-endpoints
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
@@ -0,0 +1,43 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{EncodedID: "2ZBQPL", FullName: "Jill Chill"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "fitbit: Context missing Fitbit User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{EncodedID: "2ZBQPL"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "fitbit", ID: "2ZBQPL"}, identity)
+}
+
+func TestUser_AccountCreated(t *testing.T) {
+	// date created at timestamps
+	user := new(User)
+	err := json.Unmarshal([]byte(`{"encodedId": "257V3V", "memberSince": "2010-02-07"}`), user)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2010, time.February, 7, 0, 0, 0, 0, time.UTC), user.AccountCreated())
+	assert.True(t, (&User{}).AccountCreated().IsZero())
+}
@@ -0,0 +1,5 @@
+// Package fitbit provides Fitbit OAuth2 login and callback handlers.
+//
+// Fitbit requires client credentials be sent with HTTP Basic auth on token
+// exchange.
+package fitbit
@@ -0,0 +1,98 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Fitbit login errors
+var (
+	ErrUnableToGetFitbitUser = errors.New("fitbit: unable to get Fitbit User")
+)
+
+// Provider is the Fitbit OAuth2 Provider for use with oauth2 HandleCallback.
+// Fitbit requires client credentials in the token request Authorization header.
+var Provider = oauth2Login.Provider{
+	Name:            "fitbit",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInHeader,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Fitbit login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Fitbit redirection URI requests and adds the Fitbit
+// access token and User to the ctx. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+//
+// Configs which auto-detect the AuthStyle use AuthStyleInHeader.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	config = oauth2Login.Provider{AuthStyle: oauth2.AuthStyleInHeader}.Configure(config)
+	success = fitbitHandler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// fitbitHandler is a ContextHandler that gets the OAuth2 Token from the ctx to
+// get the corresponding Fitbit User. If successful, the User is added to the
+// ctx and the success handler is called. Otherwise, the failure handler is
+// called.
+func fitbitHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		fitbitClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		fitbitClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		profile, resp, err := fitbitClient.Profile()
+		err = validateResponse(profile, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, &profile.User)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Fitbit profile, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(profile *profileResponse, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetFitbitUser
+	}
+	if profile == nil || profile.User.EncodedID == "" {
+		return ErrUnableToGetFitbitUser
+	}
+	return nil
+}
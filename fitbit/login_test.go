@@ -0,0 +1,137 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"user": {"encodedId": "2ZBQPL", "fullName": "Jill Chill", "displayName": "Jill C.", "avatar": "https://static0.fitbit.com/images/profile/defaultProfile_100.png", "age": 34}}`
+	expectedUser := &User{
+		EncodedID:   "2ZBQPL",
+		FullName:    "Jill Chill",
+		DisplayName: "Jill C.",
+		Avatar:      "https://static0.fitbit.com/images/profile/defaultProfile_100.png",
+	}
+	proxyClient, server := newFitbitTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	// Endpoint without an AuthStyle, so the Fitbit default must be applied
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: Endpoint.AuthURL, TokenURL: Endpoint.TokenURL},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fitbitUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, fitbitUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler exchanges the code with Basic auth, assert that:
+	// - the nested Fitbit User is added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestFitbitHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// FitbitHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	fitbitHandler := fitbitHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	fitbitHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestFitbitHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Fitbit Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetFitbitUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// FitbitHandler cannot get Fitbit User, assert that:
+	// - failure handler is called
+	// - error cannot get Fitbit User added to the failure handler ctx
+	fitbitHandler := fitbitHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	fitbitHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &profileResponse{User: User{EncodedID: "2ZBQPL"}}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetFitbitUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetFitbitUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetFitbitUser, validateResponse(&profileResponse{}, validResponse, nil))
+}
+
+func TestFitbitHandler_UserInfoURL(t *testing.T) {
+	server := testutils.NewTestServerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1/user/-/profile.json", r.URL.Path)
+		assert.Equal(t, "Bearer any-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"user": {"encodedId": "2ZBQPL", "fullName": "Jill Chill"}}`)
+	})
+	defer server.Close()
+	// the userinfo URL points at the local test server directly
+	ctx := gologin.WithUserInfoURL(context.Background(), server.URL+"/1/user/-/profile.json")
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fitbitUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "2ZBQPL", fitbitUser.EncodedID)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := fitbitHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
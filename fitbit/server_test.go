@@ -0,0 +1,34 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newFitbitTestServer returns a new httptest.Server which mocks the Fitbit token
+// endpoint, requiring Basic auth client credentials, and the profile
+// endpoint, which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newFitbitTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client-id" || secret != "client-secret" {
+			http.Error(w, `{"errors": [{"errorType": "invalid_client", "message": "Invalid authorization header format."}], "success": false}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "fitbit-token", "token_type": "bearer", "expires_in": 28800, "user_id": "2ZBQPL"}`)
+	})
+	mux.HandleFunc("/1/user/-/profile.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fitbit-token" {
+			http.Error(w, `{"errors": [{"errorType": "invalid_token", "message": "Access token invalid"}], "success": false}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,81 @@
+package fitbit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const fitbitAPI = "https://api.fitbit.com/"
+
+// Endpoint is the Fitbit OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://www.fitbit.com/oauth2/authorize",
+	TokenURL:  "https://api.fitbit.com/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInHeader,
+}
+
+func init() {
+	gologin.RegisterEndpoint("fitbit", Endpoint)
+}
+
+// User is a Fitbit user.
+type User struct {
+	EncodedID   string `json:"encodedId"`
+	FullName    string `json:"fullName"`
+	DisplayName string `json:"displayName"`
+	Avatar      string `json:"avatar"`
+	// MemberSince is the date the user joined Fitbit
+	MemberSince gologin.Timestamp `json:"memberSince"`
+}
+
+// AccountCreated returns when the Fitbit account was created, as the date the user
+// joined.
+func (u *User) AccountCreated() time.Time {
+	return u.MemberSince.Time
+}
+
+// Identity returns the Fitbit identity keyed by the encoded user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.EncodedID}
+}
+
+// PictureURL returns the Fitbit user's avatar URL, if any.
+func (u *User) PictureURL() string {
+	return u.Avatar
+}
+
+// profileResponse is a Fitbit profile response, which wraps the User.
+type profileResponse struct {
+	User User `json:"user"`
+}
+
+// client is a Fitbit client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(fitbitAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "1/user/-/profile.json"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Profile gets the authenticated User's profile.
+// https://dev.fitbit.com/build/reference/web-api/user/get-profile/
+func (c *client) Profile() (*profileResponse, *http.Response, error) {
+	profile := new(profileResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(profile)
+	return profile, resp, err
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/google/go-github/github"
+	"github.com/quasor/gologin"
 	"golang.org/x/net/context"
 )
 
@@ -12,10 +13,12 @@ type key int
 
 const (
 	userKey key = iota
+	installationsKey
 )
 
 // WithUser returns a copy of ctx that stores the Github User.
 func WithUser(ctx context.Context, user *github.User) context.Context {
+	ctx = gologin.WithUser(ctx, &providerUser{user})
 	return context.WithValue(ctx, userKey, user)
 }
 
@@ -27,3 +30,18 @@ func UserFromContext(ctx context.Context) (*github.User, error) {
 	}
 	return user, nil
 }
+
+// WithInstallations returns a copy of ctx that stores the Github App
+// installations accessible to the user.
+func WithInstallations(ctx context.Context, installations []*Installation) context.Context {
+	return context.WithValue(ctx, installationsKey, installations)
+}
+
+// InstallationsFromContext returns the Github App installations from the ctx.
+func InstallationsFromContext(ctx context.Context) ([]*Installation, error) {
+	installations, ok := ctx.Value(installationsKey).([]*Installation)
+	if !ok {
+		return nil, fmt.Errorf("github: Context missing Github App installations")
+	}
+	return installations, nil
+}
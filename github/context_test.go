@@ -23,3 +23,19 @@ func TestContextUser_Error(t *testing.T) {
 		assert.Equal(t, "github: Context missing Github User", err.Error())
 	}
 }
+
+func TestContextInstallations(t *testing.T) {
+	expectedInstallations := []*Installation{{ID: 1, AppID: 2}}
+	ctx := WithInstallations(context.Background(), expectedInstallations)
+	installations, err := InstallationsFromContext(ctx)
+	assert.Equal(t, expectedInstallations, installations)
+	assert.Nil(t, err)
+}
+
+func TestContextInstallations_Error(t *testing.T) {
+	installations, err := InstallationsFromContext(context.Background())
+	assert.Nil(t, installations)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "github: Context missing Github App installations", err.Error())
+	}
+}
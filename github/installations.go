@@ -0,0 +1,116 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"goji.io"
+	"github.com/dghubble/sling"
+	"github.com/google/go-github/github"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const githubAPI = "https://api.github.com/"
+
+// maxInstallationPages bounds the number of installation pages followed.
+const maxInstallationPages = 10
+
+// Github App errors
+var (
+	ErrUnableToGetInstallations = errors.New("github: unable to get Github App installations")
+)
+
+// Installation is a Github App installation the user can access.
+type Installation struct {
+	ID         int          `json:"id"`
+	AppID      int          `json:"app_id"`
+	TargetType string       `json:"target_type"` // User, Organization
+	Account    *github.User `json:"account"`
+	HTMLURL    string       `json:"html_url"`
+}
+
+// installationsResponse is a Github user installations response.
+type installationsResponse struct {
+	TotalCount    int             `json:"total_count"`
+	Installations []*Installation `json:"installations"`
+}
+
+// InstallationsHandler is a ContextHandler for Github App web flow logins. It
+// gets the user-to-server OAuth2 Token from the ctx to list the Github App
+// installations accessible to the user. If successful, the installations are
+// added to the ctx and the success handler is called, so apps can route users
+// without an installation to install or configure the App. Otherwise, the
+// failure handler is called.
+//
+// Chain it after the CallbackHandler of a Github App's OAuth2 config.
+func InstallationsHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		installations, resp, err := listInstallations(httpClient)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			ctx = gologin.WithError(ctx, ErrUnableToGetInstallations)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithInstallations(ctx, installations)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// listInstallations pages through the Github App installations accessible
+// to the user, following Link headers for up to maxInstallationPages pages.
+// The last response is returned.
+// https://developer.github.com/v3/apps/#list-installations-for-user
+func listInstallations(httpClient *http.Client) ([]*Installation, *http.Response, error) {
+	var installations []*Installation
+	base := sling.New().Client(httpClient).Base(githubAPI).ResponseDecoder(internal.JSONDecoder{}).
+		Set("Accept", "application/vnd.github.machine-man-preview+json")
+	req := base.New().Get("user/installations").QueryStruct(&listOptions{PerPage: 100})
+	var resp *http.Response
+	for i := 0; i < maxInstallationPages; i++ {
+		installationsResp := new(installationsResponse)
+		var err error
+		resp, err = req.ReceiveSuccess(installationsResp)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return nil, resp, err
+		}
+		installations = append(installations, installationsResp.Installations...)
+		next := nextPageURL(resp.Header.Get("Link"))
+		if next == "" {
+			break
+		}
+		// the user's token is only sent to the Github API
+		if !isGithubAPIURL(next) {
+			return nil, resp, ErrUnableToGetInstallations
+		}
+		// next page URLs include the pagination query
+		req = base.New().Get(next)
+	}
+	return installations, resp, nil
+}
+
+// isGithubAPIURL returns true if the URL is on the Github API host.
+func isGithubAPIURL(url string) bool {
+	return strings.HasPrefix(url, githubAPI)
+}
+
+// listOptions are Github list pagination options.
+type listOptions struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page,omitempty"`
+}
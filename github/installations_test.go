@@ -0,0 +1,153 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/google/go-github/github"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestInstallationsHandler(t *testing.T) {
+	jsonData := `{"total_count": 2, "installations": [
+		{"id": 25381, "app_id": 7, "target_type": "Organization", "account": {"login": "octo-org", "id": 6811672}, "html_url": "https://github.com/organizations/octo-org/settings/installations/25381"},
+		{"id": 25382, "app_id": 7, "target_type": "User", "account": {"login": "octocat", "id": 583231}}
+	]}`
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/user/installations", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer any-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		installations, err := InstallationsFromContext(ctx)
+		assert.Nil(t, err)
+		if assert.Len(t, installations, 2) {
+			assert.Equal(t, 25381, installations[0].ID)
+			assert.Equal(t, "Organization", installations[0].TargetType)
+			assert.Equal(t, &github.User{Login: github.String("octo-org"), ID: github.Int(6811672)}, installations[0].Account)
+			assert.Equal(t, "octocat", *installations[1].Account.Login)
+		}
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// InstallationsHandler assert that:
+	// - Token is read from the ctx and passed to the Github API
+	// - installations accessible to the user are added to the ctx
+	handler := InstallationsHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestInstallationsHandler_Paginated(t *testing.T) {
+	pages := []string{
+		`{"total_count": 3, "installations": [{"id": 1, "app_id": 7}, {"id": 2, "app_id": 7}]}`,
+		`{"total_count": 3, "installations": [{"id": 3, "app_id": 7}]}`,
+	}
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/user/installations", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer any-token", r.Header.Get("Authorization"))
+		page := 1
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		if page < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/user/installations?per_page=100&page=%d>; rel="next", <https://api.github.com/user/installations?per_page=100&page=%d>; rel="last"`, page+1, len(pages)))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, pages[page-1])
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		installations, err := InstallationsFromContext(ctx)
+		assert.Nil(t, err)
+		if assert.Len(t, installations, 3) {
+			assert.Equal(t, 1, installations[0].ID)
+			assert.Equal(t, 3, installations[2].ID)
+		}
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// InstallationsHandler assert that:
+	// - installation pages are followed using Link headers
+	// - installations from every page are added to the ctx
+	handler := InstallationsHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestInstallationsHandler_ForeignNextPage(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/user/installations", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.URL.Query().Get("page"), "foreign next page was requested")
+		w.Header().Set("Link", `<https://example.com/user/installations?page=2>; rel="next"`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"total_count": 2, "installations": [{"id": 1, "app_id": 7}]}`)
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetInstallations, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// InstallationsHandler assert that:
+	// - next page URLs off the Github API host are not followed
+	// - the failure handler is called
+	handler := InstallationsHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestInstallationsHandler_ErrorGettingInstallations(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Github Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetInstallations, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := InstallationsHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
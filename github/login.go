@@ -17,6 +17,19 @@ var (
 	ErrUnableToGetGithubUser = errors.New("github: unable to get Github User")
 )
 
+// Provider is the Github OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{Name: "github", CallbackHandler: CallbackHandler}
+
+// Endpoint is the Github OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://github.com/login/oauth/authorize",
+	TokenURL: "https://github.com/login/oauth/access_token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("github", Endpoint)
+}
+
 // StateHandler checks for a state cookie. If found, the state value is read
 // and added to the ctx. Otherwise, a non-guessable value is added to the ctx
 // and to a (short-lived) state cookie issued to the requester.
@@ -46,7 +59,8 @@ func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.
 // githubHandler is a ContextHandler that gets the OAuth2 Token from the ctx to
 // get the corresponding Github User. If successful, the User is added to the
 // ctx and the success handler is called. Otherwise, the failure handler is
-// called.
+// called, with a RateLimitError if Github's secondary rate limit rejected the
+// request.
 func githubHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
@@ -60,8 +74,7 @@ func githubHandler(config *oauth2.Config, success, failure goji.Handler) goji.Ha
 		}
 		httpClient := config.Client(ctx, token)
 		githubClient := github.NewClient(httpClient)
-		user, resp, err := githubClient.Users.Get("")
-		err = validateResponse(user, resp, err)
+		user, err := getUser(ctx, githubClient)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTP(ctx, w, req)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"goji.io"
@@ -16,6 +17,14 @@ import (
 	"golang.org/x/oauth2"
 )
 
+func TestEndpointRegistered(t *testing.T) {
+	os.Setenv("GITHUB_CLIENT_ID", "client-id")
+	defer os.Unsetenv("GITHUB_CLIENT_ID")
+	config, err := gologin.ConfigFromEnv("github")
+	assert.Nil(t, err)
+	assert.Equal(t, Endpoint, config.Endpoint)
+}
+
 func TestGithubHandler(t *testing.T) {
 	jsonData := `{"id": 917408, "name": "Alyssa Hacker"}`
 	expectedUser := &github.User{ID: github.Int(917408), Name: github.String("Alyssa Hacker")}
@@ -96,6 +105,49 @@ func TestGithubHandler_ErrorGettingUser(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestHandleCallback(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "any-token", "token_type": "bearer"}`)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": 917408, "name": "Alyssa Hacker"}`)
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: "https://example.com/oauth/token",
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		login, err := oauth2Login.LoginFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "github", login.Provider)
+		assert.Equal(t, "any-token", login.Token.AccessToken)
+		if user, ok := login.User.(*providerUser); assert.True(t, ok) {
+			assert.Equal(t, 917408, *user.ID)
+		}
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// HandleCallback with the Github Provider, assert that:
+	// - state is checked and the auth code is exchanged for a Token
+	// - the Github User is obtained
+	// - a Login with the provider name, User, and Token is added to the ctx
+	handler := oauth2Login.HandleCallback(config, Provider, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
 func TestValidateResponse(t *testing.T) {
 	validUser := &github.User{ID: github.Int(123)}
 	validResponse := &github.Response{Response: &http.Response{StatusCode: 200}}
@@ -105,3 +157,39 @@ func TestValidateResponse(t *testing.T) {
 	assert.Equal(t, ErrUnableToGetGithubUser, validateResponse(validUser, invalidResponse, nil))
 	assert.Equal(t, ErrUnableToGetGithubUser, validateResponse(&github.User{}, validResponse, nil))
 }
+
+func BenchmarkGithubCallback(b *testing.B) {
+	proxyClient, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "github-token", "token_type": "bearer", "scope": "user:email"}`)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": 917408, "login": "alyssa", "name": "Alyssa Hacker", "email": "alyssa@example.com"}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   "https://github.com/login/oauth/authorize",
+			TokenURL:  "https://github.com/login/oauth/access_token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		b.Fatalf("unexpected call to failure handler: %v", gologin.ErrorFromContext(ctx))
+	}
+	handler := CallbackHandler(config, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(ctx, httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/net/context"
+)
+
+// MaxRetryAfter is the longest Retry-After duration githubHandler waits
+// before retrying a request rejected by a secondary rate limit once. Longer
+// (or repeated) limits fail with a RateLimitError. Defaults to 0, which only
+// retries limits that may be retried immediately.
+var MaxRetryAfter time.Duration
+
+// RateLimitError is returned when Github rejects a request with a rate
+// limit, as distinct from an authentication failure. Both primary limits
+// (a 403 with X-RateLimit-Remaining 0) and secondary limits (a 403 with a
+// Retry-After header) are reported as a *RateLimitError, so a type assertion
+// covers both. Its message is prefixed by ErrRateLimited.
+// https://docs.github.com/en/rest/overview/resources-in-the-rest-api#rate-limiting
+type RateLimitError struct {
+	// RetryAfter is how long Github asks clients to wait before retrying,
+	// or 0 if unknown
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited.Error(), e.RetryAfter)
+}
+
+// retrySleep waits for d or until the ctx is done. Tests may replace it.
+var retrySleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// secondaryRateLimit returns the Retry-After duration and true if the
+// response is a secondary rate limit rejection.
+func secondaryRateLimit(resp *github.Response) (time.Duration, bool) {
+	if resp == nil || resp.Response == nil || resp.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// getUser gets the authenticated Github User, retrying once if a secondary
+// rate limit asks to wait no longer than MaxRetryAfter. Secondary rate limits
+// which are not retried return a RateLimitError.
+func getUser(ctx context.Context, client *github.Client) (*github.User, error) {
+	user, resp, err := client.Users.Get("")
+	if retryAfter, limited := secondaryRateLimit(resp); limited && retryAfter <= MaxRetryAfter {
+		if err := retrySleep(ctx, retryAfter); err != nil {
+			return nil, err
+		}
+		user, resp, err = client.Users.Get("")
+	}
+	if retryAfter, limited := secondaryRateLimit(resp); limited {
+		return nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+	return user, validateResponse(user, resp, err)
+}
+
+// primaryRateLimit returns a RateLimitError if the response is a primary rate
+// limit rejection, with the time until the X-RateLimit-Reset (Unix seconds)
+// as the RetryAfter. Otherwise, it returns nil.
+func primaryRateLimit(resp *http.Response) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	rateLimitErr := new(RateLimitError)
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if wait := time.Unix(reset, 0).Sub(internal.DefaultClock.Now()); wait > 0 {
+			rateLimitErr.RetryAfter = wait
+		}
+	}
+	return rateLimitErr
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// newRateLimitTestServer returns a new httptest.Server which rejects the
+// first limited user requests with a 403 and the given Retry-After header,
+// then responds with a Github user. The caller must close the server.
+func newRateLimitTestServer(limited int, retryAfter string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if limited > 0 {
+			limited--
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"message": "You have exceeded a secondary rate limit."}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id": 917408, "name": "Alyssa Hacker"}`)
+	})
+	return client, server
+}
+
+// withRetrySleep records retry sleeps instead of waiting and returns a func
+// to restore retrySleep and MaxRetryAfter.
+func withRetrySleep(sleeps *[]time.Duration) func() {
+	originalSleep, originalMax := retrySleep, MaxRetryAfter
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return nil
+	}
+	return func() {
+		retrySleep, MaxRetryAfter = originalSleep, originalMax
+	}
+}
+
+func TestGithubHandler_SecondaryRateLimited(t *testing.T) {
+	var sleeps []time.Duration
+	defer withRetrySleep(&sleeps)()
+	proxyClient, server := newRateLimitTestServer(2, "30")
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if rateLimitErr, ok := err.(*RateLimitError); assert.True(t, ok) {
+			assert.Equal(t, 30*time.Second, rateLimitErr.RetryAfter)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := githubHandler(&oauth2.Config{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	// Retry-After exceeds MaxRetryAfter, so no retry
+	assert.Empty(t, sleeps)
+}
+
+func TestGithubHandler_SecondaryRateLimitRetry(t *testing.T) {
+	var sleeps []time.Duration
+	defer withRetrySleep(&sleeps)()
+	MaxRetryAfter = time.Minute
+	proxyClient, server := newRateLimitTestServer(1, "30")
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 917408, *user.ID)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := githubHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Equal(t, []time.Duration{30 * time.Second}, sleeps)
+}
+
+func TestGithubHandler_ForbiddenWithoutRetryAfter(t *testing.T) {
+	var sleeps []time.Duration
+	defer withRetrySleep(&sleeps)()
+	proxyClient, server := newRateLimitTestServer(1, "")
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetGithubUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := githubHandler(&oauth2.Config{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	assert.Empty(t, sleeps)
+}
@@ -0,0 +1,43 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnableToGetTokenScopes is returned when the scopes granted to a token
+// cannot be determined.
+var ErrUnableToGetTokenScopes = errors.New("github: unable to get token scopes")
+
+// TokenScopes returns the scopes granted to the token, which may differ from
+// those requested if the user modified them. Github reports the granted
+// scopes in the X-OAuth-Scopes header of API responses, so a lightweight
+// HEAD request is made to read it.
+// https://developer.github.com/v3/oauth/#scopes
+func TokenScopes(ctx context.Context, config *oauth2.Config, token *oauth2.Token) ([]string, error) {
+	httpClient := config.Client(ctx, token)
+	resp, err := httpClient.Head(githubAPI + "user")
+	if err != nil {
+		return nil, ErrUnableToGetTokenScopes
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrUnableToGetTokenScopes
+	}
+	return parseScopes(resp.Header.Get("X-OAuth-Scopes")), nil
+}
+
+// parseScopes parses a comma separated X-OAuth-Scopes header value.
+func parseScopes(header string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(header, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,43 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenScopes(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "HEAD", r.Method)
+		assert.Equal(t, "Bearer any-token", r.Header.Get("Authorization"))
+		w.Header().Set("X-OAuth-Scopes", "repo, user:email, read:org")
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+
+	scopes, err := TokenScopes(ctx, &oauth2.Config{}, &oauth2.Token{AccessToken: "any-token"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"repo", "user:email", "read:org"}, scopes)
+}
+
+func TestTokenScopes_Error(t *testing.T) {
+	client, server := testutils.NewErrorServer("Bad credentials", http.StatusUnauthorized)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+
+	scopes, err := TokenScopes(ctx, &oauth2.Config{}, &oauth2.Token{AccessToken: "any-token"})
+	assert.Nil(t, scopes)
+	assert.Equal(t, ErrUnableToGetTokenScopes, err)
+}
+
+func TestParseScopes(t *testing.T) {
+	assert.Equal(t, []string{}, parseScopes(""))
+	assert.Equal(t, []string{"repo"}, parseScopes("repo"))
+	assert.Equal(t, []string{"repo", "gist"}, parseScopes("repo,gist"))
+}
@@ -0,0 +1,136 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/oauth2"
+)
+
+// Github team errors
+var (
+	ErrNotTeamMember    = errors.New("github: user is not a member of the required team")
+	ErrUnableToGetTeams = errors.New("github: unable to get Github team memberships")
+	// ErrRateLimited prefixes the message of RateLimitErrors
+	ErrRateLimited = errors.New("github: Github API rate limit exceeded")
+)
+
+// maxTeamPages bounds the number of team pages followed.
+const maxTeamPages = 10
+
+// Team is a Github team the user is a member of.
+type Team struct {
+	ID           int    `json:"id"`
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	Organization struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+}
+
+// TeamHandler is a ContextHandler which gets the OAuth2 Token from the ctx to
+// check that the user is a member of the team with the given slug in the
+// given organization. If so, the success handler is called. Otherwise, the
+// failure handler is called with ErrNotTeamMember, or with a RateLimitError
+// or ErrUnableToGetTeams if memberships could not be listed.
+//
+// Requires the "read:org" scope. Chain it after a CallbackHandler.
+func TeamHandler(config *oauth2.Config, org, team string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		member, err := isTeamMember(ctx, httpClient, githubAPI+"user/teams?per_page=100", org, team)
+		if err == nil && !member {
+			err = ErrNotTeamMember
+		}
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// isTeamMember pages through the user's teams, following Link headers for up
+// to maxTeamPages pages, until the team is found or the pages are exhausted.
+// Paging stops if the ctx is canceled, the rate limit is exceeded, or a next
+// page is not on the Github API.
+// https://developer.github.com/v3/orgs/teams/#list-user-teams
+func isTeamMember(ctx context.Context, client *http.Client, url, org, team string) (bool, error) {
+	for i := 0; i < maxTeamPages && url != ""; i++ {
+		resp, err := ctxhttp.Get(ctx, client, url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			return false, ErrUnableToGetTeams
+		}
+		teams, err := decodeTeams(resp)
+		if err != nil {
+			return false, err
+		}
+		for _, t := range teams {
+			if strings.EqualFold(t.Organization.Login, org) && strings.EqualFold(t.Slug, team) {
+				return true, nil
+			}
+		}
+		url = nextPageURL(resp.Header.Get("Link"))
+		// the user's token is only sent to the Github API
+		if url != "" && !isGithubAPIURL(url) {
+			return false, ErrUnableToGetTeams
+		}
+	}
+	return false, nil
+}
+
+// decodeTeams decodes a page of teams and closes the response body.
+func decodeTeams(resp *http.Response) ([]*Team, error) {
+	defer resp.Body.Close()
+	if rateLimitErr := primaryRateLimit(resp); rateLimitErr != nil {
+		return nil, rateLimitErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrUnableToGetTeams
+	}
+	var teams []*Team
+	if err := internal.DecodeJSON(resp.Body, &teams); err != nil {
+		return nil, ErrUnableToGetTeams
+	}
+	return teams, nil
+}
+
+// nextPageURL returns the rel="next" URL from a Link header or "" if there is
+// no next page.
+// https://developer.github.com/v3/#pagination
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+		url := strings.Trim(strings.TrimSpace(segments[0]), "<>")
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return url
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// newTeamsTestServer returns a client and server which mock the paginated
+// Github user teams endpoint with the given pages of teams JSON. The caller
+// must close the server.
+func newTeamsTestServer(t *testing.T, pages []string) (*http.Client, *httptest.Server, *int) {
+	client, mux, server := testutils.TestServer()
+	requests := 0
+	mux.HandleFunc("/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer any-token", r.Header.Get("Authorization"))
+		page := 1
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		if page < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/user/teams?per_page=100&page=%d>; rel="next", <https://api.github.com/user/teams?per_page=100&page=%d>; rel="last"`, page+1, len(pages)))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, pages[page-1])
+	})
+	return client, server, &requests
+}
+
+var teamPages = []string{
+	`[{"id": 1, "slug": "owners", "name": "Owners", "organization": {"login": "octo-org"}}]`,
+	`[{"id": 2, "slug": "justice-league", "name": "Justice League", "organization": {"login": "other-org"}}]`,
+	`[{"id": 3, "slug": "justice-league", "name": "Justice League", "organization": {"login": "octo-org"}}]`,
+}
+
+func teamsContext(client *http.Client) context.Context {
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	return oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+}
+
+func TestTeamHandler(t *testing.T) {
+	client, server, requests := newTeamsTestServer(t, teamPages)
+	defer server.Close()
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// TeamHandler with the team on the last page, assert that:
+	// - team pages are followed using Link headers
+	// - success handler is called
+	handler := TeamHandler(&oauth2.Config{}, "octo-org", "justice-league", goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(teamsContext(client), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Equal(t, 3, *requests)
+}
+
+func TestTeamHandler_StopsAtMember(t *testing.T) {
+	client, server, requests := newTeamsTestServer(t, teamPages)
+	defer server.Close()
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {}
+	handler := TeamHandler(&oauth2.Config{}, "octo-org", "owners", goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(teamsContext(client), httptest.NewRecorder(), req)
+	// assert later pages are not requested once the team is found
+	assert.Equal(t, 1, *requests)
+}
+
+func TestTeamHandler_NotTeamMember(t *testing.T) {
+	client, server, requests := newTeamsTestServer(t, teamPages)
+	defer server.Close()
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrNotTeamMember, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TeamHandler without membership in the team, assert that:
+	// - all pages are checked
+	// - failure handler is called with ErrNotTeamMember
+	handler := TeamHandler(&oauth2.Config{}, "other-org", "owners", testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(teamsContext(client), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	assert.Equal(t, 3, *requests)
+}
+
+func TestTeamHandler_MaxPages(t *testing.T) {
+	pages := make([]string, maxTeamPages+2)
+	for i := range pages {
+		pages[i] = teamPages[1]
+	}
+	client, server, requests := newTeamsTestServer(t, pages)
+	defer server.Close()
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrNotTeamMember, gologin.ErrorFromContext(ctx))
+	}
+	handler := TeamHandler(&oauth2.Config{}, "octo-org", "owners", testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(teamsContext(client), httptest.NewRecorder(), req)
+	// assert paging stops after maxTeamPages pages
+	assert.Equal(t, maxTeamPages, *requests)
+}
+
+func TestTeamHandler_ForeignNextPage(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	requests := 0
+	mux.HandleFunc("/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Link", `<https://example.com/user/teams?page=2>; rel="next"`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, teamPages[1])
+	})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetTeams, gologin.ErrorFromContext(ctx))
+	}
+	handler := TeamHandler(&oauth2.Config{}, "octo-org", "owners", testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(teamsContext(client), httptest.NewRecorder(), req)
+	// assert the token is not sent to next pages off the Github API host
+	assert.Equal(t, 1, requests)
+}
+
+func TestTeamHandler_RateLimited(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	original := internal.DefaultClock
+	internal.DefaultClock = internal.NewFakeClock(now)
+	defer func() { internal.DefaultClock = original }()
+	mux.HandleFunc("/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(90*time.Second).Unix(), 10))
+		http.Error(w, `{"message": "API rate limit exceeded"}`, http.StatusForbidden)
+	})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		// primary rate limits are reported like secondary rate limits
+		err := gologin.ErrorFromContext(ctx)
+		if rateLimitErr, ok := err.(*RateLimitError); assert.True(t, ok) {
+			assert.Equal(t, 90*time.Second, rateLimitErr.RetryAfter)
+		}
+	}
+	handler := TeamHandler(&oauth2.Config{}, "octo-org", "owners", testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(teamsContext(client), httptest.NewRecorder(), req)
+}
+
+func TestTeamHandler_CanceledContext(t *testing.T) {
+	client, server, requests := newTeamsTestServer(t, teamPages)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(teamsContext(client))
+	cancel()
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, context.Canceled, gologin.ErrorFromContext(ctx))
+	}
+	handler := TeamHandler(&oauth2.Config{}, "octo-org", "justice-league", testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, httptest.NewRecorder(), req)
+	assert.Equal(t, 0, *requests)
+}
+
+func TestNextPageURL(t *testing.T) {
+	link := `<https://api.github.com/user/teams?page=2>; rel="next", <https://api.github.com/user/teams?page=5>; rel="last"`
+	assert.Equal(t, "https://api.github.com/user/teams?page=2", nextPageURL(link))
+	assert.Equal(t, "", nextPageURL(`<https://api.github.com/user/teams?page=1>; rel="prev"`))
+	assert.Equal(t, "", nextPageURL(""))
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/quasor/gologin"
+)
+
+// providerUser wraps a Github User to implement the gologin provider user
+// interfaces. It is the provider-agnostic user WithUser adds to the ctx.
+type providerUser struct {
+	*github.User
+}
+
+// Suspended returns true if Github reports the account as suspended.
+func (u *providerUser) Suspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// AccountCreated returns when the Github account was created.
+func (u *providerUser) AccountCreated() time.Time {
+	if u.CreatedAt == nil {
+		return time.Time{}
+	}
+	return u.CreatedAt.Time
+}
+
+// Identity returns the Github identity keyed by the numeric user ID, which
+// is stable across login renames.
+func (u *providerUser) Identity() gologin.Identity {
+	identity := gologin.Identity{Provider: Provider.Name}
+	if u.ID != nil {
+		identity.ID = strconv.Itoa(*u.ID)
+	}
+	return identity
+}
+
+// EmailAddress returns the Github user's public profile email address, if
+// any.
+func (u *providerUser) EmailAddress() (string, bool) {
+	if u.User.Email == nil || *u.User.Email == "" {
+		return "", false
+	}
+	return *u.User.Email, true
+}
+
+// PictureURL returns the Github user's avatar URL, if any.
+func (u *providerUser) PictureURL() string {
+	if u.AvatarURL == nil {
+		return ""
+	}
+	return *u.AvatarURL
+}
@@ -0,0 +1,52 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProviderUser_Suspended(t *testing.T) {
+	active := &providerUser{&github.User{ID: github.Int(917408)}}
+	suspended := &providerUser{&github.User{ID: github.Int(917408), SuspendedAt: &github.Timestamp{Time: time.Now()}}}
+	assert.False(t, active.Suspended())
+	assert.True(t, suspended.Suspended())
+}
+
+func TestProviderUser_Identity(t *testing.T) {
+	user := &providerUser{&github.User{ID: github.Int(917408), Login: github.String("octocat")}}
+	assert.Equal(t, gologin.Identity{Provider: "github", ID: "917408"}, user.Identity())
+	assert.Equal(t, "github:917408", user.Identity().String())
+	// assert a user without an ID has no identity
+	assert.Equal(t, "", (&providerUser{&github.User{}}).Identity().ID)
+}
+
+func TestProviderUser_AccountCreated(t *testing.T) {
+	created := time.Date(2011, time.January, 25, 18, 44, 36, 0, time.UTC)
+	user := &providerUser{&github.User{CreatedAt: &github.Timestamp{Time: created}}}
+	assert.Equal(t, created, user.AccountCreated())
+	assert.True(t, (&providerUser{&github.User{}}).AccountCreated().IsZero())
+}
+
+func TestProviderUser_Email(t *testing.T) {
+	ctx := WithUser(context.Background(), &github.User{ID: github.Int(917408), Email: github.String("octocat@github.com")})
+	email, ok := gologin.UserEmail(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "octocat@github.com", email)
+	// users without a public email
+	_, ok = gologin.UserEmail(WithUser(context.Background(), &github.User{ID: github.Int(917408)}))
+	assert.False(t, ok)
+}
+
+func TestProviderUser_PictureURL(t *testing.T) {
+	ctx := WithUser(context.Background(), &github.User{ID: github.Int(917408), AvatarURL: github.String("https://avatars.githubusercontent.com/u/917408")})
+	picture, ok := gologin.UserPicture(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "https://avatars.githubusercontent.com/u/917408", picture)
+	_, ok = gologin.UserPicture(WithUser(context.Background(), &github.User{ID: github.Int(917408)}))
+	assert.False(t, ok)
+}
@@ -0,0 +1,39 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: 917, Username: "gitster"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "gitlab: Context missing Gitlab User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: 917, Username: "gitster"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "gitlab", ID: "917"}, identity)
+}
+
+func TestUser_Picture(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: 917, AvatarURL: "https://gitlab.com/uploads/avatar.png"})
+	picture, ok := gologin.UserPicture(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "https://gitlab.com/uploads/avatar.png", picture)
+}
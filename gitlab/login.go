@@ -0,0 +1,104 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Gitlab login errors
+var (
+	ErrUnableToGetGitlabUser = errors.New("gitlab: unable to get Gitlab User")
+)
+
+// Provider is the gitlab.com OAuth2 Provider for use with oauth2
+// HandleCallback. Self-hosted instances should use NewProvider.
+var Provider = NewProvider(DefaultBaseURL)
+
+// NewProvider returns the OAuth2 Provider of the GitLab instance at the given
+// base URL for use with oauth2 HandleCallback.
+func NewProvider(baseURL string) oauth2Login.Provider {
+	callbackHandler := func(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+		return CallbackHandler(config, baseURL, success, failure)
+	}
+	return oauth2Login.Provider{Name: "gitlab", CallbackHandler: callbackHandler}
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles GitLab login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles GitLab redirection URI requests and adds the GitLab
+// access token and User to the ctx. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+//
+// The User is fetched from the GitLab instance at the given base URL (e.g.
+// "https://gitlab.example.com"), or gitlab.com if it is empty. The config
+// Endpoint should be the instance's Endpoint, see NewEndpoint.
+func CallbackHandler(config *oauth2.Config, baseURL string, success, failure goji.Handler) goji.Handler {
+	success = gitlabHandler(config, baseURL, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// gitlabHandler is a ContextHandler that gets the OAuth2 Token from the ctx to
+// get the corresponding GitLab User from the instance at the base URL. If
+// successful, the User is added to the ctx and the success handler is called.
+// Otherwise, the failure handler is called.
+func gitlabHandler(config *oauth2.Config, baseURL string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		gitlabClient := newClient(httpClient, baseURL, gologin.UserInfoURLFromContext(ctx))
+		gitlabClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		user, resp, err := gitlabClient.CurrentUser()
+		err = validateResponse(user, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, user)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given GitLab User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetGitlabUser
+	}
+	if user == nil || user.ID == 0 {
+		return ErrUnableToGetGitlabUser
+	}
+	return nil
+}
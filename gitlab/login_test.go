@@ -0,0 +1,135 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const testUserJSON = `{"id": 917, "username": "gitster", "name": "Git Ster", "email": "gitster@example.com", "avatar_url": "https://gitlab.example.com/uploads/-/system/user/avatar/917/avatar.png"}`
+
+func TestGitlabHandler(t *testing.T) {
+	expectedUser := &User{
+		ID:        917,
+		Username:  "gitster",
+		Name:      "Git Ster",
+		Email:     "gitster@example.com",
+		AvatarURL: "https://gitlab.example.com/uploads/-/system/user/avatar/917/avatar.png",
+	}
+	cases := []struct {
+		baseURL string
+		path    string
+	}{
+		{"", "/api/v4/user"},
+		{"https://gitlab.example.com", "/api/v4/user"},
+		{"https://gitlab.example.com/", "/api/v4/user"},
+		{"https://example.com/gitlab/", "/gitlab/api/v4/user"},
+	}
+	for _, c := range cases {
+		proxyClient, server := newGitlabTestServer(c.path, testUserJSON)
+		// oauth2 Client will use the proxy client's base Transport
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		anyToken := &oauth2.Token{AccessToken: "any-token"}
+		ctx = oauth2Login.WithToken(ctx, anyToken)
+
+		config := &oauth2.Config{}
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			gitlabUser, err := UserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedUser, gitlabUser)
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := testutils.AssertFailureNotCalled(t)
+
+		// GitlabHandler assert that:
+		// - Token is read from the ctx and passed to the GitLab API
+		// - GitLab User is obtained from the base URL's API, with or without a trailing slash
+		// - success handler is called
+		// - GitLab User is added to the ctx of the success handler
+		gitlabHandler := gitlabHandler(config, c.baseURL, goji.HandlerFunc(success), failure)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		gitlabHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "success handler called", w.Body.String(), c.baseURL)
+		server.Close()
+	}
+}
+
+func TestGitlabHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// GitlabHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	gitlabHandler := gitlabHandler(config, DefaultBaseURL, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	gitlabHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestGitlabHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("GitLab Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetGitlabUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// GitlabHandler cannot get GitLab User, assert that:
+	// - failure handler is called
+	// - error cannot get GitLab User added to the failure handler ctx
+	gitlabHandler := gitlabHandler(config, "https://gitlab.example.com/", success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	gitlabHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestNewEndpoint(t *testing.T) {
+	expected := oauth2.Endpoint{
+		AuthURL:  "https://gitlab.example.com/oauth/authorize",
+		TokenURL: "https://gitlab.example.com/oauth/token",
+	}
+	assert.Equal(t, expected, NewEndpoint("https://gitlab.example.com"))
+	assert.Equal(t, expected, NewEndpoint("https://gitlab.example.com/"))
+	assert.Equal(t, "https://gitlab.com/oauth/authorize", Endpoint.AuthURL)
+	assert.Equal(t, Endpoint, NewEndpoint(""))
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: 917}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetGitlabUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetGitlabUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetGitlabUser, validateResponse(&User{}, validResponse, nil))
+}
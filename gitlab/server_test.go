@@ -0,0 +1,22 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newGitlabTestServer returns a new httptest.Server which mocks the GitLab
+// user endpoint at the given path and a client which proxies requests to the
+// server. The server responds with the given json data. The caller must close
+// the server.
+func newGitlabTestServer(path, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
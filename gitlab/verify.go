@@ -0,0 +1,92 @@
+package gitlab
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+// DefaultBaseURL is the base URL of gitlab.com.
+const DefaultBaseURL = "https://gitlab.com"
+
+// Endpoint is the gitlab.com OAuth2 endpoint.
+var Endpoint = NewEndpoint(DefaultBaseURL)
+
+func init() {
+	gologin.RegisterEndpoint("gitlab", Endpoint)
+}
+
+// NewEndpoint returns the OAuth2 endpoint of the GitLab instance at the given
+// base URL (e.g. "https://gitlab.example.com").
+func NewEndpoint(baseURL string) oauth2.Endpoint {
+	baseURL = normalizeBaseURL(baseURL)
+	return oauth2.Endpoint{
+		AuthURL:  baseURL + "oauth/authorize",
+		TokenURL: baseURL + "oauth/token",
+	}
+}
+
+// normalizeBaseURL returns the base URL with a single trailing slash, or the
+// DefaultBaseURL if it is empty.
+func normalizeBaseURL(baseURL string) string {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/"
+}
+
+// User is a GitLab user. Email is the user's public email, which may be
+// empty without the read_user scope.
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// Identity returns the GitLab identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: strconv.FormatInt(u.ID, 10)}
+}
+
+// EmailAddress returns the GitLab user's public email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// PictureURL returns the GitLab user's avatar URL.
+func (u *User) PictureURL() string {
+	return u.AvatarURL
+}
+
+// client is a GitLab client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the base URL
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, baseURL, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(normalizeBaseURL(baseURL)).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "api/v4/user"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// CurrentUser gets the authenticated User.
+// https://docs.gitlab.com/ee/api/users.html#for-normal-users-1
+func (c *client) CurrentUser() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
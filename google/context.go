@@ -3,6 +3,7 @@ package google
 import (
 	"fmt"
 
+	"github.com/quasor/gologin"
 	"golang.org/x/net/context"
 	google "google.golang.org/api/oauth2/v2"
 )
@@ -16,6 +17,7 @@ const (
 
 // WithUser returns a copy of ctx that stores the Google Userinfoplus.
 func WithUser(ctx context.Context, user *google.Userinfoplus) context.Context {
+	ctx = gologin.WithUser(ctx, &providerUser{user})
 	return context.WithValue(ctx, userKey, user)
 }
 
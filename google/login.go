@@ -18,6 +18,19 @@ var (
 	ErrCannotValidateGoogleUser = errors.New("google: could not validate Google User")
 )
 
+// Provider is the Google OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{Name: "google", CallbackHandler: CallbackHandler}
+
+// Endpoint is the Google OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+	TokenURL: "https://oauth2.googleapis.com/token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("google", Endpoint)
+}
+
 // StateHandler checks for a state cookie. If found, the state value is read
 // and added to the ctx. Otherwise, a non-guessable value is added to the ctx
 // and to a (short-lived) state cookie issued to the requester.
@@ -36,6 +49,14 @@ func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
 	return oauth2Login.LoginHandler(config, failure)
 }
 
+// LoginHandlerWithOptions handles Google login requests like LoginHandler,
+// configured by the given oauth2 LoginOptions. Set IncludeGrantedScopes for
+// Google incremental authorization.
+// https://developers.google.com/identity/protocols/oauth2/web-server#incrementalAuth
+func LoginHandlerWithOptions(config *oauth2.Config, options oauth2Login.LoginOptions, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandlerWithOptions(config, options, failure)
+}
+
 // CallbackHandler handles Google redirection URI requests and adds the Google
 // access token and Userinfoplus to the ctx. If authentication succeeds,
 // handling delegates to the success handler, otherwise to the failure handler.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"goji.io"
@@ -104,3 +105,23 @@ func TestValidateResponse(t *testing.T) {
 	assert.Equal(t, ErrCannotValidateGoogleUser, validateResponse(nil, nil))
 	assert.Equal(t, ErrCannotValidateGoogleUser, validateResponse(&google.Userinfoplus{Name: "Ben"}, nil))
 }
+
+func TestLoginHandlerWithOptions_IncludeGrantedScopes(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID:    "client-id",
+		RedirectURL: "https://example.com/google/callback",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://accounts.google.com/o/oauth2/auth",
+		},
+	}
+	options := oauth2Login.LoginOptions{IncludeGrantedScopes: true}
+	handler := LoginHandlerWithOptions(config, options, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(oauth2Login.WithState(context.Background(), "d4e5f6"), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "true", location.Query().Get("include_granted_scopes"))
+	}
+}
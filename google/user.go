@@ -0,0 +1,33 @@
+package google
+
+import (
+	"github.com/quasor/gologin"
+	google "google.golang.org/api/oauth2/v2"
+)
+
+// providerUser wraps a Google Userinfoplus to implement the gologin provider
+// user interfaces. It is the provider-agnostic user WithUser adds to the ctx.
+type providerUser struct {
+	*google.Userinfoplus
+}
+
+// Identity returns the Google identity keyed by the account ID (the OpenID
+// Connect subject), which is stable across email address changes.
+func (u *providerUser) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.Id}
+}
+
+// AccountVerified returns true if Google has verified the account email.
+func (u *providerUser) AccountVerified() bool {
+	return u.VerifiedEmail != nil && *u.VerifiedEmail
+}
+
+// EmailAddress returns the Google account email address, if any.
+func (u *providerUser) EmailAddress() (string, bool) {
+	return u.Userinfoplus.Email, u.Userinfoplus.Email != ""
+}
+
+// PictureURL returns the Google account profile picture URL, if any.
+func (u *providerUser) PictureURL() string {
+	return u.Picture
+}
@@ -0,0 +1,35 @@
+package google
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	google "google.golang.org/api/oauth2/v2"
+)
+
+func TestProviderUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &google.Userinfoplus{Id: "900913", Email: "user@example.com"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "google", ID: "900913"}, identity)
+}
+
+func TestProviderUser_Email(t *testing.T) {
+	ctx := WithUser(context.Background(), &google.Userinfoplus{Id: "900913", Email: "user@example.com"})
+	email, ok := gologin.UserEmail(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "user@example.com", email)
+	_, ok = gologin.UserEmail(WithUser(context.Background(), &google.Userinfoplus{Id: "900913"}))
+	assert.False(t, ok)
+}
+
+func TestProviderUser_PictureURL(t *testing.T) {
+	ctx := WithUser(context.Background(), &google.Userinfoplus{Id: "900913", Picture: "https://lh3.googleusercontent.com/photo.jpg"})
+	picture, ok := gologin.UserPicture(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "https://lh3.googleusercontent.com/photo.jpg", picture)
+	_, ok = gologin.UserPicture(WithUser(context.Background(), &google.Userinfoplus{Id: "900913"}))
+	assert.False(t, ok)
+}
@@ -0,0 +1,102 @@
+package gologin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"goji.io"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/oauth2"
+)
+
+// DefaultHealthTimeout is the reachability check timeout used for
+// HealthProviders which do not set a Timeout.
+const DefaultHealthTimeout = 2 * time.Second
+
+// Provider health statuses.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// HealthProvider is a provider endpoint checked by a HealthHandler.
+type HealthProvider struct {
+	// Name identifies the provider in the health response (e.g. "github")
+	Name string
+	// URL is a provider endpoint to check, such as the authorize URL
+	URL string
+	// Timeout bounds the check. Defaults to DefaultHealthTimeout.
+	Timeout time.Duration
+}
+
+// healthResponse is the JSON body written by a HealthHandler.
+type healthResponse struct {
+	Status    string            `json:"status"`
+	Providers map[string]string `json:"providers"`
+}
+
+// HealthHandler returns a readiness handler which concurrently sends a HEAD
+// request to each provider URL. Any HTTP response counts as reachable since
+// authorize and userinfo endpoints commonly reject bare HEAD requests. The
+// handler responds with JSON per-provider "up"/"down" statuses and a 200
+// status code if all providers are up, or 503 otherwise. Checks use the ctx
+// oauth2 HTTPClient, if any, and set the UserAgent and apply the RequestHook
+// like provider requests.
+func HealthHandler(providers ...HealthProvider) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		statuses := checkProviders(ctx, providers)
+		body := healthResponse{Status: StatusUp, Providers: statuses}
+		code := http.StatusOK
+		for _, status := range statuses {
+			if status != StatusUp {
+				body.Status = StatusDown
+				code = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(body)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// checkProviders checks providers concurrently and returns their statuses by
+// name.
+func checkProviders(ctx context.Context, providers []HealthProvider) map[string]string {
+	statuses := make(map[string]string, len(providers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, provider := range providers {
+		wg.Add(1)
+		go func(p HealthProvider) {
+			defer wg.Done()
+			status := checkProvider(ctx, p)
+			mu.Lock()
+			statuses[p.Name] = status
+			mu.Unlock()
+		}(provider)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// checkProvider returns StatusUp if the provider URL responds to a HEAD
+// request within the timeout.
+func checkProvider(ctx context.Context, provider HealthProvider) string {
+	timeout := provider.Timeout
+	if timeout == 0 {
+		timeout = DefaultHealthTimeout
+	}
+	client, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	client = hookClient(client)
+	client.Timeout = timeout
+	resp, err := ctxhttp.Head(ctx, client, provider.URL)
+	if err != nil {
+		return StatusDown
+	}
+	resp.Body.Close()
+	return StatusUp
+}
@@ -0,0 +1,77 @@
+package gologin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestHealthHandler(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "HEAD", req.Method)
+		// provider endpoints may reject HEAD, but are still reachable
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	down.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	handler := HealthHandler(
+		HealthProvider{Name: "github", URL: up.URL},
+		HealthProvider{Name: "twitter", URL: down.URL},
+		HealthProvider{Name: "google", URL: slow.URL, Timeout: 20 * time.Millisecond},
+	)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+
+	// assert per-provider statuses and a 503 since some providers are down
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "application/json", w.HeaderMap.Get("Content-Type"))
+	var body healthResponse
+	err := json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusDown, body.Status)
+	expected := map[string]string{"github": StatusUp, "twitter": StatusDown, "google": StatusDown}
+	assert.Equal(t, expected, body.Providers)
+}
+
+func TestHealthHandler_AllUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	handler := HealthHandler(HealthProvider{Name: "github", URL: server.URL}, HealthProvider{Name: "google", URL: server.URL})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "up", "providers": {"github": "up", "google": "up"}}`, w.Body.String())
+}
+
+func TestHealthHandler_RequestHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, UserAgent, req.Header.Get("User-Agent"))
+		assert.Equal(t, "hooked", req.Header.Get("X-Hook"))
+	}))
+	defer server.Close()
+	RequestHook = func(req *http.Request) {
+		req.Header.Set("X-Hook", "hooked")
+	}
+	defer func() { RequestHook = nil }()
+
+	// assert that health checks set the UserAgent and apply the RequestHook
+	handler := HealthHandler(HealthProvider{Name: "github", URL: server.URL})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,43 @@
+package heroku
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "9da7a204-544e-5fd1-9a12-61176c5d4cd8"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "heroku: Context missing Heroku User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "9da7a204-544e-5fd1-9a12-61176c5d4cd8"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "heroku", ID: "9da7a204-544e-5fd1-9a12-61176c5d4cd8"}, identity)
+}
+
+func TestUser_AccountCreated(t *testing.T) {
+	// RFC 3339 created at timestamps
+	user := new(User)
+	err := json.Unmarshal([]byte(`{"id": "01234567-89ab-cdef-0123-456789abcdef", "created_at": "2012-01-01T12:00:00Z"}`), user)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2012, time.January, 1, 12, 0, 0, 0, time.UTC), user.AccountCreated())
+	assert.True(t, (&User{}).AccountCreated().IsZero())
+}
@@ -0,0 +1,5 @@
+// Package heroku provides Heroku OAuth2 login and callback handlers.
+//
+// Heroku requires client credentials be sent in the token request body and
+// API requests to select the API version with the Accept header.
+package heroku
@@ -0,0 +1,40 @@
+package heroku
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newHerokuTestServer returns a new httptest.Server which mocks the Heroku
+// token endpoint, requiring client credentials in the body, and the account
+// endpoint, requiring the versioned Accept header, which responds with the
+// given json data. It also returns a client which proxies requests to the
+// server. The caller must close the server.
+func newHerokuTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "heroku-token", "token_type": "Bearer", "expires_in": 28800}`)
+	})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != acceptVersion {
+			http.Error(w, `{"id": "missing_version", "message": "Please specify a version along with Heroku's API MIME type."}`, http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer heroku-token" {
+			http.Error(w, `{"id": "unauthorized", "message": "Invalid credentials provided."}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,77 @@
+package heroku
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const (
+	herokuAPI = "https://api.heroku.com/"
+	// acceptVersion is the Accept header value selecting the v3 Platform API
+	acceptVersion = "application/vnd.heroku+json; version=3"
+)
+
+// Endpoint is the Heroku OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://id.heroku.com/oauth/authorize",
+	TokenURL:  "https://id.heroku.com/oauth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("heroku", Endpoint)
+}
+
+// User is a Heroku account.
+type User struct {
+	ID        string            `json:"id"`
+	Email     string            `json:"email"`
+	Name      string            `json:"name"`
+	CreatedAt gologin.Timestamp `json:"created_at"`
+}
+
+// AccountCreated returns when the Heroku account was created.
+func (u *User) AccountCreated() time.Time {
+	return u.CreatedAt.Time
+}
+
+// Identity returns the Heroku identity keyed by the account ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Heroku account's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// client is a Heroku client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(herokuAPI).Set("Accept", acceptVersion).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "account"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Account gets the authenticated account User.
+// https://devcenter.heroku.com/articles/platform-api-reference#account-info
+func (c *client) Account() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time for expiry logic.
+type Clock interface {
+	Now() time.Time
+}
+
+// DefaultClock is the Clock used wherever gologin computes or checks expiry.
+// Tests may replace it with a FakeClock to control time.
+var DefaultClock Clock = systemClock{}
+
+// systemClock is a Clock which uses time.Now.
+type systemClock struct{}
+
+func (c systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock is a Clock which reports a set time until it is advanced. It is
+// safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the fake current time forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+)
+
+// useFakeClock sets the DefaultClock to a FakeClock for the duration of a
+// test. The returned func restores the DefaultClock.
+func useFakeClock(now time.Time) (*FakeClock, func()) {
+	previous := DefaultClock
+	clock := NewFakeClock(now)
+	DefaultClock = clock
+	return clock, func() { DefaultClock = previous }
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2015, 10, 9, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	assert.Equal(t, start, clock.Now())
+	clock.Advance(90 * time.Second)
+	assert.Equal(t, start.Add(90*time.Second), clock.Now())
+}
+
+func TestNewCookie_ExpiresFromClock(t *testing.T) {
+	start := time.Date(2015, 10, 9, 12, 0, 0, 0, time.UTC)
+	clock, restore := useFakeClock(start)
+	defer restore()
+
+	// assert cookie Expires is MaxAge seconds after the clock time
+	cookie := NewCookie(gologin.DefaultCookieConfig, "value")
+	assert.Equal(t, start.Add(60*time.Second), cookie.Expires)
+	clock.Advance(time.Hour)
+	cookie = NewCookie(gologin.DefaultCookieConfig, "value")
+	assert.Equal(t, start.Add(time.Hour+60*time.Second), cookie.Expires)
+}
@@ -2,19 +2,42 @@ package internal
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/quasor/gologin"
 )
 
+// NewRequestCookie returns a new http.Cookie like NewCookie, but if the
+// CookieConfig uses AutoSecure, the Secure flag is set according to whether
+// the request was made over HTTPS.
+func NewRequestCookie(config gologin.CookieConfig, req *http.Request, value string) *http.Cookie {
+	if config.AutoSecure {
+		config.Secure = IsSecureRequest(req)
+	}
+	return NewCookie(config, value)
+}
+
+// IsSecureRequest returns true if the request was received over TLS or was
+// forwarded by a proxy with the "X-Forwarded-Proto: https" header.
+func IsSecureRequest(req *http.Request) bool {
+	if req.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(req.Header.Get("X-Forwarded-Proto"), "https")
+}
+
 // NewCookie returns a new http.Cookie with the given value and CookieConfig
 // properties (name, max-age, etc.).
 //
 // The MaxAge field is used to determine whether an Expires field should be
 // added for Internet Explorer compatability and what its value should be.
+//
+// If the CookieConfig uses the "__Host-" prefix, the cookie is always Secure
+// with Path "/".
 func NewCookie(config gologin.CookieConfig, value string) *http.Cookie {
 	cookie := &http.Cookie{
-		Name:     config.Name,
+		Name:     CookieName(config),
 		Value:    value,
 		Domain:   config.Domain,
 		Path:     config.Path,
@@ -22,6 +45,10 @@ func NewCookie(config gologin.CookieConfig, value string) *http.Cookie {
 		HttpOnly: config.HTTPOnly,
 		Secure:   config.Secure,
 	}
+	if config.UseHostPrefix {
+		cookie.Secure = true
+		cookie.Path = "/"
+	}
 	// IE <9 does not understand MaxAge, set Expires if MaxAge is non-zero.
 	if expires, ok := expiresTime(config.MaxAge); ok {
 		cookie.Expires = expires
@@ -29,6 +56,65 @@ func NewCookie(config gologin.CookieConfig, value string) *http.Cookie {
 	return cookie
 }
 
+// EncodeCookieValue encodes the cookie value with the CookieConfig Codec, if
+// set.
+func EncodeCookieValue(config gologin.CookieConfig, value string) (string, error) {
+	if config.Codec == nil {
+		return value, nil
+	}
+	return config.Codec.Encode(CookieName(config), value)
+}
+
+// DecodeCookieValue decodes the cookie value with the CookieConfig Codec, if
+// set.
+func DecodeCookieValue(config gologin.CookieConfig, value string) (string, error) {
+	if config.Codec == nil {
+		return value, nil
+	}
+	var decoded string
+	err := config.Codec.Decode(CookieName(config), value, &decoded)
+	return decoded, err
+}
+
+// MarkerCookieName returns the name of companion marker cookies issued with
+// the given CookieConfig.
+func MarkerCookieName(config gologin.CookieConfig) string {
+	return CookieName(config) + "-marker"
+}
+
+// NewMarkerCookie returns a new HttpOnly companion marker http.Cookie which
+// holds the value signed with the CookieConfig Codec.
+func NewMarkerCookie(config gologin.CookieConfig, req *http.Request, value string) (*http.Cookie, error) {
+	signed, err := config.Codec.Encode(MarkerCookieName(config), value)
+	if err != nil {
+		return nil, err
+	}
+	cookie := NewRequestCookie(config, req, signed)
+	cookie.Name = MarkerCookieName(config)
+	cookie.HttpOnly = true
+	return cookie, nil
+}
+
+// VerifyMarkerCookie returns true if the request has a companion marker
+// cookie whose signed value matches the given value.
+func VerifyMarkerCookie(config gologin.CookieConfig, req *http.Request, value string) bool {
+	cookie, err := req.Cookie(MarkerCookieName(config))
+	if err != nil || config.Codec == nil {
+		return false
+	}
+	var marked string
+	err = config.Codec.Decode(MarkerCookieName(config), cookie.Value, &marked)
+	return err == nil && marked == value
+}
+
+// CookieName returns the name of cookies issued with the given CookieConfig.
+func CookieName(config gologin.CookieConfig) string {
+	if config.UseHostPrefix {
+		return gologin.HostPrefix + config.Name
+	}
+	return config.Name
+}
+
 // expiresTime converts a maxAge time in seconds to a time.Time in the future
 // if the maxAge is positive or the beginning of the epoch if maxAge is
 // negative. If maxAge is exactly 0, an empty time and false are returned
@@ -37,7 +123,7 @@ func NewCookie(config gologin.CookieConfig, value string) *http.Cookie {
 func expiresTime(maxAge int) (time.Time, bool) {
 	if maxAge > 0 {
 		d := time.Duration(maxAge) * time.Second
-		return time.Now().Add(d), true
+		return DefaultClock.Now().Add(d), true
 	} else if maxAge < 0 {
 		return time.Unix(1, 0), true // first second of the epoch
 	}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCookie(t *testing.T) {
+	config := gologin.CookieConfig{
+		Name:     "name",
+		Domain:   "example.com",
+		Path:     "/login",
+		MaxAge:   60,
+		HTTPOnly: true,
+	}
+	cookie := NewCookie(config, "value")
+	assert.Equal(t, "name", cookie.Name)
+	assert.Equal(t, "value", cookie.Value)
+	assert.Equal(t, "example.com", cookie.Domain)
+	assert.Equal(t, "/login", cookie.Path)
+	assert.Equal(t, 60, cookie.MaxAge)
+	assert.True(t, cookie.HttpOnly)
+	assert.False(t, cookie.Secure)
+	assert.False(t, cookie.Expires.IsZero())
+}
+
+func TestNewCookie_HostPrefix(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	config.Path = "/login"
+	config.UseHostPrefix = true
+	cookie := NewCookie(config, "value")
+	// assert that __Host- prefix attributes are enforced
+	assert.Equal(t, "__Host-gologin-temporary-cookie", cookie.Name)
+	assert.Equal(t, "__Host-gologin-temporary-cookie", CookieName(config))
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, "", cookie.Domain)
+}
+
+func TestNewRequestCookie_AutoSecure(t *testing.T) {
+	config := gologin.DefaultCookieConfig
+	config.AutoSecure = true
+
+	cases := []struct {
+		setup    func(req *http.Request)
+		expected bool
+	}{
+		{func(req *http.Request) {}, false},
+		{func(req *http.Request) { req.TLS = &tls.ConnectionState{} }, true},
+		{func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "https") }, true},
+		{func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "http") }, false},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/login", nil)
+		c.setup(req)
+		// assert AutoSecure overrides the static Secure field per request
+		cookie := NewRequestCookie(config, req, "value")
+		assert.Equal(t, c.expected, cookie.Secure)
+	}
+}
+
+func TestNewRequestCookie_Static(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/login", nil)
+	// assert that without AutoSecure the Secure field is used as is
+	assert.True(t, NewRequestCookie(gologin.DefaultCookieConfig, req, "value").Secure)
+	assert.False(t, NewRequestCookie(gologin.DebugOnlyCookieConfig, req, "value").Secure)
+}
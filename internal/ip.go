@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client which made the request, or
+// nil if it cannot be parsed. By default, the req.RemoteAddr host is used.
+// If trustForwardedFor is true, the last "X-Forwarded-For" address is used
+// instead, which is the client address seen by a proxy which appends to the
+// header. Only trust X-Forwarded-For behind such a proxy, since clients may
+// send any header value.
+func ClientIP(req *http.Request, trustForwardedFor bool) net.IP {
+	if trustForwardedFor {
+		if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			return net.ParseIP(strings.TrimSpace(addrs[len(addrs)-1]))
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		remoteAddr        string
+		forwardedFor      string
+		trustForwardedFor bool
+		expected          net.IP
+	}{
+		{"192.0.2.1:1234", "", false, net.ParseIP("192.0.2.1")},
+		{"192.0.2.1", "", false, net.ParseIP("192.0.2.1")},
+		{"[2001:db8::1]:1234", "", false, net.ParseIP("2001:db8::1")},
+		{"invalid", "", false, nil},
+		// X-Forwarded-For is ignored unless trusted
+		{"192.0.2.1:1234", "198.51.100.7", false, net.ParseIP("192.0.2.1")},
+		{"192.0.2.1:1234", "198.51.100.7", true, net.ParseIP("198.51.100.7")},
+		// the last address was appended by the trusted proxy
+		{"192.0.2.1:1234", "203.0.113.9, 198.51.100.7", true, net.ParseIP("198.51.100.7")},
+		{"192.0.2.1:1234", "", true, net.ParseIP("192.0.2.1")},
+		{"192.0.2.1:1234", "unknown", true, nil},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.remoteAddr
+		if c.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", c.forwardedFor)
+		}
+		assert.Equal(t, c.expected, ClientIP(req, c.trustForwardedFor))
+	}
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// DecodeJSON decodes JSON from r into v. Numbers decoded into interface{}
+// values (e.g. map[string]interface{} extras) are kept as json.Number rather
+// than float64, so large 64-bit integer IDs are not rounded.
+func DecodeJSON(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// JSONDecoder decodes http.Response JSON bodies with DecodeJSON, or with the
+// UserDecoder if set. It implements sling's ResponseDecoder.
+type JSONDecoder struct {
+	UserDecoder gologin.UserDecoder
+}
+
+// NewJSONDecoder returns a JSONDecoder which decodes with the gologin
+// UserDecoder from the ctx, if any.
+func NewJSONDecoder(ctx context.Context) JSONDecoder {
+	return JSONDecoder{UserDecoder: gologin.UserDecoderFromContext(ctx)}
+}
+
+// Decode decodes the Response Body into the value pointed to by v.
+//
+// Bodies with "Content-Encoding: gzip" are decompressed first. The default
+// Transport removes the header when it decompresses transparently, but
+// custom RoundTrippers (e.g. for tracing) may leave a gzip body.
+func (d JSONDecoder) Decode(resp *http.Response, v interface{}) error {
+	decode := DecodeJSON
+	if d.UserDecoder != nil {
+		decode = d.UserDecoder
+	}
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		body, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		return decode(body, v)
+	}
+	return decode(resp.Body, v)
+}
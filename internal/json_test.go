@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestDecodeJSON_PreservesLargeIDs(t *testing.T) {
+	data := map[string]interface{}{}
+	err := DecodeJSON(strings.NewReader(`{"id": 1234567890123456789}`), &data)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("1234567890123456789"), data["id"])
+	id, err := data["id"].(json.Number).Int64()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1234567890123456789), id)
+}
+
+func TestJSONDecoder(t *testing.T) {
+	resp := &http.Response{Body: ioutil.NopCloser(strings.NewReader(`{"id": 1234567890123456789, "name": "gopher"}`))}
+	data := map[string]interface{}{}
+	err := JSONDecoder{}.Decode(resp, &data)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("1234567890123456789"), data["id"])
+	assert.Equal(t, "gopher", data["name"])
+}
+
+func TestJSONDecoder_UserDecoder(t *testing.T) {
+	resp := &http.Response{Body: ioutil.NopCloser(strings.NewReader(`{"id": "7", "extra": "value"}`))}
+	var raw []byte
+	decoder := func(r io.Reader, v interface{}) error {
+		var err error
+		raw, err = ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(raw, v)
+	}
+	ctx := gologin.WithUserDecoder(context.Background(), decoder)
+	data := map[string]interface{}{}
+	err := NewJSONDecoder(ctx).Decode(resp, &data)
+	assert.Nil(t, err)
+	assert.Equal(t, "7", data["id"])
+	assert.Equal(t, `{"id": "7", "extra": "value"}`, string(raw))
+	// without a UserDecoder, the default DecodeJSON is used
+	assert.Nil(t, NewJSONDecoder(context.Background()).UserDecoder)
+}
+
+func TestJSONDecoder_Gzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"id": 1234567890123456789, "name": "gopher"}`))
+		gz.Close()
+	}))
+	defer server.Close()
+	// a Transport which does not transparently decompress, like some custom
+	// RoundTrippers
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := client.Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	data := map[string]interface{}{}
+	err = JSONDecoder{}.Decode(resp, &data)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("1234567890123456789"), data["id"])
+	assert.Equal(t, "gopher", data["name"])
+}
+
+func TestJSONDecoder_InvalidGzip(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": {"gzip"}},
+		Body:   ioutil.NopCloser(strings.NewReader(`{"name": "gopher"}`)),
+	}
+	data := map[string]interface{}{}
+	assert.NotNil(t, JSONDecoder{}.Decode(resp, &data))
+}
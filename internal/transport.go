@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"net/http"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// UserAgentTransport is an http.RoundTripper which sets the User-Agent
+// header of requests and applies the request Hook, if any.
+type UserAgentTransport struct {
+	UserAgent string
+	// Hook, if set, is called with the copy of each request before it is sent
+	Hook func(*http.Request)
+	// Base is the RoundTripper used to make requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// RoundTrip sets the User-Agent header on a copy of the request, applies the
+// Hook, and calls through to the Base RoundTripper.
+func (t *UserAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers should not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	if t.UserAgent != "" {
+		r.Header.Set("User-Agent", t.UserAgent)
+	}
+	if t.Hook != nil {
+		// only hooks may rewrite the URL
+		u := *req.URL
+		r.URL = &u
+		t.Hook(r)
+	}
+	return t.base().RoundTrip(r)
+}
+
+func (t *UserAgentTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// UserAgentClient returns a copy of the http.Client (or of the default
+// client if nil) whose requests set the User-Agent header. If the userAgent
+// is empty, the client is returned unchanged.
+func UserAgentClient(client *http.Client, userAgent string) *http.Client {
+	return hookClient(client, userAgent, nil)
+}
+
+// hookClient returns a copy of the http.Client (or of the default client if
+// nil) whose requests set the User-Agent header, if not empty, and are passed
+// to the hook, if not nil. Otherwise, the client is returned unchanged.
+func hookClient(client *http.Client, userAgent string, hook func(*http.Request)) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if userAgent == "" && hook == nil {
+		return client
+	}
+	c := new(http.Client)
+	*c = *client
+	c.Transport = &UserAgentTransport{UserAgent: userAgent, Hook: hook, Base: client.Transport}
+	return c
+}
+
+// WithUserAgentClient returns a copy of ctx in which the *http.Client stored
+// under the given key (e.g. oauth2.HTTPClient) sets the gologin UserAgent and
+// applies the gologin RequestHook.
+func WithUserAgentClient(ctx context.Context, key interface{}) context.Context {
+	client, _ := ctx.Value(key).(*http.Client)
+	return context.WithValue(ctx, key, hookClient(client, gologin.UserAgent, gologin.RequestHook))
+}
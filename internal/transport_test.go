@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type testKey int
+
+const testClientKey testKey = 0
+
+func TestUserAgentClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gologin-test/1.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "value", r.Header.Get("X-Other"))
+	}))
+	defer server.Close()
+
+	client := UserAgentClient(nil, "gologin-test/1.0")
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("X-Other", "value")
+	_, err := client.Do(req)
+	assert.Nil(t, err)
+	// assert the original request was not modified
+	assert.Equal(t, "", req.Header.Get("User-Agent"))
+}
+
+func TestUserAgentClient_Empty(t *testing.T) {
+	client := &http.Client{}
+	assert.Equal(t, client, UserAgentClient(client, ""))
+}
+
+func TestWithUserAgentClient_RequestHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/userinfo", r.URL.Path)
+		assert.Equal(t, "mirror", r.Header.Get("X-Egress"))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	gologin.RequestHook = func(req *http.Request) {
+		// route requests through the test server
+		req.URL.Host = serverURL.Host
+		req.Host = serverURL.Host
+		req.Header.Set("X-Egress", "mirror")
+	}
+	defer func() { gologin.RequestHook = nil }()
+
+	ctx := WithUserAgentClient(context.Background(), testClientKey)
+	client, _ := ctx.Value(testClientKey).(*http.Client)
+	req, _ := http.NewRequest("GET", "http://provider.invalid/userinfo", nil)
+	resp, err := client.Do(req)
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	// assert the original request was not modified
+	assert.Equal(t, "provider.invalid", req.URL.Host)
+	assert.Equal(t, "", req.Header.Get("X-Egress"))
+}
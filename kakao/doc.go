@@ -0,0 +1,4 @@
+// Package kakao provides Kakao OAuth2 login and callback handlers.
+//
+// Kakao requires client credentials be sent in the token request body.
+package kakao
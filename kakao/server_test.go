@@ -0,0 +1,35 @@
+package kakao
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newKakaoTestServer returns a new httptest.Server which mocks the Kakao token
+// endpoint, requiring client credentials in the body, and the user/me endpoint,
+// which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newKakaoTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": "invalid_client", "error_description": "Bad client credentials", "error_code": "KOE010"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "kakao-token", "token_type": "bearer", "expires_in": 21599}`)
+	})
+	mux.HandleFunc("/v2/user/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer kakao-token" {
+			http.Error(w, `{"msg": "this access token does not exist", "code": -401}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,85 @@
+package kakao
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const kakaoAPI = "https://kapi.kakao.com/"
+
+// Endpoint is the Kakao OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://kauth.kakao.com/oauth/authorize",
+	TokenURL:  "https://kauth.kakao.com/oauth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("kakao", Endpoint)
+}
+
+// User is a Kakao user.
+type User struct {
+	ID           int64   `json:"id"`
+	KakaoAccount Account `json:"kakao_account"`
+	Properties   struct {
+		Nickname string `json:"nickname"`
+	} `json:"properties"`
+}
+
+// Account is a Kakao user's account information, which depends on the
+// consented scopes.
+type Account struct {
+	Email           string               `json:"email"`
+	IsEmailVerified gologin.FlexibleBool `json:"is_email_verified"`
+}
+
+// Identity returns the Kakao identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: strconv.FormatInt(u.ID, 10)}
+}
+
+// EmailAddress returns the Kakao account's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.KakaoAccount.Email, u.KakaoAccount.Email != ""
+}
+
+// userResponse is a Kakao user response. Kakao may respond to failed
+// requests with an error envelope, even with HTTP 200.
+type userResponse struct {
+	User
+	// Code is a negative Kakao error code, or 0 on success
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// client is a Kakao client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(kakaoAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "v2/user/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User.
+// https://developers.kakao.com/docs/latest/en/kakaologin/rest-api#req-user-info
+func (c *client) Me() (*userResponse, *http.Response, error) {
+	userResp := new(userResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(userResp)
+	return userResp, resp, err
+}
@@ -0,0 +1,6 @@
+// Package line provides LINE Login OAuth2 login and callback handlers.
+//
+// With the openid scope, LINE returns an ID token signed with the channel
+// secret (HS256), which is verified to obtain the User. Otherwise, the User
+// is fetched from the LINE profile API.
+package line
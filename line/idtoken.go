@@ -0,0 +1,98 @@
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/quasor/gologin/oidc"
+)
+
+// lineIssuer is the iss claim of LINE ID tokens.
+const lineIssuer = "https://access.line.me"
+
+// ErrInvalidIDToken is returned when a LINE ID token is malformed, has an
+// invalid signature, or has unexpected claims.
+var ErrInvalidIDToken = errors.New("line: invalid ID token")
+
+// idTokenHeader is the JOSE header of an ID token.
+type idTokenHeader struct {
+	Alg string `json:"alg"`
+}
+
+// idTokenClaims are the LINE ID token claims.
+type idTokenClaims struct {
+	oidc.Claims
+	Name    string `json:"name"`
+	Picture string `json:"picture"`
+	Email   string `json:"email"`
+}
+
+// verifyIDToken verifies the HS256 signature of the ID token with the
+// channel secret and checks the issuer, audience (channel ID), nonce, and
+// time claims (within the clockSkew), then calls the validateClaims func, if
+// any. If valid, the User described by the claims is returned. Errors of
+// validateClaims are returned as is.
+// https://developers.line.biz/en/docs/line-login/verify-id-token/
+func verifyIDToken(idToken, channelID, channelSecret, nonce string, clockSkew time.Duration, validateClaims oidc.ClaimsValidator) (*User, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+	header := new(idTokenHeader)
+	if err := decodeSegment(parts[0], header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidIDToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidIDToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	claims := new(idTokenClaims)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	if claims.Issuer != lineIssuer || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+	if err := oidc.ValidateAudience(&claims.Claims, channelID); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrInvalidIDToken
+	}
+	if err := oidc.ValidateTime(&claims.Claims, clockSkew); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	if err := oidc.ValidateClaims(payload, validateClaims); err != nil {
+		return nil, err
+	}
+	return &User{
+		ID:      claims.Subject,
+		Name:    claims.Name,
+		Picture: claims.Picture,
+		Email:   claims.Email,
+	}, nil
+}
+
+// decodeSegment decodes a base64url encoded JSON token segment into v.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,195 @@
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/oidc"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testChannelID     = "1234567890"
+	testChannelSecret = "channel-secret"
+)
+
+// testNonce is the nonce LoginHandler sends for the test state value.
+var testNonce = oauth2Login.StateNonce("d4e5f6")
+
+// testNow is the fixed time ID tokens are verified at in tests.
+var testNow = time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// signIDToken returns an ID token with the given alg header and claims,
+// signed with HS256 using the secret.
+func signIDToken(alg string, claims map[string]interface{}, secret string) string {
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": alg})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// testClaims returns valid LINE ID token claims.
+func testClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":     "https://access.line.me",
+		"sub":     "U1234567890abcdef1234567890abcdef",
+		"aud":     testChannelID,
+		"exp":     testNow.Add(time.Hour).Unix(),
+		"iat":     testNow.Unix(),
+		"name":    "Taro Line",
+		"picture": "https://profile.line-scdn.net/abcdefghijklmn",
+		"email":   "taro.line@example.com",
+		"nonce":   testNonce,
+	}
+}
+
+// withTestClock sets the internal DefaultClock to testNow and returns a func
+// to restore it.
+func withTestClock() func() {
+	original := internal.DefaultClock
+	internal.DefaultClock = internal.NewFakeClock(testNow)
+	return func() { internal.DefaultClock = original }
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	defer withTestClock()()
+	expectedUser := &User{
+		ID:      "U1234567890abcdef1234567890abcdef",
+		Name:    "Taro Line",
+		Picture: "https://profile.line-scdn.net/abcdefghijklmn",
+		Email:   "taro.line@example.com",
+	}
+	idToken := signIDToken("HS256", testClaims(), testChannelSecret)
+	user, err := verifyIDToken(idToken, testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, user)
+}
+
+func TestVerifyIDToken_Invalid(t *testing.T) {
+	defer withTestClock()()
+	wrongAudience := testClaims()
+	wrongAudience["aud"] = "0987654321"
+	wrongIssuer := testClaims()
+	wrongIssuer["iss"] = "https://example.com"
+	expired := testClaims()
+	expired["exp"] = testNow.Add(-2 * time.Minute).Unix()
+	missingSubject := testClaims()
+	delete(missingSubject, "sub")
+	wrongNonce := testClaims()
+	wrongNonce["nonce"] = oauth2Login.StateNonce("other-state")
+	missingNonce := testClaims()
+	delete(missingNonce, "nonce")
+
+	cases := []string{
+		// wrong channel secret
+		signIDToken("HS256", testClaims(), "wrong-secret"),
+		// unexpected algorithm
+		signIDToken("none", testClaims(), testChannelSecret),
+		signIDToken("HS256", wrongAudience, testChannelSecret),
+		signIDToken("HS256", wrongIssuer, testChannelSecret),
+		signIDToken("HS256", expired, testChannelSecret),
+		signIDToken("HS256", missingSubject, testChannelSecret),
+		// nonce of another login
+		signIDToken("HS256", wrongNonce, testChannelSecret),
+		signIDToken("HS256", missingNonce, testChannelSecret),
+		"not-a-jwt",
+		"a.b.c",
+	}
+	for _, idToken := range cases {
+		user, err := verifyIDToken(idToken, testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, nil)
+		assert.Nil(t, user)
+		assert.Equal(t, ErrInvalidIDToken, err)
+	}
+}
+
+func TestVerifyIDToken_ClockSkew(t *testing.T) {
+	defer withTestClock()()
+	claims := testClaims()
+	claims["exp"] = testNow.Add(-30 * time.Second).Unix()
+	idToken := signIDToken("HS256", claims, testChannelSecret)
+	// expired within the allowed clock skew
+	user, err := verifyIDToken(idToken, testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, user)
+
+	// expired beyond a smaller clock skew
+	user, err = verifyIDToken(idToken, testChannelID, testChannelSecret, testNonce, 10*time.Second, nil)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrInvalidIDToken, err)
+}
+
+func TestVerifyIDToken_ArrayAudience(t *testing.T) {
+	defer withTestClock()()
+	claims := testClaims()
+	claims["aud"] = []string{testChannelID, "other"}
+	claims["azp"] = testChannelID
+	user, err := verifyIDToken(signIDToken("HS256", claims, testChannelSecret), testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, user)
+
+	delete(claims, "azp")
+	user, err = verifyIDToken(signIDToken("HS256", claims, testChannelSecret), testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, nil)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrInvalidIDToken, err)
+}
+
+// requireGroup returns a ClaimsValidator requiring the group in the groups
+// claim.
+func requireGroup(group string) func(claims map[string]interface{}) error {
+	return func(claims map[string]interface{}) error {
+		groups, _ := claims["groups"].([]interface{})
+		for _, g := range groups {
+			if g == group {
+				return nil
+			}
+		}
+		return errors.New("app: not a member of " + group)
+	}
+}
+
+func TestVerifyIDToken_ValidateClaims(t *testing.T) {
+	defer withTestClock()()
+	claims := testClaims()
+	claims["groups"] = []string{"staff", "admins"}
+	idToken := signIDToken("HS256", claims, testChannelSecret)
+
+	// custom validation passes
+	user, err := verifyIDToken(idToken, testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, requireGroup("admins"))
+	assert.Nil(t, err)
+	assert.NotNil(t, user)
+
+	// custom validation fails with the app's error
+	user, err = verifyIDToken(idToken, testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, requireGroup("billing"))
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "app: not a member of billing", err.Error())
+	}
+
+	// custom validation only runs after the standard checks
+	called := false
+	validate := func(claims map[string]interface{}) error {
+		called = true
+		return nil
+	}
+	_, err = verifyIDToken(signIDToken("HS256", claims, "wrong-secret"), testChannelID, testChannelSecret, testNonce, oidc.DefaultClockSkew, validate)
+	assert.Equal(t, ErrInvalidIDToken, err)
+	assert.False(t, called)
+}
+
+func TestVerifyIDToken_NoExpectedNonce(t *testing.T) {
+	defer withTestClock()()
+	// an ID token is rejected if there is no nonce to compare with
+	idToken := signIDToken("HS256", testClaims(), testChannelSecret)
+	user, err := verifyIDToken(idToken, testChannelID, testChannelSecret, "", oidc.DefaultClockSkew, nil)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrInvalidIDToken, err)
+}
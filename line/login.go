@@ -0,0 +1,141 @@
+package line
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/oidc"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// LINE login errors
+var (
+	ErrUnableToGetLineUser = errors.New("line: unable to get LINE User")
+	ErrMissingIDToken      = errors.New("line: token response has no ID token to validate claims")
+)
+
+// Provider is the LINE OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{
+	Name:            "line",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInParams,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles LINE login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value and
+// a nonce derived from it (see oauth2 StateNonce). The CallbackHandler
+// rejects ID tokens without the nonce.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandlerWithOptions(config, oauth2Login.LoginOptions{Nonce: true}, failure)
+}
+
+// CallbackHandler handles LINE redirection URI requests and adds the LINE
+// access token and User to the ctx. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+//
+// The config ClientID and ClientSecret are the LINE channel ID and channel
+// secret, which are used to verify ID tokens.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	return CallbackHandlerWithOptions(config, CallbackOptions{}, success, failure)
+}
+
+// CallbackOptions configures a LINE CallbackHandler.
+type CallbackOptions struct {
+	// ValidateClaims is called with the claims of verified ID tokens, so
+	// apps can enforce custom constraints. Its error is added to the ctx of
+	// the failure handler as is. If set, logins without an ID token (e.g.
+	// without the openid scope) fail with ErrMissingIDToken rather than
+	// falling back to the profile API.
+	ValidateClaims oidc.ClaimsValidator
+	// ClockSkew is the maximum clock skew tolerated when validating ID token
+	// time claims. Defaults to oidc.DefaultClockSkew.
+	ClockSkew time.Duration
+}
+
+// CallbackHandlerWithOptions handles LINE redirection URI requests like
+// CallbackHandler, configured by the given CallbackOptions.
+func CallbackHandlerWithOptions(config *oauth2.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
+	if options.ClockSkew == 0 {
+		options.ClockSkew = oidc.DefaultClockSkew
+	}
+	success = lineHandler(config, options, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// lineHandler is a ContextHandler that gets the OAuth2 Token from the ctx.
+// If the Token has an ID token, it is verified, including its nonce against
+// the ctx state value, to obtain the User. Otherwise,
+// the User is fetched from the profile API, unless the options require
+// claims validation. If successful, the User is added
+// to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
+func lineHandler(config *oauth2.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		var user *User
+		if idToken, ok := token.Extra("id_token").(string); ok && idToken != "" {
+			// the ID token must carry the nonce LoginHandler sent
+			state, _ := oauth2Login.StateFromContext(ctx)
+			var nonce string
+			if state != "" {
+				nonce = oauth2Login.StateNonce(state)
+			}
+			user, err = verifyIDToken(idToken, config.ClientID, config.ClientSecret, nonce, options.ClockSkew, options.ValidateClaims)
+		} else if options.ValidateClaims != nil {
+			err = ErrMissingIDToken
+		} else {
+			httpClient := config.Client(ctx, token)
+			lineClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+			lineClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+			var resp *http.Response
+			user, resp, err = lineClient.Profile()
+			err = validateResponse(user, resp, err)
+		}
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, user)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given LINE User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetLineUser
+	}
+	if user == nil || user.ID == "" {
+		return ErrUnableToGetLineUser
+	}
+	return nil
+}
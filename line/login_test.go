@@ -0,0 +1,310 @@
+package line
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler_IDToken(t *testing.T) {
+	defer withTestClock()()
+	expectedUser := &User{
+		ID:      "U1234567890abcdef1234567890abcdef",
+		Name:    "Taro Line",
+		Picture: "https://profile.line-scdn.net/abcdefghijklmn",
+		Email:   "taro.line@example.com",
+	}
+	idToken := signIDToken("HS256", testClaims(), testChannelSecret)
+	proxyClient, server := newLineTestServer(idToken, "")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		lineUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, lineUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler gets a token with an ID token, assert that:
+	// - the ID token is verified and its User is added to the ctx
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLoginHandler_Nonce(t *testing.T) {
+	config := &oauth2.Config{ClientID: testChannelID, Endpoint: Endpoint}
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+
+	// LoginHandler redirects to the AuthURL, assert that:
+	// - the nonce derived from the state value is sent
+	handler := LoginHandler(config, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "d4e5f6", location.Query().Get("state"))
+	assert.Equal(t, testNonce, location.Query().Get("nonce"))
+}
+
+func TestCallbackHandler_IDTokenNonceMismatch(t *testing.T) {
+	defer withTestClock()()
+	// ID token issued for the login of another state value
+	claims := testClaims()
+	claims["nonce"] = oauth2Login.StateNonce("attacker-state")
+	idToken := signIDToken("HS256", claims, testChannelSecret)
+	proxyClient, server := newLineTestServer(idToken, "")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInvalidIDToken, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler gets an ID token with another nonce, assert that:
+	// - failure handler is called
+	handler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestCallbackHandler_InvalidIDToken(t *testing.T) {
+	defer withTestClock()()
+	idToken := signIDToken("HS256", testClaims(), "wrong-secret")
+	proxyClient, server := newLineTestServer(idToken, "")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInvalidIDToken, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler gets an ID token not signed with the channel secret,
+	// assert that:
+	// - failure handler is called
+	handler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestCallbackHandlerWithOptions_ValidateClaims(t *testing.T) {
+	defer withTestClock()()
+	claims := testClaims()
+	claims["groups"] = []string{"staff"}
+	idToken := signIDToken("HS256", claims, testChannelSecret)
+	proxyClient, server := newLineTestServer(idToken, "")
+	defer server.Close()
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+
+	for _, group := range []string{"staff", "admins"} {
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithState(ctx, "d4e5f6")
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "failure handler called: %v", gologin.ErrorFromContext(ctx))
+		}
+
+		// CallbackHandlerWithOptions with a ValidateClaims func, assert that:
+		// - logins whose claims pass the validation succeed
+		// - logins whose claims fail it are denied with the app's error
+		options := CallbackOptions{ValidateClaims: requireGroup(group)}
+		handler := CallbackHandlerWithOptions(config, options, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		handler.ServeHTTP(ctx, w, req)
+		if group == "staff" {
+			assert.Equal(t, "success handler called", w.Body.String())
+		} else {
+			assert.Equal(t, "failure handler called: app: not a member of admins", w.Body.String())
+		}
+	}
+}
+
+func TestCallbackHandlerWithOptions_ClockSkew(t *testing.T) {
+	defer withTestClock()()
+	claims := testClaims()
+	claims["exp"] = testNow.Add(-30 * time.Second).Unix()
+	idToken := signIDToken("HS256", claims, testChannelSecret)
+	proxyClient, server := newLineTestServer(idToken, "")
+	defer server.Close()
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+
+	cases := []struct {
+		clockSkew time.Duration
+		expected  string
+	}{
+		// defaults to oidc.DefaultClockSkew
+		{0, "success handler called"},
+		{10 * time.Second, "failure handler called: line: invalid ID token"},
+	}
+	for _, c := range cases {
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithState(ctx, "d4e5f6")
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "failure handler called: %v", gologin.ErrorFromContext(ctx))
+		}
+
+		// CallbackHandlerWithOptions with a ClockSkew, assert that:
+		// - ID tokens expired within the clock skew are accepted
+		// - ID tokens expired beyond it are rejected
+		options := CallbackOptions{ClockSkew: c.clockSkew}
+		handler := CallbackHandlerWithOptions(config, options, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+func TestCallbackHandlerWithOptions_ValidateClaimsMissingIDToken(t *testing.T) {
+	jsonData := `{"userId": "U1234567890abcdef1234567890abcdef", "displayName": "Taro Line"}`
+	proxyClient, server := newLineTestServer("", jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrMissingIDToken, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandlerWithOptions with a ValidateClaims func and a token
+	// response without an ID token, assert that:
+	// - the login is denied with ErrMissingIDToken
+	// - the profile API is not used as a fallback
+	options := CallbackOptions{ValidateClaims: requireGroup("staff")}
+	handler := CallbackHandlerWithOptions(config, options, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestCallbackHandler_Profile(t *testing.T) {
+	jsonData := `{"userId": "U1234567890abcdef1234567890abcdef", "displayName": "Taro Line", "pictureUrl": "https://profile.line-scdn.net/abcdefghijklmn", "statusMessage": "Hello, LINE!"}`
+	expectedUser := &User{
+		ID:      "U1234567890abcdef1234567890abcdef",
+		Name:    "Taro Line",
+		Picture: "https://profile.line-scdn.net/abcdefghijklmn",
+	}
+	proxyClient, server := newLineTestServer("", jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: testChannelID, ClientSecret: testChannelSecret, Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		lineUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, lineUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CallbackHandler gets a token without an ID token, assert that:
+	// - the User is fetched from the profile API
+	handler := CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLineHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LineHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	lineHandler := lineHandler(config, CallbackOptions{}, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	lineHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestLineHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("LINE Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetLineUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LineHandler cannot get LINE User, assert that:
+	// - failure handler is called
+	// - error cannot get LINE User added to the failure handler ctx
+	lineHandler := lineHandler(config, CallbackOptions{}, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	lineHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "U1234567890abcdef1234567890abcdef"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetLineUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetLineUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetLineUser, validateResponse(&User{}, validResponse, nil))
+}
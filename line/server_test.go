@@ -0,0 +1,34 @@
+package line
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newLineTestServer returns a new httptest.Server which mocks the LINE token
+// endpoint, responding with the given ID token (if any), and the profile
+// endpoint, which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newLineTestServer(idToken, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2/v2.1/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if idToken == "" {
+			fmt.Fprintf(w, `{"access_token": "line-token", "token_type": "Bearer", "expires_in": 2592000, "scope": "profile"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "line-token", "token_type": "Bearer", "expires_in": 2592000, "scope": "profile openid email", "id_token": %q}`, idToken)
+	})
+	mux.HandleFunc("/v2/profile", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer line-token" {
+			http.Error(w, `{"message": "invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
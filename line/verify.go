@@ -0,0 +1,87 @@
+package line
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const lineAPI = "https://api.line.me/v2/"
+
+// Endpoint is the LINE Login v2.1 OAuth2 endpoint. LINE requires client
+// credentials in the token request body.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://access.line.me/oauth2/v2.1/authorize",
+	TokenURL:  "https://api.line.me/oauth2/v2.1/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("line", Endpoint)
+}
+
+// User is a LINE user.
+type User struct {
+	ID      string
+	Name    string
+	Picture string
+	// Email is only present in ID tokens with the email scope
+	Email string
+}
+
+// Identity returns the LINE identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the LINE user's email address from the ID token, if
+// any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// PictureURL returns the LINE user's profile picture URL, if any.
+func (u *User) PictureURL() string {
+	return u.Picture
+}
+
+// profile is a LINE profile API response.
+type profile struct {
+	UserID      string `json:"userId"`
+	DisplayName string `json:"displayName"`
+	PictureURL  string `json:"pictureUrl"`
+}
+
+// client is a LINE client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(lineAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "profile"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Profile gets the User's profile.
+// https://developers.line.biz/en/reference/line-login/#get-user-profile
+func (c *client) Profile() (*User, *http.Response, error) {
+	userProfile := new(profile)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(userProfile)
+	user := &User{
+		ID:      userProfile.UserID,
+		Name:    userProfile.DisplayName,
+		Picture: userProfile.PictureURL,
+	}
+	return user, resp, err
+}
@@ -0,0 +1,39 @@
+package linkedin
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "yrZCpj2Z12", FirstName: "Bob", LastName: "Smith"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "linkedin: Context missing LinkedIn User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "yrZCpj2Z12"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "linkedin", ID: "yrZCpj2Z12"}, identity)
+}
+
+func TestUser_Email(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "yrZCpj2Z12", Email: "bob@example.com"})
+	email, ok := gologin.UserEmail(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "bob@example.com", email)
+}
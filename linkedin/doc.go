@@ -0,0 +1,6 @@
+// Package linkedin provides LinkedIn OAuth2 login and callback handlers.
+//
+// LinkedIn requires client credentials be sent in the token request body.
+// The User name and email address are fetched with separate API requests,
+// the latter requiring the r_emailaddress scope.
+package linkedin
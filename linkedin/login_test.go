@@ -0,0 +1,152 @@
+package linkedin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testProfileJSON = `{"id": "yrZCpj2Z12", "localizedFirstName": "Bob", "localizedLastName": "Smith"}`
+	testEmailJSON   = `{"elements": [{"handle": "urn:li:emailAddress:3775708763", "handle~": {"emailAddress": "bob@example.com"}}]}`
+)
+
+func TestLinkedInHandler(t *testing.T) {
+	expectedUser := &User{ID: "yrZCpj2Z12", FirstName: "Bob", LastName: "Smith", Email: "bob@example.com"}
+	proxyClient, server := newLinkedInTestServer(testProfileJSON, testEmailJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		linkedinUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, linkedinUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// LinkedInHandler assert that:
+	// - Token is read from the ctx and passed to the LinkedIn API
+	// - the profile and email address are aggregated into the LinkedIn User
+	// - success handler is called
+	// - LinkedIn User is added to the ctx of the success handler
+	linkedinHandler := linkedinHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	linkedinHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLinkedInHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LinkedInHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	linkedinHandler := linkedinHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	linkedinHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestLinkedInHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("LinkedIn Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetLinkedInUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LinkedInHandler cannot get LinkedIn User, assert that:
+	// - failure handler is called
+	// - error cannot get LinkedIn User added to the failure handler ctx
+	linkedinHandler := linkedinHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	linkedinHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestLinkedInHandler_ErrorGettingEmail(t *testing.T) {
+	proxyClient, server := newLinkedInEmailErrorServer(testProfileJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetLinkedInUser, err)
+		}
+		_, err = UserFromContext(ctx)
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LinkedInHandler gets the profile but not the email address, assert that:
+	// - failure handler is called
+	// - error cannot get LinkedIn User added to the failure handler ctx
+	// - no partial LinkedIn User is added to the failure handler ctx
+	linkedinHandler := linkedinHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	linkedinHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "yrZCpj2Z12"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateResponse(&User{}, validResponse, nil))
+}
+
+func TestValidateEmailResponse(t *testing.T) {
+	validEmailResp := &emailAddressResponse{Elements: []emailElement{{Handle: emailHandle{EmailAddress: "bob@example.com"}}}}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 403}
+	assert.Equal(t, nil, validateEmailResponse(validEmailResp, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateEmailResponse(&emailAddressResponse{}, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateEmailResponse(nil, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateEmailResponse(validEmailResp, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetLinkedInUser, validateEmailResponse(validEmailResp, validResponse, fmt.Errorf("Server error")))
+}
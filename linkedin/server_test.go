@@ -0,0 +1,46 @@
+package linkedin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newLinkedInTestServer returns a new httptest.Server which mocks the
+// LinkedIn profile and email address endpoints, responding with the given
+// json data, and a client which proxies requests to the server. The caller
+// must close the server.
+func newLinkedInTestServer(profileJSON, emailJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v2/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, profileJSON)
+	})
+	mux.HandleFunc("/v2/emailAddress", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "members" || r.URL.Query().Get("projection") != "(elements*(handle~))" {
+			http.Error(w, `{"message": "Invalid query"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, emailJSON)
+	})
+	return client, server
+}
+
+// newLinkedInEmailErrorServer returns a new httptest.Server which mocks the
+// LinkedIn profile endpoint, responding with the given json data, and an
+// email address endpoint which responds with an error. The caller must close
+// the server.
+func newLinkedInEmailErrorServer(profileJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v2/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, profileJSON)
+	})
+	mux.HandleFunc("/v2/emailAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not enough permissions to access: GET /emailAddress"}`, http.StatusForbidden)
+	})
+	return client, server
+}
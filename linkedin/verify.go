@@ -0,0 +1,110 @@
+package linkedin
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const linkedinAPI = "https://api.linkedin.com/"
+
+// Endpoint is the LinkedIn OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://www.linkedin.com/oauth/v2/authorization",
+	TokenURL:  "https://www.linkedin.com/oauth/v2/accessToken",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("linkedin", Endpoint)
+}
+
+// User is a LinkedIn member. The ID, FirstName, and LastName are read from
+// the profile and the Email from the member's primary email address.
+type User struct {
+	ID        string `json:"id"`
+	FirstName string `json:"localizedFirstName"`
+	LastName  string `json:"localizedLastName"`
+	Email     string `json:"email"`
+}
+
+// Identity returns the LinkedIn identity keyed by the member ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the LinkedIn member's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// emailAddressResponse is a LinkedIn email address API response, which
+// wraps the email address in the projected handle of its elements.
+type emailAddressResponse struct {
+	Elements []emailElement `json:"elements"`
+}
+
+// emailElement is a LinkedIn email address element with its handle
+// projected.
+type emailElement struct {
+	Handle emailHandle `json:"handle~"`
+}
+
+// emailHandle is a LinkedIn email address handle.
+type emailHandle struct {
+	EmailAddress string `json:"emailAddress"`
+}
+
+// emailAddress returns the first email address of the response or "".
+func (r *emailAddressResponse) emailAddress() string {
+	for _, element := range r.Elements {
+		if element.Handle.EmailAddress != "" {
+			return element.Handle.EmailAddress
+		}
+	}
+	return ""
+}
+
+// client is a LinkedIn client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(linkedinAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "v2/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated member's User profile, without an Email.
+// https://learn.microsoft.com/en-us/linkedin/shared/integrations/people/profile-api
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
+
+// emailAddressParams are the query parameters of an email address request.
+type emailAddressParams struct {
+	Q          string `url:"q"`
+	Projection string `url:"projection"`
+}
+
+// EmailAddress gets the authenticated member's primary email address.
+// https://learn.microsoft.com/en-us/linkedin/shared/integrations/people/primary-contact-api
+func (c *client) EmailAddress() (*emailAddressResponse, *http.Response, error) {
+	emailResp := new(emailAddressResponse)
+	params := &emailAddressParams{Q: "members", Projection: "(elements*(handle~))"}
+	resp, err := c.sling.New().Get("v2/emailAddress").QueryStruct(params).ReceiveSuccess(emailResp)
+	return emailResp, resp, err
+}
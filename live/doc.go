@@ -0,0 +1,6 @@
+// Package live provides Microsoft personal account (Live, Outlook.com)
+// OAuth2 login and callback handlers.
+//
+// Personal accounts authenticate against the Microsoft identity platform
+// "consumers" tenant. Work and school (Azure AD) accounts use other tenants.
+package live
@@ -0,0 +1,153 @@
+package live
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestLiveHandler(t *testing.T) {
+	jsonData := `{"id": "4a3f1b2c5d6e7f80", "displayName": "Ada Lovelace", "userPrincipalName": "ada@outlook.com", "mail": null}`
+	expectedUser := &User{ID: "4a3f1b2c5d6e7f80", DisplayName: "Ada Lovelace", UserPrincipalName: "ada@outlook.com"}
+	proxyClient, server := newLiveTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		liveUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, liveUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// LiveHandler assert that:
+	// - Token is read from the ctx and passed to the Live API
+	// - live User is obtained from the Live API
+	// - success handler is called
+	// - live User is added to the ctx of the success handler
+	liveHandler := liveHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	liveHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLiveHandler_AcceptLanguage(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/v1.0/me", func(w http.ResponseWriter, r *http.Request) {
+		// assert the ctx Accept-Language is sent to the Graph API
+		assert.Equal(t, "de-DE,de;q=0.9", r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "4a3f1b2c5d6e7f80", "displayName": "Ada Lovelace"}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	ctx = gologin.WithAcceptLanguage(ctx, "de-DE,de;q=0.9")
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	liveHandler := liveHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	liveHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLiveHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LiveHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	liveHandler := liveHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	liveHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestLiveHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Live Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetLiveUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LiveHandler cannot get Live User, assert that:
+	// - failure handler is called
+	// - error cannot get Live User added to the failure handler ctx
+	liveHandler := liveHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	liveHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestEndpoint_ConsumersTenant(t *testing.T) {
+	assert.Equal(t, "https://login.microsoftonline.com/consumers/oauth2/v2.0/authorize", Endpoint.AuthURL)
+	assert.Equal(t, "https://login.microsoftonline.com/consumers/oauth2/v2.0/token", Endpoint.TokenURL)
+
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: Endpoint,
+		Scopes:   Scopes,
+	}
+	// LoginHandler redirects to the consumers tenant authorize endpoint
+	loginHandler := LoginHandler(config, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := oauth2Login.WithState(context.Background(), "state_val")
+	loginHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "/consumers/oauth2/v2.0/authorize", location.Path)
+		assert.Equal(t, "openid User.Read", location.Query().Get("scope"))
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "4a3f1b2c5d6e7f80"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetLiveUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetLiveUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetLiveUser, validateResponse(&User{}, validResponse, nil))
+}
@@ -0,0 +1,21 @@
+package live
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newLiveTestServer returns a new httptest.Server which mocks the Live
+// user endpoint and a client which proxies requests to the server. The server
+// responds with the given json data. The caller must close the server.
+func newLiveTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1.0/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
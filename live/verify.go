@@ -0,0 +1,83 @@
+package live
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const graphAPI = "https://graph.microsoft.com/v1.0/"
+
+// Endpoint is the Microsoft identity platform OAuth2 endpoint for the
+// consumers tenant, which only accepts personal Microsoft accounts.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://login.microsoftonline.com/consumers/oauth2/v2.0/authorize",
+	TokenURL: "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("live", Endpoint)
+}
+
+// Scopes are the scopes required to read a personal account profile from
+// Microsoft Graph.
+var Scopes = []string{"openid", "User.Read"}
+
+// User is a Microsoft personal account user.
+//
+// Personal accounts often have no Mail, the UserPrincipalName is the
+// account's sign-in email address.
+type User struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	GivenName         string `json:"givenName"`
+	Surname           string `json:"surname"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	Mail              string `json:"mail"`
+}
+
+// Identity returns the Microsoft identity keyed by the Graph user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Microsoft user's Mail, or else the
+// UserPrincipalName, which is the sign-in email address of personal accounts.
+func (u *User) EmailAddress() (string, bool) {
+	if u.Mail != "" {
+		return u.Mail, true
+	}
+	return u.UserPrincipalName, u.UserPrincipalName != ""
+}
+
+// client is a Microsoft Graph client for obtaining the current User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, acceptLanguage string, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(graphAPI).ResponseDecoder(internal.JSONDecoder{})
+	if acceptLanguage != "" {
+		base.Set("Accept-Language", acceptLanguage)
+	}
+	if userInfoURL == "" {
+		userInfoURL = "me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the signed-in user's profile.
+// https://docs.microsoft.com/en-us/graph/api/user-get
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
@@ -0,0 +1,80 @@
+package gologin
+
+import (
+	"sort"
+	"strings"
+)
+
+// Locale is a language with an optional country, such as en_US.
+type Locale struct {
+	Language string `json:"language"`
+	Country  string `json:"country"`
+}
+
+// String returns the locale as "language_COUNTRY" or "language".
+func (l Locale) String() string {
+	if l.Country == "" {
+		return l.Language
+	}
+	return l.Language + "_" + l.Country
+}
+
+// LocalizedString is a provider profile string available in several locales,
+// such as a LinkedIn MultiLocaleString name.
+type LocalizedString struct {
+	// Localized maps locales (e.g. "en_US") to the localized value
+	Localized map[string]string `json:"localized"`
+	// PreferredLocale is the locale the user prefers
+	PreferredLocale Locale `json:"preferredLocale"`
+}
+
+// Value returns the value for the first given locale which is available.
+// Locales may be "language_COUNTRY", "language-COUNTRY" (as in
+// Accept-Language), or a bare language matching any country. If none match,
+// the value for the preferred locale is returned, or else the value for the
+// first available locale in sorted order.
+func (s LocalizedString) Value(locales ...string) string {
+	for _, locale := range locales {
+		if value, ok := s.lookup(locale); ok {
+			return value
+		}
+	}
+	if value, ok := s.lookup(s.PreferredLocale.String()); ok {
+		return value
+	}
+	keys := make([]string, 0, len(s.Localized))
+	for key := range s.Localized {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+	return s.Localized[keys[0]]
+}
+
+// lookup returns the value for a locale, matching a bare language to any
+// country.
+func (s LocalizedString) lookup(locale string) (string, bool) {
+	locale = strings.Replace(locale, "-", "_", -1)
+	if locale == "" {
+		return "", false
+	}
+	if value, ok := s.Localized[locale]; ok {
+		return value, true
+	}
+	if strings.Contains(locale, "_") {
+		return "", false
+	}
+	keys := make([]string, 0, len(s.Localized))
+	for key := range s.Localized {
+		if strings.HasPrefix(key, locale+"_") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return s.Localized[keys[0]], true
+}
@@ -0,0 +1,49 @@
+package gologin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// LinkedIn-style multi-locale profile name
+const multiLocaleJSON = `{
+	"localized": {"en_US": "Bob", "de_DE": "Robert", "fr_FR": "Robert-Jean"},
+	"preferredLocale": {"country": "DE", "language": "de"}
+}`
+
+func TestLocalizedString_Value(t *testing.T) {
+	var name LocalizedString
+	err := json.Unmarshal([]byte(multiLocaleJSON), &name)
+	assert.Nil(t, err)
+	assert.Equal(t, Locale{Language: "de", Country: "DE"}, name.PreferredLocale)
+
+	cases := []struct {
+		locales  []string
+		expected string
+	}{
+		{[]string{"en_US"}, "Bob"},
+		{[]string{"fr-FR"}, "Robert-Jean"},
+		{[]string{"fr"}, "Robert-Jean"},
+		{[]string{"es_ES", "en"}, "Bob"},
+		// fall back to the preferred locale
+		{[]string{"es_ES"}, "Robert"},
+		{nil, "Robert"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, name.Value(c.locales...))
+	}
+}
+
+func TestLocalizedString_ValueFirstAvailable(t *testing.T) {
+	name := LocalizedString{Localized: map[string]string{"fr_FR": "Robert-Jean", "en_US": "Bob"}}
+	// assert the first available locale is used without a preferred locale
+	assert.Equal(t, "Bob", name.Value())
+	assert.Equal(t, "", LocalizedString{}.Value("en_US"))
+}
+
+func TestLocale_String(t *testing.T) {
+	assert.Equal(t, "en_US", Locale{Language: "en", Country: "US"}.String())
+	assert.Equal(t, "en", Locale{Language: "en"}.String())
+}
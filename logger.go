@@ -0,0 +1,17 @@
+package gologin
+
+// Logger is the logging hook gologin uses to report notable events, such as
+// insecure handler configurations. It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// DefaultLogger is the Logger used by gologin handlers. It discards messages
+// by default; set it to a Logger such as log.New(os.Stderr, "", log.LstdFlags)
+// to receive them.
+var DefaultLogger Logger = nopLogger{}
+
+// nopLogger is a Logger which discards messages.
+type nopLogger struct{}
+
+func (l nopLogger) Printf(format string, v ...interface{}) {}
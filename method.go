@@ -0,0 +1,39 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// ErrMethodNotAllowed is returned when a handler receives a request whose
+// method it does not allow.
+var ErrMethodNotAllowed = fmt.Errorf("Method not allowed")
+
+// MethodHandler calls the handler if the request method is one of the given
+// methods. Otherwise, it sets the Allow header to the methods and calls the
+// failure handler with ErrMethodNotAllowed, which the DefaultFailureHandler
+// responds to with a 405 status code.
+//
+// Login and callback handlers allow GET, while token handlers allow POST.
+func MethodHandler(methods []string, handler, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	allow := strings.Join(methods, ", ")
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		for _, method := range methods {
+			if req.Method == method {
+				handler.ServeHTTP(ctx, w, req)
+				return
+			}
+		}
+		w.Header().Set("Allow", allow)
+		ctx = WithError(ctx, ErrMethodNotAllowed)
+		failure.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
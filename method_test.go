@@ -0,0 +1,49 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestMethodHandler(t *testing.T) {
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := MethodHandler([]string{"GET", "POST"}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	for _, method := range []string{"GET", "POST"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/", nil)
+		handler.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+		assert.Equal(t, "", w.Header().Get("Allow"))
+	}
+}
+
+func TestMethodHandler_NotAllowed(t *testing.T) {
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrMethodNotAllowed, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := MethodHandler([]string{"GET", "POST"}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+}
+
+func TestMethodHandler_DefaultFailureHandler(t *testing.T) {
+	handler := MethodHandler([]string{"POST"}, testutils.AssertSuccessNotCalled(t), nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+}
@@ -0,0 +1,41 @@
+package microsoft
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "87d349ed-44d7-43e1-9a83-5f2406dee5bd"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "microsoft: Context missing Microsoft User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "87d349ed-44d7-43e1-9a83-5f2406dee5bd"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "microsoft", ID: "87d349ed-44d7-43e1-9a83-5f2406dee5bd"}, identity)
+}
+
+func TestUser_Email(t *testing.T) {
+	email, ok := (&User{UserPrincipalName: "adele@contoso.com", Mail: "adele.vance@contoso.com"}).EmailAddress()
+	assert.True(t, ok)
+	assert.Equal(t, "adele.vance@contoso.com", email)
+	// the UserPrincipalName is not used as an email address
+	_, ok = (&User{UserPrincipalName: "adele@contoso.com"}).EmailAddress()
+	assert.False(t, ok)
+}
@@ -0,0 +1,8 @@
+// Package microsoft provides Microsoft identity platform (Azure AD) OAuth2
+// login and callback handlers for work and school accounts.
+//
+// Azure AD authenticates against a tenant, such as a tenant ID, a verified
+// domain, "organizations", or "common". Users are read from the Microsoft
+// Graph API and, for single tenants, must belong to the tenant's
+// organization. For personal Microsoft accounts only, see the live package.
+package microsoft
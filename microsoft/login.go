@@ -0,0 +1,175 @@
+package microsoft
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Microsoft login errors
+var (
+	ErrUnableToGetMicrosoftUser = errors.New("microsoft: unable to get Microsoft User")
+	ErrTenantMismatch           = errors.New("microsoft: config Endpoint is not the tenant's Endpoint")
+	ErrWrongTenant              = errors.New("microsoft: user does not belong to the tenant")
+)
+
+// Provider is the Microsoft OAuth2 Provider for the common tenant for use
+// with oauth2 HandleCallback. Single tenant apps should use NewProvider.
+var Provider = NewProvider(TenantCommon)
+
+// NewProvider returns the Microsoft OAuth2 Provider for the given tenant for
+// use with oauth2 HandleCallback.
+func NewProvider(tenant string) oauth2Login.Provider {
+	callbackHandler := func(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+		return CallbackHandler(config, tenant, success, failure)
+	}
+	return oauth2Login.Provider{Name: "microsoft", CallbackHandler: callbackHandler}
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Microsoft login requests for the given tenant by
+// reading the state value from the ctx and redirecting requests to the
+// AuthURL with that state value.
+//
+// Configs without an Endpoint use the tenant's Endpoint. If the config
+// Endpoint is another tenant's, login fails with ErrTenantMismatch.
+func LoginHandler(config *oauth2.Config, tenant string, failure goji.Handler) goji.Handler {
+	config, err := configureTenant(config, tenant)
+	if err != nil {
+		return errorHandler(err, failure)
+	}
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Microsoft redirection URI requests for the given
+// tenant and adds the Microsoft access token and User to the ctx. If
+// authentication succeeds, handling delegates to the success handler,
+// otherwise to the failure handler.
+//
+// Configs without an Endpoint use the tenant's Endpoint. If the config
+// Endpoint is another tenant's, login fails with ErrTenantMismatch. For
+// single tenants (i.e. not TenantCommon, TenantOrganizations, or
+// TenantConsumers), users whose organization is not the tenant fail with
+// ErrWrongTenant.
+func CallbackHandler(config *oauth2.Config, tenant string, success, failure goji.Handler) goji.Handler {
+	config, err := configureTenant(config, tenant)
+	if err != nil {
+		return errorHandler(err, failure)
+	}
+	success = microsoftHandler(config, tenant, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// configureTenant returns the config for use with the tenant. If the config
+// has no Endpoint, a copy of the config using the tenant's Endpoint is
+// returned. If the config has another tenant's Endpoint, ErrTenantMismatch
+// is returned.
+func configureTenant(config *oauth2.Config, tenant string) (*oauth2.Config, error) {
+	endpoint := NewEndpoint(normalizeTenant(tenant))
+	if config.Endpoint.AuthURL == "" && config.Endpoint.TokenURL == "" {
+		configured := *config
+		configured.Endpoint = endpoint
+		return &configured, nil
+	}
+	if config.Endpoint.AuthURL != endpoint.AuthURL || config.Endpoint.TokenURL != endpoint.TokenURL {
+		return nil, ErrTenantMismatch
+	}
+	return config, nil
+}
+
+// errorHandler returns a goji.Handler which calls the failure handler with
+// the given error added to the ctx.
+func errorHandler(err error, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		ctx = gologin.WithError(ctx, err)
+		failure.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// microsoftHandler is a ContextHandler that gets the OAuth2 Token from the
+// ctx to get the corresponding Microsoft User and, for single tenants,
+// checks the User's organization is the tenant. If successful, the User is
+// added to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
+func microsoftHandler(config *oauth2.Config, tenant string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		microsoftClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		microsoftClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		user, resp, err := microsoftClient.Me()
+		err = validateResponse(user, resp, err)
+		if err == nil && !isMultiTenant(tenant) {
+			orgResp, resp, orgErr := microsoftClient.Organization()
+			err = validateOrganization(orgResp, resp, orgErr, tenant)
+		}
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, user)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Microsoft User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetMicrosoftUser
+	}
+	if user == nil || user.ID == "" {
+		return ErrUnableToGetMicrosoftUser
+	}
+	return nil
+}
+
+// validateOrganization returns an error if the given Microsoft organization
+// response, raw http.Response, or error are unexpected, or
+// ErrWrongTenant if the organization is not the tenant. Returns nil if they
+// are valid.
+func validateOrganization(orgResp *organizationResponse, resp *http.Response, err error, tenant string) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetMicrosoftUser
+	}
+	if orgResp == nil || len(orgResp.Value) == 0 {
+		return ErrWrongTenant
+	}
+	for _, org := range orgResp.Value {
+		if !org.isTenant(tenant) {
+			return ErrWrongTenant
+		}
+	}
+	return nil
+}
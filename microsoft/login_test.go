@@ -0,0 +1,269 @@
+package microsoft
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const testUserJSON = `{"id": "87d349ed-44d7-43e1-9a83-5f2406dee5bd", "displayName": "Adele Vance", "userPrincipalName": "adele@contoso.com", "mail": "adele.vance@contoso.com"}`
+
+const (
+	testContosoOrgJSON  = `{"value": [{"id": "84c31ca0-ac3b-4eae-ad11-519d80233e6f", "verifiedDomains": [{"name": "contoso.com"}, {"name": "contoso.onmicrosoft.com"}]}]}`
+	testFabrikamOrgJSON = `{"value": [{"id": "2b6c8f5e-7d1a-4c3e-9f0b-1a2b3c4d5e6f", "verifiedDomains": [{"name": "fabrikam.onmicrosoft.com"}]}]}`
+)
+
+var testUser = &User{
+	ID:                "87d349ed-44d7-43e1-9a83-5f2406dee5bd",
+	DisplayName:       "Adele Vance",
+	UserPrincipalName: "adele@contoso.com",
+	Mail:              "adele.vance@contoso.com",
+}
+
+func TestCallbackHandler_Tenant(t *testing.T) {
+	proxyClient, server := newTenantTestServer("contoso.onmicrosoft.com", testUserJSON, testContosoOrgJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	// config without an Endpoint, so the tenant's Endpoint must be used
+	config := &oauth2.Config{ClientID: "client-id", ClientSecret: "client-secret"}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		microsoftUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, testUser, microsoftUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler for a tenant, assert that:
+	// - the code is exchanged with the tenant's token endpoint
+	// - the User's organization is checked to be the tenant
+	// - the Microsoft User is added to the ctx of the success handler
+	handler := CallbackHandler(config, "contoso.onmicrosoft.com", goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	// the caller's config is not modified
+	assert.Equal(t, oauth2.Endpoint{}, config.Endpoint)
+}
+
+func TestCallbackHandler_WrongTenant(t *testing.T) {
+	proxyClient, server := newTenantTestServer("contoso.onmicrosoft.com", testUserJSON, testFabrikamOrgJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: "client-id", ClientSecret: "client-secret"}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrWrongTenant, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler for a tenant with a user of another tenant, assert that:
+	// - the failure handler is called with ErrWrongTenant
+	handler := CallbackHandler(config, "contoso.onmicrosoft.com", success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestCallbackHandler_TenantMismatch(t *testing.T) {
+	// config with the common tenant's Endpoint
+	config := &oauth2.Config{ClientID: "client-id", Endpoint: Endpoint}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrTenantMismatch, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler for a tenant whose Endpoint is not the config's, assert that:
+	// - the failure handler is called with ErrTenantMismatch
+	handler := CallbackHandler(config, "contoso.onmicrosoft.com", success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(oauth2Login.WithState(context.Background(), "d4e5f6"), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestLoginHandler_Tenant(t *testing.T) {
+	// config without an Endpoint, so the tenant's Endpoint must be used
+	config := &oauth2.Config{ClientID: "client-id"}
+	loginHandler := LoginHandler(config, "contoso.onmicrosoft.com", testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(oauth2Login.WithState(context.Background(), "d4e5f6"), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://login.microsoftonline.com/contoso.onmicrosoft.com/oauth2/v2.0/authorize?client_id=client-id&response_type=code&state=d4e5f6", w.HeaderMap.Get("Location"))
+}
+
+func TestLoginHandler_TenantMismatch(t *testing.T) {
+	config := &oauth2.Config{ClientID: "client-id", Endpoint: NewEndpoint("fabrikam.onmicrosoft.com")}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrTenantMismatch, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LoginHandler for a tenant whose Endpoint is not the config's, assert that:
+	// - the failure handler is called with ErrTenantMismatch instead of redirecting
+	loginHandler := LoginHandler(config, "contoso.onmicrosoft.com", goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(oauth2Login.WithState(context.Background(), "d4e5f6"), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	assert.Equal(t, "", w.HeaderMap.Get("Location"))
+}
+
+func TestMicrosoftHandler(t *testing.T) {
+	proxyClient, server := newMicrosoftTestServer(testUserJSON, testContosoOrgJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		microsoftUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, testUser, microsoftUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// MicrosoftHandler assert that:
+	// - Token is read from the ctx and passed to the Graph API
+	// - Microsoft User is obtained from the Graph API
+	// - success handler is called
+	// - Microsoft User is added to the ctx of the success handler
+	microsoftHandler := microsoftHandler(config, TenantCommon, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	microsoftHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestMicrosoftHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// MicrosoftHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	microsoftHandler := microsoftHandler(config, TenantCommon, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	microsoftHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestMicrosoftHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Microsoft Graph Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetMicrosoftUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// MicrosoftHandler cannot get Microsoft User, assert that:
+	// - failure handler is called
+	// - error cannot get Microsoft User added to the failure handler ctx
+	microsoftHandler := microsoftHandler(config, TenantCommon, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	microsoftHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestMicrosoftHandler_TenantID(t *testing.T) {
+	proxyClient, server := newMicrosoftTestServer(testUserJSON, testContosoOrgJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// MicrosoftHandler for a tenant ID (case insensitive) of the User's organization
+	microsoftHandler := microsoftHandler(&oauth2.Config{}, "84C31CA0-AC3B-4EAE-AD11-519D80233E6F", goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	microsoftHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestConfigureTenant(t *testing.T) {
+	config := &oauth2.Config{ClientID: "client-id"}
+	configured, err := configureTenant(config, TenantOrganizations)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://login.microsoftonline.com/organizations/oauth2/v2.0/authorize", configured.Endpoint.AuthURL)
+	assert.Equal(t, "https://login.microsoftonline.com/organizations/oauth2/v2.0/token", configured.Endpoint.TokenURL)
+	assert.Equal(t, "client-id", configured.ClientID)
+	// configs with the tenant's Endpoint are used as is
+	config = &oauth2.Config{Endpoint: NewEndpoint("contoso.onmicrosoft.com")}
+	configured, err = configureTenant(config, "contoso.onmicrosoft.com")
+	assert.Nil(t, err)
+	assert.Equal(t, config, configured)
+	// without a tenant, the common tenant is used
+	configured, err = configureTenant(&oauth2.Config{}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, Endpoint, configured.Endpoint)
+	// configs with another tenant's Endpoint are rejected
+	configured, err = configureTenant(&oauth2.Config{Endpoint: Endpoint}, "contoso.onmicrosoft.com")
+	assert.Nil(t, configured)
+	assert.Equal(t, ErrTenantMismatch, err)
+}
+
+func TestValidateOrganization(t *testing.T) {
+	contoso := &organizationResponse{Value: []Organization{{ID: "84c31ca0-ac3b-4eae-ad11-519d80233e6f", VerifiedDomains: []VerifiedDomain{{Name: "contoso.com"}}}}}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 403}
+	assert.Equal(t, nil, validateOrganization(contoso, validResponse, nil, "84c31ca0-ac3b-4eae-ad11-519d80233e6f"))
+	assert.Equal(t, nil, validateOrganization(contoso, validResponse, nil, "Contoso.com"))
+	assert.Equal(t, ErrWrongTenant, validateOrganization(contoso, validResponse, nil, "fabrikam.com"))
+	assert.Equal(t, ErrWrongTenant, validateOrganization(&organizationResponse{}, validResponse, nil, "contoso.com"))
+	assert.Equal(t, ErrUnableToGetMicrosoftUser, validateOrganization(contoso, invalidResponse, nil, "contoso.com"))
+	assert.Equal(t, ErrUnableToGetMicrosoftUser, validateOrganization(contoso, validResponse, fmt.Errorf("Server error"), "contoso.com"))
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "87d349ed-44d7-43e1-9a83-5f2406dee5bd"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetMicrosoftUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetMicrosoftUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetMicrosoftUser, validateResponse(&User{}, validResponse, nil))
+}
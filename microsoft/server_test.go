@@ -0,0 +1,52 @@
+package microsoft
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newMicrosoftTestServer returns a new httptest.Server which mocks the
+// Microsoft Graph user endpoint, which responds with the given json data, and
+// organization endpoint, which responds with the given organization json
+// data. It also returns a client which proxies requests to the server. The
+// caller must close the server.
+func newMicrosoftTestServer(jsonData, orgJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1.0/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	mux.HandleFunc("/v1.0/organization", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, orgJSON)
+	})
+	return client, server
+}
+
+// newTenantTestServer returns a new httptest.Server which mocks the token
+// endpoint of the given tenant and the Microsoft Graph user and organization
+// endpoints, which respond with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newTenantTestServer(tenant, jsonData, orgJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/"+tenant+"/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "microsoft-token", "token_type": "Bearer", "expires_in": 3599}`)
+	})
+	graphHandler := func(jsonData string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer microsoft-token" {
+				http.Error(w, `{"error": {"code": "InvalidAuthenticationToken"}}`, http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, jsonData)
+		}
+	}
+	mux.HandleFunc("/v1.0/me", graphHandler(jsonData))
+	mux.HandleFunc("/v1.0/organization", graphHandler(orgJSON))
+	return client, server
+}
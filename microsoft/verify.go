@@ -0,0 +1,147 @@
+package microsoft
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const graphAPI = "https://graph.microsoft.com/v1.0/"
+
+// Tenants which accept users from any Azure AD tenant.
+const (
+	// TenantCommon accepts work and school and personal accounts
+	TenantCommon = "common"
+	// TenantOrganizations accepts work and school accounts only
+	TenantOrganizations = "organizations"
+	// TenantConsumers accepts personal accounts only
+	TenantConsumers = "consumers"
+)
+
+// normalizeTenant returns the tenant or TenantCommon if it is empty.
+func normalizeTenant(tenant string) string {
+	if tenant == "" {
+		return TenantCommon
+	}
+	return tenant
+}
+
+// isMultiTenant returns true if the tenant accepts users from many tenants,
+// rather than a single tenant ID or domain.
+func isMultiTenant(tenant string) bool {
+	switch strings.ToLower(normalizeTenant(tenant)) {
+	case TenantCommon, TenantOrganizations, TenantConsumers:
+		return true
+	}
+	return false
+}
+
+// Endpoint is the Microsoft identity platform OAuth2 endpoint for the common
+// tenant.
+var Endpoint = NewEndpoint(TenantCommon)
+
+func init() {
+	gologin.RegisterEndpoint("microsoft", Endpoint)
+}
+
+// NewEndpoint returns the Microsoft identity platform OAuth2 endpoint for the
+// given tenant (e.g. a tenant ID, "contoso.onmicrosoft.com", or
+// TenantOrganizations).
+func NewEndpoint(tenant string) oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/authorize",
+		TokenURL: "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/token",
+	}
+}
+
+// Scopes are the scopes required to read the signed-in user's profile and
+// organization from Microsoft Graph.
+var Scopes = []string{"openid", "User.Read"}
+
+// User is a Microsoft Graph user.
+type User struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	Mail              string `json:"mail"`
+}
+
+// Identity returns the Microsoft identity keyed by the Graph user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Microsoft user's Mail. Users without a mailbox
+// have no Mail, their UserPrincipalName may not be a deliverable address.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Mail, u.Mail != ""
+}
+
+// Organization is an Azure AD tenant.
+type Organization struct {
+	ID              string           `json:"id"`
+	VerifiedDomains []VerifiedDomain `json:"verifiedDomains"`
+}
+
+// VerifiedDomain is a domain verified by an Azure AD tenant.
+type VerifiedDomain struct {
+	Name string `json:"name"`
+}
+
+// isTenant returns true if the tenant is the organization's ID or one of its
+// verified domains.
+func (o Organization) isTenant(tenant string) bool {
+	if o.ID != "" && strings.EqualFold(o.ID, tenant) {
+		return true
+	}
+	for _, domain := range o.VerifiedDomains {
+		if domain.Name != "" && strings.EqualFold(domain.Name, tenant) {
+			return true
+		}
+	}
+	return false
+}
+
+// organizationResponse is a Microsoft Graph response, which wraps the
+// signed-in user's Organizations in value.
+type organizationResponse struct {
+	Value []Organization `json:"value"`
+}
+
+// client is a Microsoft Graph client for obtaining the current User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(graphAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the signed-in user's profile.
+// https://learn.microsoft.com/en-us/graph/api/user-get
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}
+
+// Organization gets the signed-in user's organization (tenant).
+// https://learn.microsoft.com/en-us/graph/api/organization-get
+func (c *client) Organization() (*organizationResponse, *http.Response, error) {
+	orgResp := new(organizationResponse)
+	resp, err := c.sling.New().Get("organization").ReceiveSuccess(orgResp)
+	return orgResp, resp, err
+}
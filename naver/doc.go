@@ -0,0 +1,4 @@
+// Package naver provides Naver OAuth2 login and callback handlers.
+//
+// Naver requires client credentials be sent in the token request body.
+package naver
@@ -0,0 +1,35 @@
+package naver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newNaverTestServer returns a new httptest.Server which mocks the Naver token
+// endpoint, requiring client credentials in the body, and the nid/me endpoint,
+// which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newNaverTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"error": "invalid_request", "error_description": "no valid data in session"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "naver-token", "token_type": "bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/v1/nid/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer naver-token" {
+			http.Error(w, `{"resultcode": "024", "message": "Authentication failed"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,80 @@
+package naver
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const (
+	naverAPI = "https://openapi.naver.com/"
+	// resultOK is the Naver resultcode of a successful API response
+	resultOK = "00"
+)
+
+// Endpoint is the Naver OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://nid.naver.com/oauth2.0/authorize",
+	TokenURL:  "https://nid.naver.com/oauth2.0/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("naver", Endpoint)
+}
+
+// User is a Naver user. Fields other than ID depend on the profile
+// information the user consented to share.
+type User struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Nickname string `json:"nickname"`
+}
+
+// Identity returns the Naver identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Naver user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// userResponse is a Naver profile response, which wraps the User in a
+// resultcode envelope.
+type userResponse struct {
+	ResultCode string `json:"resultcode"`
+	Message    string `json:"message"`
+	Response   User   `json:"response"`
+}
+
+// client is a Naver client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(naverAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "v1/nid/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User's profile.
+// https://developers.naver.com/docs/login/profile/profile.md
+func (c *client) Me() (*userResponse, *http.Response, error) {
+	userResp := new(userResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(userResp)
+	return userResp, resp, err
+}
@@ -0,0 +1,45 @@
+package notion
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	workspaceKey key = iota
+	botUserKey
+)
+
+// WithWorkspace returns a copy of ctx that stores the Notion Workspace.
+func WithWorkspace(ctx context.Context, workspace *Workspace) context.Context {
+	ctx = gologin.WithUser(ctx, workspace)
+	return context.WithValue(ctx, workspaceKey, workspace)
+}
+
+// WorkspaceFromContext returns the Notion Workspace from the ctx.
+func WorkspaceFromContext(ctx context.Context) (*Workspace, error) {
+	workspace, ok := ctx.Value(workspaceKey).(*Workspace)
+	if !ok {
+		return nil, fmt.Errorf("notion: Context missing Notion Workspace")
+	}
+	return workspace, nil
+}
+
+// WithBotUser returns a copy of ctx that stores the Notion BotUser.
+func WithBotUser(ctx context.Context, bot *BotUser) context.Context {
+	return context.WithValue(ctx, botUserKey, bot)
+}
+
+// BotUserFromContext returns the Notion BotUser from the ctx.
+func BotUserFromContext(ctx context.Context) (*BotUser, error) {
+	bot, ok := ctx.Value(botUserKey).(*BotUser)
+	if !ok {
+		return nil, fmt.Errorf("notion: Context missing Notion BotUser")
+	}
+	return bot, nil
+}
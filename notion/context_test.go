@@ -0,0 +1,54 @@
+package notion
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextWorkspace(t *testing.T) {
+	expectedWorkspace := &Workspace{ID: "workspace-id", BotID: "bot-id"}
+	ctx := WithWorkspace(context.Background(), expectedWorkspace)
+	workspace, err := WorkspaceFromContext(ctx)
+	assert.Equal(t, expectedWorkspace, workspace)
+	assert.Nil(t, err)
+}
+
+func TestContextWorkspace_Error(t *testing.T) {
+	workspace, err := WorkspaceFromContext(context.Background())
+	assert.Nil(t, workspace)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "notion: Context missing Notion Workspace", err.Error())
+	}
+}
+
+func TestContextBotUser(t *testing.T) {
+	expectedBot := &BotUser{ID: "bot-id", Name: "Gologin"}
+	ctx := WithBotUser(context.Background(), expectedBot)
+	bot, err := BotUserFromContext(ctx)
+	assert.Equal(t, expectedBot, bot)
+	assert.Nil(t, err)
+}
+
+func TestContextBotUser_Error(t *testing.T) {
+	bot, err := BotUserFromContext(context.Background())
+	assert.Nil(t, bot)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "notion: Context missing Notion BotUser", err.Error())
+	}
+}
+
+func TestWorkspace_Identity(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), &Workspace{ID: "workspace-id", BotID: "bot-id", Owner: &Owner{ID: "user-id"}})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "notion", ID: "workspace-id/user-id"}, identity)
+
+	// workspace-owned integrations are identified by bot
+	ctx = WithWorkspace(context.Background(), &Workspace{ID: "workspace-id", BotID: "bot-id"})
+	identity, err = gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "notion", ID: "bot-id"}, identity)
+}
@@ -0,0 +1,6 @@
+// Package notion provides Notion OAuth2 login and callback handlers.
+//
+// Notion access tokens belong to an integration bot installed in a workspace.
+// The workspace and the user who installed the bot (its owner) are read from
+// the token response rather than fetched from a profile endpoint.
+package notion
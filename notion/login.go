@@ -0,0 +1,124 @@
+package notion
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Notion login errors
+var (
+	ErrUnableToGetNotionUser = errors.New("notion: unable to get Notion User")
+)
+
+// Provider is the Notion OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{
+	Name:            "notion",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInHeader,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Notion login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Notion redirection URI requests and adds the
+// Notion access token and Workspace, read from the token response, to the
+// ctx. If authentication succeeds, handling delegates to the success handler,
+// otherwise to the failure handler.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = notionHandler(success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// notionHandler is a ContextHandler that gets the OAuth2 Token from the ctx
+// and reads the Workspace from its response fields. If valid, the Workspace
+// is added to the ctx and the success handler is called. Otherwise, the
+// failure handler is called.
+func notionHandler(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		workspace := workspaceFromToken(token)
+		if workspace.ID == "" || workspace.BotID == "" {
+			ctx = gologin.WithError(ctx, ErrUnableToGetNotionUser)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithWorkspace(ctx, workspace)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// UserHandler is a ContextHandler that gets the OAuth2 Token from the ctx to
+// fetch the bot user from the Notion users/me endpoint. If successful, the
+// BotUser is added to the ctx and the success handler is called. Otherwise,
+// the failure handler is called.
+//
+// Optionally chain it after a CallbackHandler.
+func UserHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		notionClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		notionClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		bot, resp, err := notionClient.Me()
+		err = validateResponse(bot, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithBotUser(ctx, bot)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Notion BotUser, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(bot *BotUser, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetNotionUser
+	}
+	if bot == nil || bot.ID == "" {
+		return ErrUnableToGetNotionUser
+	}
+	return nil
+}
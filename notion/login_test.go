@@ -0,0 +1,149 @@
+package notion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	expectedWorkspace := &Workspace{
+		ID:    "j565j4d7x3-2882-61bs-564a-jj9d9ui-c36hxfr7x",
+		Name:  "Ada's Notion",
+		Icon:  "https://example.com/icon.png",
+		BotID: "b3414d65-1224-5ty7-6ffr-cc9d8773drt6",
+		Owner: &Owner{
+			ID:        "e79a0b74-3aba-4149-9f74-0bb5791a6ee6",
+			Name:      "Ada Lovelace",
+			AvatarURL: "https://example.com/ada.png",
+			Email:     "ada@example.com",
+		},
+	}
+	proxyClient, server := newNotionTestServer("")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		workspace, err := WorkspaceFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedWorkspace, workspace)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler gets a token with workspace and owner fields, assert that:
+	// - the Workspace and Owner are added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestNotionHandler_MissingWorkspace(t *testing.T) {
+	ctx := oauth2Login.WithToken(context.Background(), &oauth2.Token{AccessToken: "secret_notion"})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetNotionUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// NotionHandler with a token without workspace fields, assert that:
+	// - failure handler is called
+	notionHandler := notionHandler(testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	notionHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestNotionHandler_MissingCtxToken(t *testing.T) {
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// NotionHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	notionHandler := notionHandler(success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	notionHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestUserHandler(t *testing.T) {
+	jsonData := `{"object": "user", "id": "bot-user-id", "name": "Gologin", "avatar_url": null, "type": "bot", "bot": {"workspace_name": "Ada's Notion"}}`
+	expectedBot := &BotUser{ID: "bot-user-id", Name: "Gologin", Type: "bot", WorkspaceName: "Ada's Notion"}
+	proxyClient, server := newNotionTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "secret_notion"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		bot, err := BotUserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedBot, bot)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// UserHandler fetches users/me, which requires Notion-Version, assert that:
+	// - the BotUser is added to the ctx of the success handler
+	handler := UserHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestUserHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Notion Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetNotionUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// UserHandler cannot get the Notion bot user, assert that:
+	// - failure handler is called
+	handler := UserHandler(&oauth2.Config{}, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validBot := &BotUser{ID: "bot-user-id"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validBot, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetNotionUser, validateResponse(validBot, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetNotionUser, validateResponse(validBot, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetNotionUser, validateResponse(&BotUser{}, validResponse, nil))
+}
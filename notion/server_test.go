@@ -0,0 +1,55 @@
+package notion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+const testTokenResponse = `{
+	"access_token": "secret_notion",
+	"token_type": "bearer",
+	"bot_id": "b3414d65-1224-5ty7-6ffr-cc9d8773drt6",
+	"workspace_id": "j565j4d7x3-2882-61bs-564a-jj9d9ui-c36hxfr7x",
+	"workspace_name": "Ada's Notion",
+	"workspace_icon": "https://example.com/icon.png",
+	"owner": {
+		"type": "user",
+		"user": {
+			"object": "user",
+			"id": "e79a0b74-3aba-4149-9f74-0bb5791a6ee6",
+			"name": "Ada Lovelace",
+			"avatar_url": "https://example.com/ada.png",
+			"type": "person",
+			"person": {"email": "ada@example.com"}
+		}
+	}
+}`
+
+// newNotionTestServer returns a new httptest.Server which mocks the Notion
+// token endpoint and the users/me endpoint, which responds with the given
+// json data only if the Notion-Version header is sent. It also returns a
+// client which proxies requests to the server. The caller must close the
+// server.
+func newNotionTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, testTokenResponse)
+	})
+	mux.HandleFunc("/v1/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Notion-Version") == "" {
+			http.Error(w, `{"object": "error", "status": 400, "code": "missing_version"}`, http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret_notion" {
+			http.Error(w, `{"object": "error", "status": 401, "code": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,164 @@
+package notion
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const notionAPI = "https://api.notion.com/v1/"
+
+// Version is the Notion-Version header value sent with API requests, which
+// Notion requires.
+const Version = "2022-06-28"
+
+// Endpoint is the Notion OAuth2 endpoint. Notion requires the owner=user
+// authorization parameter and client credentials with HTTP Basic auth.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://api.notion.com/v1/oauth/authorize?owner=user",
+	TokenURL:  "https://api.notion.com/v1/oauth/token",
+	AuthStyle: oauth2.AuthStyleInHeader,
+}
+
+func init() {
+	gologin.RegisterEndpoint("notion", Endpoint)
+}
+
+// Workspace is the Notion workspace an integration was installed in.
+type Workspace struct {
+	ID    string
+	Name  string
+	Icon  string
+	BotID string
+	// Owner is the user who installed the integration, if any
+	Owner *Owner
+}
+
+// Owner is the Notion user who owns an integration's bot.
+type Owner struct {
+	ID        string
+	Name      string
+	AvatarURL string
+	Email     string
+}
+
+// Identity returns the Notion identity keyed by the workspace and owner IDs,
+// or by the bot ID for workspace-owned integrations.
+func (w *Workspace) Identity() gologin.Identity {
+	identity := gologin.Identity{Provider: Provider.Name}
+	if w.Owner != nil && w.Owner.ID != "" {
+		identity.ID = w.ID + "/" + w.Owner.ID
+	} else {
+		identity.ID = w.BotID
+	}
+	return identity
+}
+
+// EmailAddress returns the email address of the user who owns the
+// integration, if any.
+func (w *Workspace) EmailAddress() (string, bool) {
+	if w.Owner == nil || w.Owner.Email == "" {
+		return "", false
+	}
+	return w.Owner.Email, true
+}
+
+// PictureURL returns the avatar URL of the user who owns the integration,
+// if any.
+func (w *Workspace) PictureURL() string {
+	if w.Owner == nil {
+		return ""
+	}
+	return w.Owner.AvatarURL
+}
+
+// BotUser is the Notion bot user an access token acts as.
+type BotUser struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	AvatarURL     string `json:"avatar_url"`
+	Type          string `json:"type"`
+	WorkspaceName string `json:"-"`
+}
+
+// user is a Notion API user object.
+type user struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+	Type      string `json:"type"`
+	Person    struct {
+		Email string `json:"email"`
+	} `json:"person"`
+	Bot struct {
+		WorkspaceName string `json:"workspace_name"`
+	} `json:"bot"`
+}
+
+// owner is a Notion token response owner object.
+type owner struct {
+	Type string `json:"type"`
+	User user   `json:"user"`
+}
+
+// workspaceFromToken reads the Workspace from the Token response fields.
+func workspaceFromToken(token *oauth2.Token) *Workspace {
+	workspace := &Workspace{}
+	workspace.ID, _ = token.Extra("workspace_id").(string)
+	workspace.Name, _ = token.Extra("workspace_name").(string)
+	workspace.Icon, _ = token.Extra("workspace_icon").(string)
+	workspace.BotID, _ = token.Extra("bot_id").(string)
+	// owner is a JSON object, re-encode it to decode into an owner
+	data, err := json.Marshal(token.Extra("owner"))
+	if err != nil {
+		return workspace
+	}
+	tokenOwner := new(owner)
+	if json.Unmarshal(data, tokenOwner) == nil && tokenOwner.Type == "user" && tokenOwner.User.ID != "" {
+		workspace.Owner = &Owner{
+			ID:        tokenOwner.User.ID,
+			Name:      tokenOwner.User.Name,
+			AvatarURL: tokenOwner.User.AvatarURL,
+			Email:     tokenOwner.User.Person.Email,
+		}
+	}
+	return workspace
+}
+
+// client is a Notion client for obtaining a BotUser.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(notionAPI).
+		Set("Notion-Version", Version).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the bot user for the access token.
+// https://developers.notion.com/reference/get-self
+func (c *client) Me() (*BotUser, *http.Response, error) {
+	me := new(user)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(me)
+	bot := &BotUser{
+		ID:            me.ID,
+		Name:          me.Name,
+		AvatarURL:     me.AvatarURL,
+		Type:          me.Type,
+		WorkspaceName: me.Bot.WorkspaceName,
+	}
+	return bot, resp, err
+}
@@ -0,0 +1,40 @@
+package oauth1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRequestTokenFailed prefixes the message of the RequestTokenError added
+// to the ctx when the provider's request token endpoint fails.
+var ErrRequestTokenFailed = errors.New("oauth1: unable to obtain request token")
+
+// ErrRequestTokenMismatch is returned when a callback's oauth_token does not
+// match the request token cookie issued at login.
+var ErrRequestTokenMismatch = errors.New("oauth1: callback oauth_token does not match the request token")
+
+// RequestTokenError is returned when a request token (temporary credentials)
+// cannot be obtained from the provider, before the user is redirected to
+// authorize. Check for it with a type assertion.
+type RequestTokenError struct {
+	// StatusCode is the provider's HTTP status code, or 0 if the provider
+	// was unreachable or its status was not reported
+	StatusCode int
+	// Err is the underlying oauth1 error
+	Err error
+}
+
+func (e RequestTokenError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%v (provider status %d): %v", ErrRequestTokenFailed, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%v: %v", ErrRequestTokenFailed, e.Err)
+}
+
+// newRequestTokenError wraps an oauth1 RequestToken error, with the provider
+// status code the oauth1 package reports in "invalid status" errors.
+func newRequestTokenError(err error) RequestTokenError {
+	var status int
+	fmt.Sscanf(err.Error(), "oauth1: invalid status %d:", &status)
+	return RequestTokenError{StatusCode: status, Err: err}
+}
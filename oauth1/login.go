@@ -1,6 +1,7 @@
 package oauth1
 
 import (
+	"crypto/subtle"
 	"net/http"
 
 	"goji.io"
@@ -10,27 +11,60 @@ import (
 	"golang.org/x/net/context"
 )
 
+// LoginOptions configures LoginHandlerWithOptions.
+type LoginOptions struct {
+	// RequestTokenCookie, if set, issues a cookie holding the request token,
+	// so the callback can verify its oauth_token was issued to the same
+	// requester (see CallbackOptions). Its Name must differ from other
+	// cookies, such as the CookieTempHandler's.
+	RequestTokenCookie *gologin.CookieConfig
+}
+
 // LoginHandler handles OAuth1 login requests by obtaining a request token and
 // secret (temporary credentials) and adding it to the ctx. If successful,
-// handling delegates to the success handler, otherwise to the failure handler.
+// handling delegates to the success handler, otherwise the failure handler is
+// called with a RequestTokenError, which includes the provider's status code.
 //
 // Typically, the success handler is an AuthRedirectHandler or a handler which
 // stores the request token secret.
 func LoginHandler(config *oauth1.Config, success, failure goji.Handler) goji.Handler {
+	return LoginHandlerWithOptions(config, LoginOptions{}, success, failure)
+}
+
+// LoginHandlerWithOptions is a LoginHandler configured by LoginOptions.
+//
+// LoginHandlerWithOptions panics if the RequestTokenCookie CookieConfig is
+// invalid.
+func LoginHandlerWithOptions(config *oauth1.Config, options LoginOptions, success, failure goji.Handler) goji.Handler {
+	if options.RequestTokenCookie != nil {
+		if err := options.RequestTokenCookie.Validate(); err != nil {
+			panic(err)
+		}
+	}
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
 		requestToken, requestSecret, err := config.RequestToken()
 		if err != nil {
-			ctx = gologin.WithError(ctx, err)
+			ctx = gologin.WithError(ctx, newRequestTokenError(err))
 			failure.ServeHTTP(ctx, w, req)
 			return
 		}
+		if options.RequestTokenCookie != nil {
+			// add request token to a short-lived cookie
+			value, err := internal.EncodeCookieValue(*options.RequestTokenCookie, requestToken)
+			if err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTP(ctx, w, req)
+				return
+			}
+			http.SetCookie(w, internal.NewRequestCookie(*options.RequestTokenCookie, req, value))
+		}
 		ctx = WithRequestToken(ctx, requestToken, requestSecret)
 		success.ServeHTTP(ctx, w, req)
 	}
-	return goji.HandlerFunc(fn)
+	return gologin.MethodHandler([]string{"GET"}, goji.HandlerFunc(fn), failure)
 }
 
 // AuthRedirectHandler reads the request token from the ctx and redirects
@@ -68,7 +102,12 @@ func AuthRedirectHandler(config *oauth1.Config, failure goji.Handler) goji.Handl
 // Some OAuth1 providers (Twitter, Digits) do NOT require temp secrets to be
 // kept between the login phase and callback phase. To implement those
 // providers, use the EmptyTempHandler instead.
+//
+// CookieTempHandler panics if the CookieConfig is invalid.
 func CookieTempHandler(config gologin.CookieConfig, success, failure goji.Handler) goji.Handler {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
@@ -76,18 +115,30 @@ func CookieTempHandler(config gologin.CookieConfig, success, failure goji.Handle
 		_, requestSecret, err := RequestTokenFromContext(ctx)
 		if err == nil {
 			// add request secret  to a short-lived cookie
-			http.SetCookie(w, internal.NewCookie(config, requestSecret))
+			value, err := internal.EncodeCookieValue(config, requestSecret)
+			if err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTP(ctx, w, req)
+				return
+			}
+			http.SetCookie(w, internal.NewRequestCookie(config, req, value))
 			success.ServeHTTP(ctx, w, req)
 			return
 		}
 		// read request secret from the short-lived cookie to add to ctx
-		cookie, err := req.Cookie(config.Name)
+		cookie, err := req.Cookie(internal.CookieName(config))
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		requestSecret, err = internal.DecodeCookieValue(config, cookie.Value)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTP(ctx, w, req)
 			return
 		}
-		ctx = WithRequestToken(ctx, "", cookie.Value)
+		ctx = WithRequestToken(ctx, "", requestSecret)
 		success.ServeHTTP(ctx, w, req)
 	}
 	return goji.HandlerFunc(fn)
@@ -107,10 +158,33 @@ func EmptyTempHandler(success goji.Handler) goji.Handler {
 	return goji.HandlerFunc(fn)
 }
 
+// CallbackOptions configures CallbackHandlerWithOptions.
+type CallbackOptions struct {
+	// RequestTokenCookie, if set, requires the callback oauth_token to match
+	// the request token cookie issued at login (see LoginOptions), as CSRF
+	// protection. Callbacks without a matching cookie fail with
+	// ErrRequestTokenMismatch.
+	RequestTokenCookie *gologin.CookieConfig
+}
+
 // CallbackHandler handles OAuth1 callback requests by parsing the oauth token
 // and verifier, reading the request token secret from the ctx, then obtaining
 // an access token and adding it to the ctx.
 func CallbackHandler(config *oauth1.Config, success, failure goji.Handler) goji.Handler {
+	return CallbackHandlerWithOptions(config, CallbackOptions{}, success, failure)
+}
+
+// CallbackHandlerWithOptions is a CallbackHandler configured by
+// CallbackOptions.
+//
+// CallbackHandlerWithOptions panics if the RequestTokenCookie CookieConfig is
+// invalid.
+func CallbackHandlerWithOptions(config *oauth1.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
+	if options.RequestTokenCookie != nil {
+		if err := options.RequestTokenCookie.Validate(); err != nil {
+			panic(err)
+		}
+	}
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
@@ -121,6 +195,11 @@ func CallbackHandler(config *oauth1.Config, success, failure goji.Handler) goji.
 			failure.ServeHTTP(ctx, w, req)
 			return
 		}
+		if options.RequestTokenCookie != nil && !verifyRequestToken(*options.RequestTokenCookie, req, requestToken) {
+			ctx = gologin.WithError(ctx, ErrRequestTokenMismatch)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
 
 		// upstream handler should add the request token secret from the login step
 		_, requestSecret, err := RequestTokenFromContext(ctx)
@@ -137,7 +216,23 @@ func CallbackHandler(config *oauth1.Config, success, failure goji.Handler) goji.
 			return
 		}
 		ctx = WithAccessToken(ctx, accessToken, accessSecret)
+		// provider requests set the gologin User-Agent
+		ctx = internal.WithUserAgentClient(ctx, oauth1.HTTPClient)
 		success.ServeHTTP(ctx, w, req)
 	}
-	return goji.HandlerFunc(fn)
+	return gologin.MethodHandler([]string{"GET"}, goji.HandlerFunc(fn), failure)
+}
+
+// verifyRequestToken returns true if the request has a request token cookie
+// whose value matches the callback request token.
+func verifyRequestToken(config gologin.CookieConfig, req *http.Request, requestToken string) bool {
+	cookie, err := req.Cookie(internal.CookieName(config))
+	if err != nil {
+		return false
+	}
+	value, err := internal.DecodeCookieValue(config, cookie.Value)
+	if err != nil || value == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(value), []byte(requestToken)) == 1
 }
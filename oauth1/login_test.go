@@ -65,16 +65,45 @@ func TestLoginHandler_RequestTokenError(t *testing.T) {
 	success := testutils.AssertSuccessNotCalled(t)
 	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
 		err := gologin.ErrorFromContext(ctx)
-		if assert.NotNil(t, err) {
-			// first validation in OAuth1 impl failed
-			assert.Equal(t, "oauth1: oauth_callback_confirmed was not true", err.Error())
+		if requestTokenErr, ok := err.(RequestTokenError); assert.True(t, ok) {
+			assert.Equal(t, http.StatusInternalServerError, requestTokenErr.StatusCode)
+			assert.Contains(t, err.Error(), ErrRequestTokenFailed.Error())
+			assert.Contains(t, err.Error(), "OAuth1 Service Down")
 		}
 		fmt.Fprintf(w, "failure handler called")
 	}
 
 	// LoginHandler cannot get the OAuth1 request token, assert that:
 	// - failure handler is called
-	// - error is added to the ctx of the failure handler
+	// - a RequestTokenError with the provider status is added to the ctx of
+	//   the failure handler
+	loginHandler := LoginHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestLoginHandler_RequestTokenUnreachable(t *testing.T) {
+	server := testutils.NewTestServerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	// closed server refuses connections
+	server.Close()
+	config := &oauth1.Config{
+		Endpoint: oauth1.Endpoint{
+			RequestTokenURL: server.URL,
+		},
+	}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if requestTokenErr, ok := err.(RequestTokenError); assert.True(t, ok) {
+			assert.Equal(t, 0, requestTokenErr.StatusCode)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LoginHandler cannot reach the request token endpoint, assert that:
+	// - failure handler is called with a RequestTokenError without a status
 	loginHandler := LoginHandler(config, success, goji.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -82,6 +111,15 @@ func TestLoginHandler_RequestTokenError(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestRequestTokenError(t *testing.T) {
+	err := newRequestTokenError(fmt.Errorf("oauth1: invalid status 503: Over capacity"))
+	assert.Equal(t, 503, err.StatusCode)
+	assert.Equal(t, "oauth1: unable to obtain request token (provider status 503): oauth1: invalid status 503: Over capacity", err.Error())
+	err = newRequestTokenError(fmt.Errorf("oauth1: oauth_callback_confirmed was not true"))
+	assert.Equal(t, 0, err.StatusCode)
+	assert.Equal(t, "oauth1: unable to obtain request token: oauth1: oauth_callback_confirmed was not true", err.Error())
+}
+
 // AuthRedirectHandler
 
 func TestAuthRedirectHandler(t *testing.T) {
@@ -190,6 +228,96 @@ func TestCallbackHandler(t *testing.T) {
 	assert.Equal(t, "success handler called", w.Body.String())
 }
 
+func TestRequestTokenCookie(t *testing.T) {
+	data := url.Values{}
+	data.Add("oauth_token", "request_token")
+	data.Add("oauth_token_secret", "request_secret")
+	data.Add("oauth_callback_confirmed", "true")
+	requestTokenServer := NewRequestTokenServer(t, data)
+	defer requestTokenServer.Close()
+	accessData := url.Values{}
+	accessData.Add("oauth_token", "access_token")
+	accessData.Add("oauth_token_secret", "access_secret")
+	accessTokenServer := NewAccessTokenServer(t, accessData)
+	defer accessTokenServer.Close()
+
+	config := &oauth1.Config{
+		Endpoint: oauth1.Endpoint{
+			RequestTokenURL: requestTokenServer.URL,
+			AccessTokenURL:  accessTokenServer.URL,
+		},
+	}
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Name = "oauth1-request-token"
+	loginSuccess := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {}
+
+	// LoginHandlerWithOptions gets an OAuth1 request token, assert that:
+	// - the request token is issued in a cookie
+	loginHandler := LoginHandlerWithOptions(config, LoginOptions{RequestTokenCookie: &cookieConfig}, goji.HandlerFunc(loginSuccess), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	loginHandler.ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, "oauth1-request-token", cookies[0].Name)
+	assert.Equal(t, "request_token", cookies[0].Value)
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		accessToken, _, err := AccessTokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "access_token", accessToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CallbackHandlerWithOptions with an oauth_token matching the cookie,
+	// assert that:
+	// - the access token is obtained and the success handler is called
+	callbackHandler := CallbackHandlerWithOptions(config, CallbackOptions{RequestTokenCookie: &cookieConfig}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/callback?oauth_token=request_token&oauth_verifier=any_verifier", nil)
+	req.AddCookie(cookies[0])
+	ctx := WithRequestToken(context.Background(), "", "request_secret")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandlerWithOptions_RequestTokenMismatch(t *testing.T) {
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Name = "oauth1-request-token"
+	cases := []*http.Cookie{
+		// cookie for another request token
+		{Name: "oauth1-request-token", Value: "other_token"},
+		// no cookie
+		nil,
+		// empty cookie
+		{Name: "oauth1-request-token", Value: ""},
+	}
+	for _, cookie := range cases {
+		config := &oauth1.Config{}
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, ErrRequestTokenMismatch, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// CallbackHandlerWithOptions with an oauth_token which does not match
+		// the request token cookie, assert that:
+		// - failure handler is called with ErrRequestTokenMismatch
+		// - no access token is requested
+		callbackHandler := CallbackHandlerWithOptions(config, CallbackOptions{RequestTokenCookie: &cookieConfig}, success, goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/callback?oauth_token=request_token&oauth_verifier=any_verifier", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		ctx := WithRequestToken(context.Background(), "", "request_secret")
+		callbackHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
 func TestCallbackHandler_ParseAuthorizationCallbackError(t *testing.T) {
 	config := &oauth1.Config{}
 	success := testutils.AssertSuccessNotCalled(t)
@@ -261,3 +389,17 @@ func TestCallbackHandler_AccessTokenError(t *testing.T) {
 	callbackHandler.ServeHTTP(ctx, w, req)
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
+
+func TestHandlers_MethodNotAllowed(t *testing.T) {
+	config := &oauth1.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	for _, handler := range []goji.Handler{LoginHandler(config, success, nil), CallbackHandler(config, success, nil)} {
+		// handlers with the default failure handler, assert that:
+		// - non-GET requests get a 405 with Allow: GET
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/", nil)
+		handler.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET", w.Header().Get("Allow"))
+	}
+}
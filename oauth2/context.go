@@ -2,6 +2,7 @@ package oauth2
 
 import (
 	"fmt"
+	"time"
 
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
@@ -13,6 +14,12 @@ type key int
 const (
 	tokenKey key = iota
 	stateKey
+	flowIDKey
+	loginKey
+	idTokenKey
+	pkceVerifierKey
+	loginMetadataKey
+	minimalUserKey
 )
 
 // WithState returns a copy of ctx that stores the state value.
@@ -29,6 +36,20 @@ func StateFromContext(ctx context.Context) (string, error) {
 	return state, nil
 }
 
+// WithFlowID returns a copy of ctx that stores the login flow ID.
+func WithFlowID(ctx context.Context, flowID string) context.Context {
+	return context.WithValue(ctx, flowIDKey, flowID)
+}
+
+// FlowIDFromContext returns the login flow ID from the ctx.
+func FlowIDFromContext(ctx context.Context) (string, error) {
+	flowID, ok := ctx.Value(flowIDKey).(string)
+	if !ok {
+		return "", fmt.Errorf("oauth2: Context missing flow ID")
+	}
+	return flowID, nil
+}
+
 // WithToken returns a copy of ctx that stores the Token.
 func WithToken(ctx context.Context, token *oauth2.Token) context.Context {
 	return context.WithValue(ctx, tokenKey, token)
@@ -42,3 +63,90 @@ func TokenFromContext(ctx context.Context) (*oauth2.Token, error) {
 	}
 	return token, nil
 }
+
+// TokenExpiryFromContext returns the expiry of the Token in the ctx. The
+// boolean is false if the ctx has no Token or the Token does not expire.
+// Session code may use the expiry to decide when to refresh the Token.
+func TokenExpiryFromContext(ctx context.Context) (time.Time, bool) {
+	token, err := TokenFromContext(ctx)
+	if err != nil || token.Expiry.IsZero() {
+		return time.Time{}, false
+	}
+	return token.Expiry, true
+}
+
+// WithIDToken returns a copy of ctx that stores the raw OpenID Connect ID
+// token.
+func WithIDToken(ctx context.Context, idToken string) context.Context {
+	return context.WithValue(ctx, idTokenKey, idToken)
+}
+
+// IDTokenFromContext returns the raw OpenID Connect ID token from the ctx.
+// The ID token is not verified.
+func IDTokenFromContext(ctx context.Context) (string, error) {
+	idToken, ok := ctx.Value(idTokenKey).(string)
+	if !ok {
+		return "", fmt.Errorf("oauth2: Context missing ID token")
+	}
+	return idToken, nil
+}
+
+// WithPKCEVerifier returns a copy of ctx that stores the PKCE code verifier.
+func WithPKCEVerifier(ctx context.Context, verifier string) context.Context {
+	return context.WithValue(ctx, pkceVerifierKey, verifier)
+}
+
+// PKCEVerifierFromContext returns the PKCE code verifier from the ctx.
+func PKCEVerifierFromContext(ctx context.Context) (string, error) {
+	verifier, ok := ctx.Value(pkceVerifierKey).(string)
+	if !ok {
+		return "", fmt.Errorf("oauth2: Context missing PKCE verifier")
+	}
+	return verifier, nil
+}
+
+// WithLoginMetadata returns a copy of ctx that stores app metadata (e.g. an
+// invite token) to carry through a login. StateHandler embeds it in the
+// signed state cookie at login and adds it back to the ctx at callback.
+func WithLoginMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	return context.WithValue(ctx, loginMetadataKey, metadata)
+}
+
+// LoginMetadataFromContext returns the login metadata from the ctx.
+func LoginMetadataFromContext(ctx context.Context) (map[string]string, error) {
+	metadata, ok := ctx.Value(loginMetadataKey).(map[string]string)
+	if !ok {
+		return nil, fmt.Errorf("oauth2: Context missing login metadata")
+	}
+	return metadata, nil
+}
+
+// WithMinimalUser returns a copy of ctx that stores the MinimalUser. At
+// login, StateHandler embeds it in the signed state cookie and, at callback,
+// adds it back to the ctx.
+func WithMinimalUser(ctx context.Context, user *MinimalUser) context.Context {
+	return context.WithValue(ctx, minimalUserKey, user)
+}
+
+// MinimalUserFromContext returns the MinimalUser from the ctx.
+func MinimalUserFromContext(ctx context.Context) (*MinimalUser, error) {
+	user, ok := ctx.Value(minimalUserKey).(*MinimalUser)
+	if !ok {
+		return nil, fmt.Errorf("oauth2: Context missing MinimalUser")
+	}
+	return user, nil
+}
+
+// WithLogin returns a copy of ctx that stores the Login.
+func WithLogin(ctx context.Context, login *Login) context.Context {
+	return context.WithValue(ctx, loginKey, login)
+}
+
+// LoginFromContext returns the Login from the ctx.
+func LoginFromContext(ctx context.Context) (*Login, error) {
+	login, ok := ctx.Value(loginKey).(*Login)
+	if !ok {
+		return nil, fmt.Errorf("oauth2: Context missing Login")
+	}
+	return login, nil
+}
@@ -2,6 +2,7 @@ package oauth2
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
@@ -24,6 +25,38 @@ func TestContext_MissingState(t *testing.T) {
 	}
 }
 
+func TestContext_FlowID(t *testing.T) {
+	expectedFlowID := "a1b2c3d4"
+	ctx := WithFlowID(context.Background(), expectedFlowID)
+	flowID, err := FlowIDFromContext(ctx)
+	assert.Equal(t, expectedFlowID, flowID)
+	assert.Nil(t, err)
+}
+
+func TestContext_IDToken(t *testing.T) {
+	expectedIDToken := "eyJhbGciOiJSUzI1NiJ9.e30.c2lnbmF0dXJl"
+	ctx := WithIDToken(context.Background(), expectedIDToken)
+	idToken, err := IDTokenFromContext(ctx)
+	assert.Equal(t, expectedIDToken, idToken)
+	assert.Nil(t, err)
+}
+
+func TestContext_MissingIDToken(t *testing.T) {
+	idToken, err := IDTokenFromContext(context.Background())
+	assert.Equal(t, "", idToken)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing ID token", err.Error())
+	}
+}
+
+func TestContext_MissingFlowID(t *testing.T) {
+	flowID, err := FlowIDFromContext(context.Background())
+	assert.Equal(t, "", flowID)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing flow ID", err.Error())
+	}
+}
+
 func TestContext_Token(t *testing.T) {
 	expectedToken := &oauth2.Token{AccessToken: "access_token"}
 	ctx := WithToken(context.Background(), expectedToken)
@@ -39,3 +72,82 @@ func TestTokenFromContext_Error(t *testing.T) {
 		assert.Equal(t, "oauth2: Context missing Token", err.Error())
 	}
 }
+
+func TestTokenExpiryFromContext(t *testing.T) {
+	expiry := time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+	ctx := WithToken(context.Background(), &oauth2.Token{AccessToken: "access_token", Expiry: expiry})
+	tokenExpiry, ok := TokenExpiryFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, expiry, tokenExpiry)
+}
+
+func TestTokenExpiryFromContext_NoExpiry(t *testing.T) {
+	ctx := WithToken(context.Background(), &oauth2.Token{AccessToken: "access_token"})
+	_, ok := TokenExpiryFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = TokenExpiryFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestContext_PKCEVerifier(t *testing.T) {
+	ctx := WithPKCEVerifier(context.Background(), "verifier")
+	verifier, err := PKCEVerifierFromContext(ctx)
+	assert.Equal(t, "verifier", verifier)
+	assert.Nil(t, err)
+}
+
+func TestContext_MissingPKCEVerifier(t *testing.T) {
+	verifier, err := PKCEVerifierFromContext(context.Background())
+	assert.Equal(t, "", verifier)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing PKCE verifier", err.Error())
+	}
+}
+
+func TestContext_LoginMetadata(t *testing.T) {
+	expectedMetadata := map[string]string{"invite": "inv_12345"}
+	ctx := WithLoginMetadata(context.Background(), expectedMetadata)
+	metadata, err := LoginMetadataFromContext(ctx)
+	assert.Equal(t, expectedMetadata, metadata)
+	assert.Nil(t, err)
+}
+
+func TestContext_MissingLoginMetadata(t *testing.T) {
+	metadata, err := LoginMetadataFromContext(context.Background())
+	assert.Nil(t, metadata)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing login metadata", err.Error())
+	}
+}
+
+func TestContext_MinimalUser(t *testing.T) {
+	expectedUser := &MinimalUser{Subject: "248289761001", Email: "jane@example.com"}
+	ctx := WithMinimalUser(context.Background(), expectedUser)
+	user, err := MinimalUserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContext_MissingMinimalUser(t *testing.T) {
+	user, err := MinimalUserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing MinimalUser", err.Error())
+	}
+}
+
+func TestContext_Login(t *testing.T) {
+	expectedLogin := &Login{Provider: "example", Token: &oauth2.Token{AccessToken: "access_token"}}
+	ctx := WithLogin(context.Background(), expectedLogin)
+	login, err := LoginFromContext(ctx)
+	assert.Equal(t, expectedLogin, login)
+	assert.Nil(t, err)
+}
+
+func TestLoginFromContext_Error(t *testing.T) {
+	login, err := LoginFromContext(context.Background())
+	assert.Nil(t, login)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing Login", err.Error())
+	}
+}
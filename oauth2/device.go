@@ -0,0 +1,249 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/oauth2"
+)
+
+// Device authorization grant errors
+var (
+	ErrDeviceAccessDenied = errors.New("oauth2: device authorization denied")
+	ErrDeviceCodeExpired  = errors.New("oauth2: device code expired")
+	ErrDeviceAuthorize    = errors.New("oauth2: unable to get device code")
+	ErrDeviceToken        = errors.New("oauth2: unable to get device token")
+)
+
+const (
+	deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// defaultDeviceInterval is the polling interval when none is given
+	defaultDeviceInterval = 5
+	// maxDeviceInterval bounds the polling interval given by the DeviceAuth
+	maxDeviceInterval = 60
+	// defaultDeviceMaxWait is the longest Poll waits for the user by default,
+	// the typical device code expires_in (RFC 8628 3.2)
+	defaultDeviceMaxWait = 30 * time.Minute
+)
+
+// DeviceAuth is a device authorization response. Apps display the
+// VerificationURI and UserCode to the user, then poll for the Token.
+type DeviceAuth struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// DeviceFlow implements the OAuth 2.0 Device Authorization Grant (RFC 8628)
+// used by CLI and TV apps.
+type DeviceFlow struct {
+	// Config provides the client ID, scopes, and token endpoint
+	Config *oauth2.Config
+	// DeviceAuthURL is the provider's device authorization endpoint
+	DeviceAuthURL string
+	// MaxWait bounds how long Poll waits for the user, even when the
+	// DeviceAuth has no ExpiresIn (default 30 minutes)
+	MaxWait time.Duration
+}
+
+// deviceSleep waits for d or until the ctx is done. Tests may replace it.
+var deviceSleep = func(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start requests a device code and user code from the device authorization
+// endpoint.
+func (f *DeviceFlow) Start(ctx context.Context) (*DeviceAuth, error) {
+	form := url.Values{"client_id": {f.Config.ClientID}}
+	if len(f.Config.Scopes) > 0 {
+		form.Set("scope", strings.Join(f.Config.Scopes, " "))
+	}
+	resp, err := ctxhttp.PostForm(ctx, httpClient(ctx), f.DeviceAuthURL, form)
+	if err != nil {
+		return nil, ErrDeviceAuthorize
+	}
+	defer resp.Body.Close()
+	auth := new(DeviceAuth)
+	if resp.StatusCode != http.StatusOK || internal.DecodeJSON(resp.Body, auth) != nil || auth.DeviceCode == "" {
+		return nil, ErrDeviceAuthorize
+	}
+	return auth, nil
+}
+
+// Poll polls the token endpoint until the user approves or denies the
+// device, the device code expires, MaxWait elapses, or the ctx is done. It
+// waits the DeviceAuth Interval (at most 60 seconds) between requests, slowing
+// down when asked to.
+func (f *DeviceFlow) Poll(ctx context.Context, auth *DeviceAuth) (*oauth2.Token, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if auth.Interval <= 0 {
+		interval = defaultDeviceInterval * time.Second
+	} else if auth.Interval > maxDeviceInterval {
+		interval = maxDeviceInterval * time.Second
+	}
+	maxWait := f.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultDeviceMaxWait
+	}
+	if expiresIn := time.Duration(auth.ExpiresIn) * time.Second; expiresIn > 0 && expiresIn < maxWait {
+		maxWait = expiresIn
+	}
+	deadline := internal.DefaultClock.Now().Add(maxWait)
+	for {
+		if err := deviceSleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		if internal.DefaultClock.Now().After(deadline) {
+			return nil, ErrDeviceCodeExpired
+		}
+		token, errCode, err := f.requestToken(ctx, auth.DeviceCode)
+		if err != nil {
+			return nil, err
+		}
+		switch errCode {
+		case "":
+			return token, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return nil, ErrDeviceAccessDenied
+		case "expired_token":
+			return nil, ErrDeviceCodeExpired
+		default:
+			return nil, ErrDeviceToken
+		}
+	}
+}
+
+// deviceTokenResponse is a token endpoint response to a device code grant.
+type deviceTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// requestToken requests a Token for the device code. If the user has not
+// completed authorization, the token endpoint's error code is returned.
+func (f *DeviceFlow) requestToken(ctx context.Context, deviceCode string) (*oauth2.Token, string, error) {
+	form := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {deviceCode},
+		"client_id":   {f.Config.ClientID},
+	}
+	if f.Config.ClientSecret != "" {
+		form.Set("client_secret", f.Config.ClientSecret)
+	}
+	req, err := http.NewRequest("POST", f.Config.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", ErrDeviceToken
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := ctxhttp.Do(ctx, httpClient(ctx), req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		return nil, "", ErrDeviceToken
+	}
+	defer resp.Body.Close()
+	tokenResp := new(deviceTokenResponse)
+	if err := json.NewDecoder(resp.Body).Decode(tokenResp); err != nil {
+		return nil, "", ErrDeviceToken
+	}
+	if tokenResp.Error != "" {
+		return nil, tokenResp.Error, nil
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return nil, "", ErrDeviceToken
+	}
+	token := &oauth2.Token{
+		AccessToken:  tokenResp.AccessToken,
+		TokenType:    tokenResp.TokenType,
+		RefreshToken: tokenResp.RefreshToken,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.Expiry = internal.DefaultClock.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return token, "", nil
+}
+
+// httpClient returns the ctx oauth2 HTTPClient or the http.DefaultClient,
+// with the gologin User-Agent.
+func httpClient(ctx context.Context) *http.Client {
+	client, _ := internal.WithUserAgentClient(ctx, oauth2.HTTPClient).Value(oauth2.HTTPClient).(*http.Client)
+	return client
+}
+
+// DeviceAuthHandler starts a device flow and responds with the DeviceAuth as
+// JSON, for apps whose device-side client displays the code. If the flow
+// cannot be started, the failure handler is called.
+func DeviceAuthHandler(flow *DeviceFlow, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		auth, err := flow.Start(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(auth)
+	}
+	return gologin.MethodHandler([]string{"POST"}, goji.HandlerFunc(fn), failure)
+}
+
+// DeviceTokenHandler reads the "device_code" (and optional "interval") form
+// values and polls until the user completes the device flow or the flow's
+// MaxWait elapses. An "interval" which is not an integer is ignored. If a
+// Token is obtained, it is added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+func DeviceTokenHandler(flow *DeviceFlow, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		auth := &DeviceAuth{DeviceCode: req.Form.Get("device_code")}
+		if auth.DeviceCode == "" {
+			ctx = gologin.WithError(ctx, gologin.MissingFieldError{Field: "device_code"})
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		if interval, err := strconv.Atoi(req.Form.Get("interval")); err == nil {
+			auth.Interval = interval
+		}
+		token, err := flow.Poll(ctx, auth)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		ctx = WithToken(ctx, token)
+		success.ServeHTTPC(ctx, w, req)
+	}
+	return gologin.MethodHandler([]string{"POST"}, goji.HandlerFunc(fn), failure)
+}
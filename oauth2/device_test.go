@@ -0,0 +1,201 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// newDeviceServer returns a server whose device endpoint issues a device code
+// and whose token endpoint responds with the given error codes in order
+// before issuing an access token.
+func newDeviceServer(t *testing.T, pending ...string) (*httptest.Server, *DeviceFlow) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		req.ParseForm()
+		assert.Equal(t, "client_id", req.PostForm.Get("client_id"))
+		assert.Equal(t, "read write", req.PostForm.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"device_code": "dc", "user_code": "ABCD-EFGH", "verification_uri": "https://example.com/device", "expires_in": 600, "interval": 2}`)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		assert.Equal(t, deviceGrantType, req.PostForm.Get("grant_type"))
+		assert.Equal(t, "dc", req.PostForm.Get("device_code"))
+		w.Header().Set("Content-Type", "application/json")
+		if len(pending) > 0 {
+			code := pending[0]
+			pending = pending[1:]
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error": %q}`, code)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "at", "token_type": "Bearer", "expires_in": 3600}`)
+	})
+	server := httptest.NewServer(mux)
+	flow := &DeviceFlow{
+		Config: &oauth2.Config{
+			ClientID: "client_id",
+			Scopes:   []string{"read", "write"},
+			Endpoint: oauth2.Endpoint{TokenURL: server.URL + "/token"},
+		},
+		DeviceAuthURL: server.URL + "/device",
+	}
+	return server, flow
+}
+
+// recordSleeps replaces deviceSleep to record waits without sleeping.
+func recordSleeps() (*[]time.Duration, func()) {
+	original := deviceSleep
+	sleeps := []time.Duration{}
+	deviceSleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	return &sleeps, func() { deviceSleep = original }
+}
+
+func TestDeviceFlow(t *testing.T) {
+	sleeps, restore := recordSleeps()
+	defer restore()
+	server, flow := newDeviceServer(t, "authorization_pending", "slow_down", "authorization_pending")
+	defer server.Close()
+
+	auth, err := flow.Start(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ABCD-EFGH", auth.UserCode)
+	assert.Equal(t, "https://example.com/device", auth.VerificationURI)
+
+	token, err := flow.Poll(context.Background(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, "at", token.AccessToken)
+	assert.False(t, token.Expiry.IsZero())
+	// interval is honored and increased by 5 seconds on slow_down
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second, 7 * time.Second, 7 * time.Second}, *sleeps)
+}
+
+func TestDeviceFlow_AccessDenied(t *testing.T) {
+	_, restore := recordSleeps()
+	defer restore()
+	server, flow := newDeviceServer(t, "authorization_pending", "access_denied")
+	defer server.Close()
+
+	token, err := flow.Poll(context.Background(), &DeviceAuth{DeviceCode: "dc"})
+	assert.Nil(t, token)
+	assert.Equal(t, ErrDeviceAccessDenied, err)
+}
+
+func TestDeviceFlow_ExpiredToken(t *testing.T) {
+	_, restore := recordSleeps()
+	defer restore()
+	server, flow := newDeviceServer(t, "expired_token")
+	defer server.Close()
+
+	_, err := flow.Poll(context.Background(), &DeviceAuth{DeviceCode: "dc"})
+	assert.Equal(t, ErrDeviceCodeExpired, err)
+}
+
+func TestDeviceFlow_CanceledContext(t *testing.T) {
+	_, restore := recordSleeps()
+	defer restore()
+	server, flow := newDeviceServer(t, "authorization_pending")
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := flow.Poll(ctx, &DeviceAuth{DeviceCode: "dc"})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestDeviceFlow_MaxWait(t *testing.T) {
+	original := internal.DefaultClock
+	clock := internal.NewFakeClock(time.Now())
+	internal.DefaultClock = clock
+	defer func() { internal.DefaultClock = original }()
+	sleeps, restore := recordSleeps()
+	defer restore()
+	deviceSleep = func(ctx context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		clock.Advance(d)
+		return nil
+	}
+	server, flow := newDeviceServer(t, "authorization_pending", "authorization_pending", "authorization_pending")
+	defer server.Close()
+	flow.MaxWait = 10 * time.Second
+
+	// assert that polling stops after MaxWait, even without an ExpiresIn
+	_, err := flow.Poll(context.Background(), &DeviceAuth{DeviceCode: "dc"})
+	assert.Equal(t, ErrDeviceCodeExpired, err)
+	assert.Equal(t, []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second}, *sleeps)
+}
+
+func TestDeviceFlow_ClampsInterval(t *testing.T) {
+	sleeps, restore := recordSleeps()
+	defer restore()
+	server, flow := newDeviceServer(t)
+	defer server.Close()
+
+	_, err := flow.Poll(context.Background(), &DeviceAuth{DeviceCode: "dc", Interval: 3600})
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{maxDeviceInterval * time.Second}, *sleeps)
+}
+
+func TestDeviceAuthHandler(t *testing.T) {
+	server, flow := newDeviceServer(t)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/device", nil)
+	DeviceAuthHandler(flow, testutils.AssertFailureNotCalled(t)).ServeHTTPC(context.Background(), w, req)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	auth := new(DeviceAuth)
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(auth))
+	assert.Equal(t, "dc", auth.DeviceCode)
+	assert.Equal(t, "ABCD-EFGH", auth.UserCode)
+}
+
+func TestDeviceTokenHandler(t *testing.T) {
+	_, restore := recordSleeps()
+	defer restore()
+	server, flow := newDeviceServer(t, "authorization_pending")
+	defer server.Close()
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "at", token.AccessToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+	w := httptest.NewRecorder()
+	form := url.Values{"device_code": {"dc"}}
+	req, _ := http.NewRequest("POST", "/device/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	DeviceTokenHandler(flow, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t)).ServeHTTPC(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestDeviceTokenHandler_MissingDeviceCode(t *testing.T) {
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		assert.Equal(t, gologin.MissingFieldError{Field: "device_code"}, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/device/token", nil)
+	DeviceTokenHandler(&DeviceFlow{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure)).ServeHTTPC(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
@@ -2,9 +2,19 @@ package oauth2
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"goji.io"
 	"github.com/quasor/gologin"
@@ -15,37 +25,242 @@ import (
 
 // Errors which may occur on login.
 var (
-	ErrInvalidState = errors.New("oauth2: Invalid OAuth2 state parameter")
+	ErrInvalidState         = errors.New("oauth2: Invalid OAuth2 state parameter")
+	ErrIssuerMismatch       = errors.New("oauth2: Invalid or missing OAuth2 iss parameter")
+	ErrForgedStateCookie    = errors.New("oauth2: Forged OAuth2 state cookie")
+	ErrStateTooLarge        = errors.New("oauth2: login metadata exceeds MaxLoginMetadataSize")
+	ErrResponseMode         = errors.New("oauth2: callback does not match the response mode")
+	ErrInsecureCallback     = errors.New("oauth2: callback was not received over HTTPS")
+	ErrCallbackIPNotAllowed = errors.New("oauth2: callback client IP is not allowed")
+	ErrMissingCode          = errors.New("oauth2: callback missing code and error parameters")
+	ErrUnsignedMinimalUser  = errors.New("oauth2: embedding a MinimalUser in the state cookie requires a Codec")
+	ErrUnsignedMetadata     = errors.New("oauth2: embedding login metadata in the state cookie requires a Codec")
 )
 
+// ProviderError is an error response from the provider's authorization
+// endpoint (RFC 6749 4.1.2.1), such as "access_denied" when the user cancels
+// the login. Check for it with a type assertion.
+type ProviderError struct {
+	// Code is the "error" parameter (e.g. access_denied, invalid_scope)
+	Code string
+	// Description is the optional "error_description" parameter
+	Description string
+	// URI is the optional "error_uri" parameter
+	URI string
+}
+
+func (e ProviderError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2: provider returned error %s: %s", e.Code, e.Description)
+	}
+	return fmt.Sprintf("oauth2: provider returned error %s", e.Code)
+}
+
+// Response modes, which select how the authorization server delivers
+// callback parameters.
+// https://openid.net/specs/oauth-v2-form-post-response-mode-1_0.html
+const (
+	// ResponseModeQuery delivers callback parameters in the query string of
+	// a GET request (the default).
+	ResponseModeQuery = "query"
+	// ResponseModeFormPost delivers callback parameters as the form body of
+	// a POST request, as used by Sign in with Apple.
+	ResponseModeFormPost = "form_post"
+)
+
+// MaxLoginMetadataSize is the maximum size in bytes of JSON encoded login
+// metadata, which keeps state cookies small.
+const MaxLoginMetadataSize = 512
+
+// MinimalUser is a minimal set of already-known user claims (e.g. from an
+// earlier ID token) carried through a login in the signed state cookie, so
+// success handlers can act on the user without a userinfo call.
+type MinimalUser struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
 // StateHandler checks for a state cookie. If found, the state value is read
 // and added to the ctx. Otherwise, a non-guessable value is added to the ctx
 // and to a (short-lived) state cookie issued to the requester.
 //
+// A flow ID derived from the state is also added to the ctx so the login and
+// callback phases of a single login can be correlated (e.g. in logs) without
+// exposing the state value itself.
+//
+// If the CookieConfig has a Codec, state cookie values are encoded with it
+// (e.g. signed) and state cookies which cannot be decoded are replaced. If
+// the CookieConfig uses SignedMarker, requests with a state cookie lacking a
+// matching marker cookie are rejected with ErrForgedStateCookie.
+//
+// Login metadata in the ctx (see WithLoginMetadata) is embedded in the state
+// cookie, and metadata from the state cookie is added to the ctx, so it is
+// available to callback handlers. Since the metadata must not be forgeable,
+// the CookieConfig must have a Codec which signs cookie values, otherwise
+// login fails with ErrUnsignedMetadata. Metadata larger than
+// MaxLoginMetadataSize is rejected with ErrStateTooLarge.
+//
+// Likewise, a MinimalUser in the ctx (see WithMinimalUser) is embedded in the
+// state cookie and added back to the ctx at callback. The CookieConfig must
+// have a Codec, otherwise login fails with ErrUnsignedMinimalUser.
+//
 // Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
 // state params differently, write a ContextHandler which sets the ctx state,
 // using oauth2 WithState(ctx, state) since it is required by LoginHandler
 // and CallbackHandler.
+//
+// If the ctx already has a state value, such as when StateHandler is
+// accidentally applied twice, it is reused and no second state cookie is
+// issued.
+//
+// StateHandler panics if the CookieConfig is invalid.
 func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		cookie, err := req.Cookie(config.Name)
-		if err == nil {
-			// add the cookie state to the ctx
-			ctx = WithState(ctx, cookie.Value)
-		} else {
-			// add Cookie with a random state
-			val := randomState()
-			http.SetCookie(w, internal.NewCookie(config, val))
-			ctx = WithState(ctx, val)
+		if state, ok := ctx.Value(stateKey).(string); ok && state != "" {
+			// an outer StateHandler already read or issued the state
+			success.ServeHTTPC(ctx, w, req)
+			return
+		}
+		state, metadata, user, err := readStateCookie(config, req)
+		if err == ErrForgedStateCookie {
+			ctx = gologin.WithError(ctx, err)
+			gologin.DefaultFailureHandler.ServeHTTPC(ctx, w, req)
+			return
+		}
+		loginMetadata, hasMetadata := ctx.Value(loginMetadataKey).(map[string]string)
+		loginUser, hasUser := ctx.Value(minimalUserKey).(*MinimalUser)
+		if hasMetadata && config.Codec == nil {
+			ctx = gologin.WithError(ctx, ErrUnsignedMetadata)
+			gologin.DefaultFailureHandler.ServeHTTPC(ctx, w, req)
+			return
+		}
+		if hasUser && config.Codec == nil {
+			ctx = gologin.WithError(ctx, ErrUnsignedMinimalUser)
+			gologin.DefaultFailureHandler.ServeHTTPC(ctx, w, req)
+			return
+		}
+		if err != nil || hasMetadata || hasUser {
+			if err != nil {
+				// add Cookie with a random state
+				state = randomState()
+			}
+			if hasMetadata {
+				metadata = loginMetadata
+			}
+			if hasUser {
+				user = loginUser
+			}
+			err = setStateCookies(config, w, req, state, metadata, user)
+			if err != nil {
+				ctx = gologin.WithError(ctx, err)
+				gologin.DefaultFailureHandler.ServeHTTPC(ctx, w, req)
+				return
+			}
+		}
+		ctx = WithState(ctx, state)
+		ctx = WithFlowID(ctx, flowID(state))
+		if metadata != nil {
+			ctx = WithLoginMetadata(ctx, metadata)
+		}
+		if user != nil {
+			ctx = WithMinimalUser(ctx, user)
 		}
 		success.ServeHTTPC(ctx, w, req)
 	}
 	return goji.HandlerFunc(fn)
 }
 
+// readStateCookie reads the state value, login metadata, and MinimalUser from
+// the state cookie, decoding it with the CookieConfig Codec, if set. Cookies
+// which cannot be decoded are treated as missing. Login metadata and a
+// MinimalUser are only read from Codec encoded cookies.
+func readStateCookie(config gologin.CookieConfig, req *http.Request) (string, map[string]string, *MinimalUser, error) {
+	cookie, err := req.Cookie(internal.CookieName(config))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	value, err := internal.DecodeCookieValue(config, cookie.Value)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	state, metadata, user, err := parseStateValue(value)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if config.SignedMarker && !internal.VerifyMarkerCookie(config, req, state) {
+		return "", nil, nil, ErrForgedStateCookie
+	}
+	if config.Codec == nil {
+		metadata = nil
+		user = nil
+	}
+	return state, metadata, user, nil
+}
+
+// setStateCookies sets the state cookie, holding the state and any login
+// metadata and MinimalUser, and, if the CookieConfig uses SignedMarker, its
+// companion marker cookie.
+func setStateCookies(config gologin.CookieConfig, w http.ResponseWriter, req *http.Request, state string, metadata map[string]string, user *MinimalUser) error {
+	stateValue, err := formatStateValue(state, metadata, user)
+	if err != nil {
+		return err
+	}
+	value, err := internal.EncodeCookieValue(config, stateValue)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, internal.NewRequestCookie(config, req, value))
+	if config.SignedMarker {
+		marker, err := internal.NewMarkerCookie(config, req, state)
+		if err != nil {
+			return err
+		}
+		http.SetCookie(w, marker)
+	}
+	return nil
+}
+
 // LoginHandler handles OAuth2 login requests by reading the state value from
-// the ctx and redirecting requests to the AuthURL with that state value.
+// the ctx and redirecting requests to the AuthURL with that state value. If
+// the ctx has a PKCE code verifier, its code challenge is sent too. If the ctx
+// has scopes (see gologin WithScopes), they are requested instead of the
+// Config Scopes. Only GET requests are allowed (see gologin MethodHandler).
 func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return LoginHandlerWithOptions(config, LoginOptions{}, failure)
+}
+
+// LoginOptions configures a LoginHandler.
+type LoginOptions struct {
+	// ResponseMode requests a response mode (e.g. ResponseModeFormPost) by
+	// adding the response_mode parameter to the AuthURL. Left empty, the
+	// provider's default (usually query) is used.
+	ResponseMode string
+	// IncludeGrantedScopes requests incremental authorization by adding
+	// include_granted_scopes=true to the AuthURL, so tokens from a step-up
+	// login (e.g. requesting scopes with gologin WithScopes) keep the scopes
+	// previously granted. Supported by Google and some other providers.
+	IncludeGrantedScopes bool
+	// Nonce adds an OpenID Connect nonce derived from the state value (see
+	// StateNonce) to the AuthURL, so the ID token can be bound to the state
+	// cookie of the login. Providers must check the ID token nonce claim.
+	Nonce bool
+}
+
+// StateNonce returns the OpenID Connect nonce sent with LoginOptions Nonce for
+// the state value, the base64url encoded SHA-256 hash of the state. Since the
+// state value is kept in the state cookie, the nonce needn't be stored.
+func StateNonce(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// LoginHandlerWithOptions handles OAuth2 login requests like LoginHandler,
+// configured by the given LoginOptions.
+func LoginHandlerWithOptions(config *oauth2.Config, options LoginOptions, failure goji.Handler) goji.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
@@ -56,50 +271,325 @@ func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
 			failure.ServeHTTPC(ctx, w, req)
 			return
 		}
-		authURL := config.AuthCodeURL(state)
+		loginConfig := config
+		if scopes := gologin.ScopesFromContext(ctx); scopes != nil {
+			scoped := *config
+			scoped.Scopes = scopes
+			loginConfig = &scoped
+		}
+		opts := pkceChallengeOptions(ctx)
+		if options.ResponseMode != "" {
+			opts = append(opts, oauth2.SetAuthURLParam("response_mode", options.ResponseMode))
+		}
+		if options.IncludeGrantedScopes {
+			opts = append(opts, oauth2.SetAuthURLParam("include_granted_scopes", "true"))
+		}
+		if options.Nonce {
+			opts = append(opts, oauth2.SetAuthURLParam("nonce", StateNonce(state)))
+		}
+		authURL := loginConfig.AuthCodeURL(state, opts...)
 		http.Redirect(w, req, authURL, http.StatusFound)
 	}
-	return goji.HandlerFunc(fn)
+	return gologin.MethodHandler([]string{"GET"}, goji.HandlerFunc(fn), failure)
+}
+
+// CallbackOptions configures a CallbackHandler.
+type CallbackOptions struct {
+	// DisableStateCheck skips comparing the callback state parameter with
+	// the state value from the ctx. This DISABLES CSRF protection and is
+	// only intended for trusted server-to-server flows and tests. Defaults
+	// to false.
+	DisableStateCheck bool
+	// ResponseMode is the response mode requested with LoginOptions. With
+	// ResponseModeFormPost, only POST callbacks are accepted and the code,
+	// state, and id_token are read from the form body (never the query).
+	// Since the callback is a cross-site POST, browsers only send the state
+	// cookie if it allows cross-site requests.
+	ResponseMode string
+	// BaseContext, if set, cancels the ctx of in-flight callbacks when it is
+	// done. This cancels the token exchange and success handler work which
+	// honors the ctx (e.g. requests made with ctxhttp), but not the provider
+	// user requests of gologin's provider packages, which are not bound to
+	// the ctx. Pass a ctx which is cancelled on server shutdown so callbacks
+	// don't hang the graceful shutdown. Provider CallbackHandlers do not
+	// expose it; it only applies to CallbackHandlerWithOptions.
+	BaseContext context.Context
+	// RequireTLS rejects callbacks which were not received over TLS (or
+	// forwarded by a proxy with "X-Forwarded-Proto: https") with
+	// ErrInsecureCallback, so auth codes never travel over plain HTTP. Only
+	// trust X-Forwarded-Proto behind a proxy which sets it. Defaults to false
+	// for local HTTP development.
+	RequireTLS bool
+	// AllowedNetworks, if non-empty, rejects callbacks from client IPs
+	// outside the networks with ErrCallbackIPNotAllowed. Only use it with
+	// providers which document fixed egress ranges for callback requests.
+	// See ParseNetworks.
+	AllowedNetworks []*net.IPNet
+	// TrustForwardedFor reads the client IP checked against AllowedNetworks
+	// from the last "X-Forwarded-For" address instead of the req.RemoteAddr.
+	// Only enable it behind a proxy which appends to X-Forwarded-For.
+	TrustForwardedFor bool
+}
+
+// ParseNetworks parses CIDR notation networks (e.g. "192.0.2.0/24") for use
+// as CallbackOptions AllowedNetworks.
+func ParseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
 }
 
 // CallbackHandler handles OAuth2 redirection URI requests by parsing the auth
 // code and state, comparing with the state value from the ctx, and obtaining
-// an OAuth2 Token.
+// an OAuth2 Token. If the ctx has a PKCE code verifier, it is sent with the
+// token request. The scopes granted to the token are added to the ctx (see
+// gologin ScopesFromContext).
+//
+// The code and state may also be POSTed as a JSON body {"code", "state"} by
+// single-page apps which receive them in the URL fragment. The request must
+// still carry the state cookie (e.g. a same-origin fetch). Other methods than
+// GET and POST are not allowed (see gologin MethodHandler).
 func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	return CallbackHandlerWithOptions(config, CallbackOptions{}, success, failure)
+}
+
+// CallbackHandlerWithOptions handles OAuth2 redirection URI requests like
+// CallbackHandler, configured by the given CallbackOptions.
+func CallbackHandlerWithOptions(config *oauth2.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
+	if options.DisableStateCheck {
+		gologin.DefaultLogger.Printf("gologin: WARNING oauth2 CallbackHandler state check is disabled, CSRF protection is off")
+	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		authCode, state, err := parseCallback(req)
-		if err != nil {
-			ctx = gologin.WithError(ctx, err)
+		if options.BaseContext != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = withBaseContext(ctx, options.BaseContext)
+			defer cancel()
+		}
+		if options.RequireTLS && !internal.IsSecureRequest(req) {
+			ctx = gologin.WithError(ctx, ErrInsecureCallback)
 			failure.ServeHTTPC(ctx, w, req)
 			return
 		}
-		ownerState, err := StateFromContext(ctx)
-		if err != nil {
-			ctx = gologin.WithError(ctx, err)
+		if len(options.AllowedNetworks) > 0 && !ipAllowed(internal.ClientIP(req, options.TrustForwardedFor), options.AllowedNetworks) {
+			ctx = gologin.WithError(ctx, ErrCallbackIPNotAllowed)
 			failure.ServeHTTPC(ctx, w, req)
 			return
 		}
-		if state != ownerState || state == "" {
-			ctx = gologin.WithError(ctx, ErrInvalidState)
+		if options.ResponseMode == ResponseModeFormPost {
+			if err := parseFormPost(req); err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTPC(ctx, w, req)
+				return
+			}
+		}
+		var authCode string
+		var err error
+		if options.DisableStateCheck {
+			authCode, err = parseAuthCode(req)
+		} else {
+			authCode, err = verifyCallback(ctx, req)
+		}
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTPC(ctx, w, req)
 			return
 		}
+		// token and provider requests set the gologin User-Agent
+		ctx = internal.WithUserAgentClient(ctx, oauth2.HTTPClient)
 		// use the authorization code to get a Token
-		token, err := config.Exchange(ctx, authCode)
+		token, err := config.Exchange(ctx, authCode, pkceVerifierOptions(ctx)...)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTPC(ctx, w, req)
 			return
 		}
 		ctx = WithToken(ctx, token)
+		ctx = gologin.WithScopes(ctx, grantedScopes(ctx, config, token))
+		// token responses may include an OpenID Connect id_token
+		if idToken, ok := token.Extra("id_token").(string); ok && idToken != "" {
+			ctx = WithIDToken(ctx, idToken)
+		} else if idToken := req.PostForm.Get("id_token"); options.ResponseMode == ResponseModeFormPost && idToken != "" {
+			// form_post callbacks may deliver the id_token in the form body
+			ctx = WithIDToken(ctx, idToken)
+		}
+		success.ServeHTTPC(ctx, w, req)
+	}
+	return gologin.MethodHandler([]string{"GET", "POST"}, goji.HandlerFunc(fn), failure)
+}
+
+// ipAllowed returns true if the ip is within one of the networks.
+func ipAllowed(ip net.IP, networks []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// withBaseContext returns a copy of ctx which is also cancelled when the base
+// ctx is done. The caller must call the CancelFunc to release resources.
+func withBaseContext(ctx, base context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-base.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// grantedScopes returns the scopes granted to the token. Providers include
+// the scope in token responses if it differs from the requested scopes (RFC
+// 6749 5.1), otherwise the requested ctx or Config scopes were granted.
+func grantedScopes(ctx context.Context, config *oauth2.Config, token *oauth2.Token) []string {
+	if scope, ok := token.Extra("scope").(string); ok && scope != "" {
+		// some providers (e.g. GitHub) separate scopes with commas
+		return strings.FieldsFunc(scope, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+	}
+	if scopes := gologin.ScopesFromContext(ctx); scopes != nil {
+		return scopes
+	}
+	return config.Scopes
+}
+
+// verifyCallback parses the callback request and returns the auth code if
+// the state parameter matches the state value from the ctx.
+func verifyCallback(ctx context.Context, req *http.Request) (authCode string, err error) {
+	authCode, state, err := parseCallback(req)
+	if err != nil {
+		return "", err
+	}
+	ownerState, err := StateFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if state == "" || !stateEqual(state, ownerState) {
+		return "", ErrInvalidState
+	}
+	return authCode, nil
+}
+
+// stateEqual compares the callback state parameter with the state value in
+// constant time.
+func stateEqual(state, ownerState string) bool {
+	return subtle.ConstantTimeCompare([]byte(state), []byte(ownerState)) == 1
+}
+
+// IssuerHandler checks that the "iss" parameter of OAuth2 redirection URI
+// requests equals the expected issuer, as described in RFC 9207 to prevent
+// mix-up attacks. If it matches, handling delegates to the success handler
+// (typically a CallbackHandler), otherwise ErrIssuerMismatch is added to the
+// ctx and the failure handler is called.
+//
+// Only use IssuerHandler with providers which send the "iss" parameter, since
+// a missing parameter is rejected.
+func IssuerHandler(issuer string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := parseCallbackForm(req)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		if req.Form.Get("iss") != issuer {
+			ctx = gologin.WithError(ctx, ErrIssuerMismatch)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
 		success.ServeHTTPC(ctx, w, req)
 	}
 	return goji.HandlerFunc(fn)
 }
 
+// formatStateValue returns the state cookie value for the state, login
+// metadata, and MinimalUser. Metadata is appended as ".<base64url JSON>" and
+// the MinimalUser as a further ".<base64url JSON>" (after an empty metadata
+// segment if there is no metadata), since std base64 states never contain a
+// ".".
+func formatStateValue(state string, metadata map[string]string, user *MinimalUser) (string, error) {
+	if metadata == nil && user == nil {
+		return state, nil
+	}
+	value := state + "."
+	if metadata != nil {
+		segment, err := encodeStateSegment(metadata)
+		if err != nil {
+			return "", err
+		}
+		value += segment
+	}
+	if user != nil {
+		segment, err := encodeStateSegment(user)
+		if err != nil {
+			return "", err
+		}
+		value += "." + segment
+	}
+	return value, nil
+}
+
+// encodeStateSegment returns the base64url JSON encoding of v, which may be
+// at most MaxLoginMetadataSize bytes of JSON.
+func encodeStateSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxLoginMetadataSize {
+		return "", ErrStateTooLarge
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// parseStateValue parses the state, login metadata, and MinimalUser from a
+// state cookie value.
+func parseStateValue(value string) (string, map[string]string, *MinimalUser, error) {
+	segments := strings.SplitN(value, ".", 3)
+	var metadata map[string]string
+	if len(segments) > 1 && segments[1] != "" {
+		if err := decodeStateSegment(segments[1], &metadata); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	var user *MinimalUser
+	if len(segments) > 2 {
+		user = new(MinimalUser)
+		if err := decodeStateSegment(segments[2], user); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	return segments[0], metadata, user, nil
+}
+
+// decodeStateSegment decodes a base64url JSON state cookie segment into v.
+func decodeStateSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
 // Returns a base64 encoded random 32 byte string.
 func randomState() string {
 	b := make([]byte, 32)
@@ -107,17 +597,132 @@ func randomState() string {
 	return base64.StdEncoding.EncodeToString(b)
 }
 
+// flowID returns a short identifier derived from the state value. The same
+// state always yields the same flow ID, but the state cannot be recovered from
+// it, so it is safe to log.
+func flowID(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:8])
+}
+
+// maxCallbackBodySize is the maximum size in bytes of a JSON callback body.
+const maxCallbackBodySize = 1 << 16
+
+// callbackBody is a JSON callback body posted by single-page apps which
+// receive the callback parameters in the URL fragment.
+type callbackBody struct {
+	Code             string `json:"code"`
+	State            string `json:"state"`
+	Iss              string `json:"iss"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	ErrorURI         string `json:"error_uri"`
+}
+
+// parseCallbackForm parses the callback parameters into req.Form. Besides
+// query and form parameters, POST requests with a JSON body of the form
+// {"code": "...", "state": "..."} are accepted, so single-page apps which
+// receive the parameters in the URL fragment (e.g. with hash routing) can
+// post them back. The JSON body is only read once, so handlers may parse the
+// same request repeatedly.
+func parseCallbackForm(req *http.Request) error {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if req.Method != "POST" || mediaType != "application/json" || req.PostForm != nil {
+		return req.ParseForm()
+	}
+	body := new(callbackBody)
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxCallbackBodySize)).Decode(body); err != nil {
+		return errors.New("oauth2: Invalid JSON callback body")
+	}
+	req.PostForm = url.Values{}
+	params := map[string]string{
+		"code":              body.Code,
+		"state":             body.State,
+		"iss":               body.Iss,
+		"error":             body.Error,
+		"error_description": body.ErrorDescription,
+		"error_uri":         body.ErrorURI,
+	}
+	for key, value := range params {
+		if value != "" {
+			req.PostForm.Set(key, value)
+		}
+	}
+	req.Form = url.Values{}
+	for key, values := range req.URL.Query() {
+		req.Form[key] = values
+	}
+	// body parameters take precedence over query parameters
+	for key, values := range req.PostForm {
+		req.Form[key] = values
+	}
+	return nil
+}
+
+// parseFormPost parses a form_post callback, keeping only the form body
+// parameters in req.Form so query parameters are ignored.
+func parseFormPost(req *http.Request) error {
+	if req.Method != "POST" {
+		return ErrResponseMode
+	}
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	req.Form = req.PostForm
+	return nil
+}
+
 // parseCallback parses the "code" and "state" parameters from the http.Request
-// and returns them.
+// and returns them. Callbacks with an "error" parameter return a
+// ProviderError. Callbacks with neither a "code" nor an "error" parameter
+// return ErrMissingCode, since they usually reach a misconfigured redirect
+// URI rather than come from the provider.
 func parseCallback(req *http.Request) (authCode, state string, err error) {
-	err = req.ParseForm()
+	err = parseCallbackForm(req)
 	if err != nil {
 		return "", "", err
 	}
+	if err := parseProviderError(req); err != nil {
+		return "", "", err
+	}
 	authCode = req.Form.Get("code")
 	state = req.Form.Get("state")
+	if authCode == "" {
+		return "", "", ErrMissingCode
+	}
 	if authCode == "" || state == "" {
 		return "", "", errors.New("oauth2: Request missing code or state")
 	}
 	return authCode, state, nil
 }
+
+// parseAuthCode parses the "code" parameter from the http.Request and returns
+// it, ignoring any "state" parameter.
+func parseAuthCode(req *http.Request) (authCode string, err error) {
+	err = parseCallbackForm(req)
+	if err != nil {
+		return "", err
+	}
+	if err := parseProviderError(req); err != nil {
+		return "", err
+	}
+	authCode = req.Form.Get("code")
+	if authCode == "" {
+		return "", ErrMissingCode
+	}
+	return authCode, nil
+}
+
+// parseProviderError returns a ProviderError if the parsed callback has an
+// "error" parameter, or nil otherwise.
+func parseProviderError(req *http.Request) error {
+	code := req.Form.Get("error")
+	if code == "" {
+		return nil
+	}
+	return ProviderError{
+		Code:        code,
+		Description: req.Form.Get("error_description"),
+		URI:         req.Form.Get("error_uri"),
+	}
+}
@@ -1,11 +1,14 @@
 package oauth2
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"goji.io"
 	"github.com/quasor/gologin"
@@ -15,6 +18,445 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// StateHandler
+
+func TestStateHandler_FlowID(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	var loginFlowID, callbackFlowID string
+	login := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		flowID, err := FlowIDFromContext(ctx)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, flowID)
+		loginFlowID = flowID
+	}
+	callback := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		flowID, err := FlowIDFromContext(ctx)
+		assert.Nil(t, err)
+		callbackFlowID = flowID
+	}
+
+	// StateHandler login phase issues a state cookie, assert that:
+	// - a flow ID is added to the ctx
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	StateHandler(config, goji.HandlerFunc(login)).ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 1) {
+		// StateHandler callback phase reads the state cookie, assert that:
+		// - the same flow ID is added to the ctx
+		req, _ = http.NewRequest("GET", "/callback", nil)
+		req.AddCookie(cookies[0])
+		StateHandler(config, goji.HandlerFunc(callback)).ServeHTTP(context.Background(), httptest.NewRecorder(), req)
+		assert.Equal(t, loginFlowID, callbackFlowID)
+		assert.NotContains(t, cookies[0].Value, loginFlowID)
+	}
+}
+
+func TestStateHandler_Twice(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	var outerState, innerState string
+	inner := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		innerState, _ = StateFromContext(ctx)
+	}
+	outer := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		outerState, _ = StateFromContext(ctx)
+		StateHandler(config, goji.HandlerFunc(inner)).ServeHTTP(ctx, w, req)
+	}
+
+	// StateHandler wrapped twice, assert that:
+	// - a single state cookie is issued
+	// - the inner handler sees the same state as the outer handler
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	StateHandler(config, goji.HandlerFunc(outer)).ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, outerState, cookies[0].Value)
+	}
+	assert.NotEmpty(t, innerState)
+	assert.Equal(t, outerState, innerState)
+}
+
+func TestStateHandler_HostPrefix(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	config.UseHostPrefix = true
+	var loginState, callbackState string
+	login := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		loginState, _ = StateFromContext(ctx)
+	}
+	callback := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		callbackState, _ = StateFromContext(ctx)
+	}
+
+	// StateHandler issues a __Host- prefixed Secure state cookie
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	StateHandler(config, goji.HandlerFunc(login)).ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "__Host-gologin-temporary-cookie", cookies[0].Name)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, "/", cookies[0].Path)
+		// StateHandler reads the __Host- prefixed state cookie
+		req, _ = http.NewRequest("GET", "/callback", nil)
+		req.AddCookie(cookies[0])
+		StateHandler(config, goji.HandlerFunc(callback)).ServeHTTP(context.Background(), httptest.NewRecorder(), req)
+		assert.Equal(t, loginState, callbackState)
+	}
+}
+
+func TestStateHandler_AutoSecure(t *testing.T) {
+	config := gologin.DefaultCookieConfig
+	config.AutoSecure = true
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {}
+	handler := StateHandler(config, goji.HandlerFunc(next))
+
+	// StateHandler issues a non-Secure state cookie for HTTP requests
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://example.com/login", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.False(t, cookies[0].Secure)
+	}
+
+	// StateHandler issues a Secure state cookie for HTTPS requests
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "https://example.com/login", nil)
+	req.TLS = &tls.ConnectionState{}
+	handler.ServeHTTP(context.Background(), w, req)
+	cookies = (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.True(t, cookies[0].Secure)
+	}
+}
+
+func TestStateHandler_InvalidHostPrefixConfig(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	config.UseHostPrefix = true
+	config.Domain = "example.com"
+	assert.Panics(t, func() {
+		StateHandler(config, testutils.AssertSuccessNotCalled(t))
+	})
+}
+
+func TestStateHandler_Codec(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token": "any-token", "token_type": "bearer"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Codec = fakeCodec{}
+	var state string
+	login := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		state, _ = StateFromContext(ctx)
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// StateHandler login phase, assert that:
+	// - the state cookie value is encoded with the Codec
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	StateHandler(cookieConfig, goji.HandlerFunc(login)).ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "signed:"+state, cookies[0].Value)
+	}
+
+	// StateHandler callback phase, assert that:
+	// - the state cookie is decoded with the Codec
+	// - CallbackHandler state check passes
+	callbackHandler := StateHandler(cookieConfig, CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t)))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/callback?code=any_code&state="+url.QueryEscape(state), nil)
+	req.AddCookie(cookies[0])
+	callbackHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestStateHandler_LoginMetadata(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token": "any-token", "token_type": "bearer"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Codec = fakeCodec{}
+	metadata := map[string]string{"invite": "inv_12345"}
+	var state string
+	login := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		state, _ = StateFromContext(ctx)
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		callbackMetadata, err := LoginMetadataFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, metadata, callbackMetadata)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// StateHandler login phase with ctx login metadata, assert that:
+	// - the metadata is embedded in the encoded state cookie
+	// - the state value itself is unchanged
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	ctx := WithLoginMetadata(context.Background(), metadata)
+	StateHandler(cookieConfig, goji.HandlerFunc(login)).ServeHTTP(ctx, w, req)
+	assert.NotContains(t, state, ".")
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(cookies[0].Value, "signed:"+state+"."))
+
+	// StateHandler callback phase, assert that:
+	// - the login metadata is added to the ctx of the success handler
+	// - CallbackHandler state check passes
+	callbackHandler := StateHandler(cookieConfig, CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t)))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/callback?code=any_code&state="+url.QueryEscape(state), nil)
+	req.AddCookie(cookies[0])
+	callbackHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestStateHandler_LoginMetadataTooLarge(t *testing.T) {
+	metadata := map[string]string{"invite": strings.Repeat("x", MaxLoginMetadataSize)}
+
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Codec = fakeCodec{}
+
+	// StateHandler login phase with oversized login metadata, assert that:
+	// - the request fails with ErrStateTooLarge and no cookie is issued
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	ctx := WithLoginMetadata(context.Background(), metadata)
+	StateHandler(cookieConfig, testutils.AssertSuccessNotCalled(t)).ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrStateTooLarge.Error()+"\n", w.Body.String())
+	assert.Empty(t, w.HeaderMap["Set-Cookie"])
+}
+
+func TestStateHandler_LoginMetadataRequiresCodec(t *testing.T) {
+	// StateHandler login phase with login metadata but no Codec, assert that:
+	// - the request fails with ErrUnsignedMetadata and no cookie is issued
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	ctx := WithLoginMetadata(context.Background(), map[string]string{"invite": "inv_12345"})
+	StateHandler(gologin.DebugOnlyCookieConfig, testutils.AssertSuccessNotCalled(t)).ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrUnsignedMetadata.Error()+"\n", w.Body.String())
+	assert.Empty(t, w.HeaderMap["Set-Cookie"])
+}
+
+func TestStateHandler_IgnoresUnsignedLoginMetadata(t *testing.T) {
+	// forged metadata in an unencoded state cookie
+	value, err := formatStateValue("some-state", map[string]string{"invite": "forged"}, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		state, _ := StateFromContext(ctx)
+		assert.Equal(t, "some-state", state)
+		metadata, err := LoginMetadataFromContext(ctx)
+		assert.Nil(t, metadata)
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// StateHandler callback phase without a Codec, assert that:
+	// - the login metadata is not added to the ctx
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	req.AddCookie(&http.Cookie{Name: gologin.DebugOnlyCookieConfig.Name, Value: value})
+	StateHandler(gologin.DebugOnlyCookieConfig, goji.HandlerFunc(success)).ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestStateHandler_MinimalUser(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token": "any-token", "token_type": "bearer"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Codec = fakeCodec{}
+	expectedUser := &MinimalUser{Subject: "248289761001", Email: "jane@example.com", Name: "Jane Doe"}
+	metadata := map[string]string{"invite": "inv_12345"}
+	for _, loginMetadata := range []map[string]string{nil, metadata} {
+		var state string
+		login := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			state, _ = StateFromContext(ctx)
+		}
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			user, err := MinimalUserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedUser, user)
+			callbackMetadata, _ := LoginMetadataFromContext(ctx)
+			assert.Equal(t, loginMetadata, callbackMetadata)
+			fmt.Fprintf(w, "success handler called")
+		}
+
+		// StateHandler login phase with a ctx MinimalUser (and optionally login
+		// metadata), assert that:
+		// - the claims are embedded in the encoded state cookie
+		// - the state value itself is unchanged
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/login", nil)
+		ctx := WithMinimalUser(context.Background(), expectedUser)
+		if loginMetadata != nil {
+			ctx = WithLoginMetadata(ctx, loginMetadata)
+		}
+		StateHandler(cookieConfig, goji.HandlerFunc(login)).ServeHTTP(ctx, w, req)
+		assert.NotContains(t, state, ".")
+		cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+		if !assert.Len(t, cookies, 1) {
+			return
+		}
+		assert.True(t, strings.HasPrefix(cookies[0].Value, "signed:"+state+"."))
+
+		// StateHandler callback phase, assert that:
+		// - the MinimalUser round-trips to the ctx of the success handler
+		callbackHandler := StateHandler(cookieConfig, CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t)))
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/callback?code=any_code&state="+url.QueryEscape(state), nil)
+		req.AddCookie(cookies[0])
+		callbackHandler.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+	}
+}
+
+func TestStateHandler_MinimalUserRequiresCodec(t *testing.T) {
+	// StateHandler login phase with a MinimalUser but no Codec, assert that:
+	// - the request fails with ErrUnsignedMinimalUser and no cookie is issued
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	ctx := WithMinimalUser(context.Background(), &MinimalUser{Subject: "248289761001"})
+	StateHandler(gologin.DebugOnlyCookieConfig, testutils.AssertSuccessNotCalled(t)).ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrUnsignedMinimalUser.Error()+"\n", w.Body.String())
+	assert.Empty(t, w.HeaderMap["Set-Cookie"])
+}
+
+func TestStateHandler_IgnoresUnsignedMinimalUser(t *testing.T) {
+	// forged claims in an unencoded state cookie
+	value, err := formatStateValue("some-state", nil, &MinimalUser{Subject: "admin"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		state, _ := StateFromContext(ctx)
+		assert.Equal(t, "some-state", state)
+		user, err := MinimalUserFromContext(ctx)
+		assert.Nil(t, user)
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// StateHandler callback phase without a Codec, assert that:
+	// - the MinimalUser is not added to the ctx
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	req.AddCookie(&http.Cookie{Name: gologin.DebugOnlyCookieConfig.Name, Value: value})
+	StateHandler(gologin.DebugOnlyCookieConfig, goji.HandlerFunc(success)).ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestStateHandler_SignedMarker(t *testing.T) {
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.HTTPOnly = false
+	cookieConfig.Codec = fakeCodec{}
+	cookieConfig.SignedMarker = true
+	var loginState, callbackState string
+	login := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		loginState, _ = StateFromContext(ctx)
+	}
+	callback := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		callbackState, _ = StateFromContext(ctx)
+	}
+
+	// StateHandler issues the state cookie and an HttpOnly signed marker
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	StateHandler(cookieConfig, goji.HandlerFunc(login)).ServeHTTP(context.Background(), w, req)
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, "gologin-temporary-cookie-marker", cookies[1].Name)
+		assert.Equal(t, "signed:"+loginState, cookies[1].Value)
+		assert.True(t, cookies[1].HttpOnly)
+		// StateHandler accepts the state cookie with its marker
+		req, _ = http.NewRequest("GET", "/callback", nil)
+		req.AddCookie(cookies[0])
+		req.AddCookie(cookies[1])
+		StateHandler(cookieConfig, goji.HandlerFunc(callback)).ServeHTTP(context.Background(), httptest.NewRecorder(), req)
+		assert.Equal(t, loginState, callbackState)
+	}
+}
+
+func TestStateHandler_SignedMarkerRejectsForgedCookie(t *testing.T) {
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Codec = fakeCodec{}
+	cookieConfig.SignedMarker = true
+	handler := StateHandler(cookieConfig, testutils.AssertSuccessNotCalled(t))
+
+	markers := []*http.Cookie{
+		// fabricated state cookie without a marker
+		nil,
+		// marker for a different state
+		{Name: "gologin-temporary-cookie-marker", Value: "signed:other"},
+		// unsigned marker
+		{Name: "gologin-temporary-cookie-marker", Value: "fabricated"},
+	}
+	for _, marker := range markers {
+		// StateHandler with a fabricated state cookie, assert that:
+		// - success handler is not called
+		// - ErrForgedStateCookie is reported
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/callback?code=any_code&state=fabricated", nil)
+		req.AddCookie(&http.Cookie{Name: "gologin-temporary-cookie", Value: "signed:fabricated"})
+		if marker != nil {
+			req.AddCookie(marker)
+		}
+		handler.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, ErrForgedStateCookie.Error()+"\n", w.Body.String())
+	}
+}
+
+func TestStateHandler_SignedMarkerWithoutCodec(t *testing.T) {
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.SignedMarker = true
+	assert.Panics(t, func() {
+		StateHandler(cookieConfig, testutils.AssertSuccessNotCalled(t))
+	})
+}
+
+func TestStateHandler_CodecRejectsForgedCookie(t *testing.T) {
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.Codec = fakeCodec{}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInvalidState, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// StateHandler receives a cookie the Codec cannot decode, assert that:
+	// - the cookie state is not trusted and the state check fails
+	callbackHandler := StateHandler(cookieConfig, CallbackHandler(&oauth2.Config{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure)))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback?code=any_code&state=forged", nil)
+	req.AddCookie(&http.Cookie{Name: cookieConfig.Name, Value: "forged"})
+	callbackHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
 // LoginHandler
 
 func TestLoginHandler(t *testing.T) {
@@ -43,6 +485,29 @@ func TestLoginHandler(t *testing.T) {
 	assert.Equal(t, expectedRedirect, w.HeaderMap.Get("Location"))
 }
 
+func TestLoginHandler_CtxScopes(t *testing.T) {
+	expectedRedirect := "https://api.example.com/authorize?client_id=client_id&response_type=code&scope=repo+read%3Aorg&state=state_val"
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Scopes:   []string{"read:user"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+
+	// LoginHandler with per-request ctx scopes, assert that:
+	// - the ctx scopes are requested instead of the Config Scopes
+	// - the Config is not modified
+	loginHandler := LoginHandler(config, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithState(context.Background(), "state_val")
+	ctx = gologin.WithScopes(ctx, []string{"repo", "read:org"})
+	loginHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, expectedRedirect, w.HeaderMap.Get("Location"))
+	assert.Equal(t, []string{"read:user"}, config.Scopes)
+}
+
 func TestLoginHandler_MissingCtxState(t *testing.T) {
 	config := &oauth2.Config{}
 	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
@@ -63,6 +528,35 @@ func TestLoginHandler_MissingCtxState(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestLoginHandler_RedirectIfAuthenticated(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+	isAuthenticated := func(req *http.Request) bool {
+		return req.Header.Get("Authorization") != ""
+	}
+	loginHandler := gologin.RedirectIfAuthenticated(isAuthenticated, "/profile", LoginHandler(config, testutils.AssertFailureNotCalled(t)))
+	ctx := WithState(context.Background(), "state_val")
+
+	// authenticated requests skip OAuth2 and redirect to the given URL
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer session")
+	loginHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/profile", w.HeaderMap.Get("Location"))
+
+	// unauthenticated requests redirect to the AuthURL
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.True(t, strings.HasPrefix(w.HeaderMap.Get("Location"), "https://api.example.com/authorize"))
+}
+
 // CallbackHandler
 
 func TestCallbackHandler(t *testing.T) {
@@ -108,6 +602,164 @@ func TestCallbackHandler(t *testing.T) {
 	assert.Equal(t, "success handler called", w.Body.String())
 }
 
+func TestCallbackHandler_Scopes(t *testing.T) {
+	cases := []struct {
+		jsonData string
+		expected []string
+	}{
+		// granted scopes differ from the requested scopes
+		{`{"access_token": "2YotnFZFEjr1zCsicMWpAA", "token_type": "bearer", "scope": "read:user,user:email"}`, []string{"read:user", "user:email"}},
+		{`{"access_token": "2YotnFZFEjr1zCsicMWpAA", "token_type": "bearer", "scope": "openid profile"}`, []string{"openid", "profile"}},
+		// requested scopes were granted
+		{`{"access_token": "2YotnFZFEjr1zCsicMWpAA", "token_type": "bearer"}`, []string{"read:user"}},
+	}
+	for _, c := range cases {
+		server := NewAccessTokenServer(t, c.jsonData)
+		config := &oauth2.Config{
+			Scopes: []string{"read:user"},
+			Endpoint: oauth2.Endpoint{
+				TokenURL: server.URL,
+			},
+		}
+		var entries []gologin.AccessLogEntry
+		record := func(entry gologin.AccessLogEntry) {
+			entries = append(entries, entry)
+		}
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, c.expected, gologin.ScopesFromContext(ctx))
+			fmt.Fprintf(w, "success handler called")
+		}
+
+		// CallbackHandler within an AccessLogHandler, assert that:
+		// - the granted scopes are added to the ctx of the success handler
+		// - the granted scopes are reported to the access log hook
+		handler := CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+		handler = gologin.AccessLogHandler("example", record, handler)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		ctx := WithState(context.Background(), "d4e5f6")
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, c.expected, entries[0].Scopes)
+		}
+		server.Close()
+	}
+}
+
+func TestCallbackHandler_RequestHook(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token": "2YotnFZFEjr1zCsicMWpAA", "token_type": "bearer"}`)
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	gologin.RequestHook = func(req *http.Request) {
+		// rewrite the provider host to the test server
+		req.URL.Host = serverURL.Host
+		req.Host = serverURL.Host
+	}
+	defer func() { gologin.RequestHook = nil }()
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: "http://provider.invalid/token",
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "2YotnFZFEjr1zCsicMWpAA", token.AccessToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CallbackHandler with a RequestHook, assert that:
+	// - the rewritten token request reaches the test server
+	callbackHandler := CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_IDToken(t *testing.T) {
+	jsonData := `{
+       "access_token":"2YotnFZFEjr1zCsicMWpAA",
+       "token_type":"Bearer",
+       "id_token":"eyJhbGciOiJSUzI1NiJ9.e30.c2lnbmF0dXJl"
+     }`
+	server := NewAccessTokenServer(t, jsonData)
+	defer server.Close()
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		idToken, err := IDTokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "eyJhbGciOiJSUzI1NiJ9.e30.c2lnbmF0dXJl", idToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler gets an access token with an id_token, assert that:
+	// - the raw id_token is added to the ctx of the success handler
+	callbackHandler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_Expiry(t *testing.T) {
+	jsonData := `{
+       "access_token":"2YotnFZFEjr1zCsicMWpAA",
+       "token_type":"Bearer",
+       "expires_in":3600
+     }`
+	server := NewAccessTokenServer(t, jsonData)
+	defer server.Close()
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	provider := Provider{
+		Name: "example",
+		CallbackHandler: func(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+			user := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+				ctx = gologin.WithUser(ctx, "example-user")
+				success.ServeHTTPC(ctx, w, req)
+			}
+			return CallbackHandler(config, goji.HandlerFunc(user), failure)
+		},
+	}
+	before := time.Now()
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		expiry, ok := TokenExpiryFromContext(ctx)
+		assert.True(t, ok)
+		assert.False(t, expiry.Before(before.Add(time.Hour)))
+		assert.False(t, expiry.After(time.Now().Add(time.Hour)))
+		login, err := LoginFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expiry, login.Token.Expiry)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// HandleCallback gets an access token which expires, assert that:
+	// - the Token expiry survives the callback chain unmodified
+	// - the Login Token has the same expiry
+	callbackHandler := HandleCallback(config, provider, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
 func TestCallbackHandler_ParseCallbackError(t *testing.T) {
 	config := &oauth2.Config{}
 	success := testutils.AssertSuccessNotCalled(t)
@@ -127,11 +779,55 @@ func TestCallbackHandler_ParseCallbackError(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/?code=any_code", nil)
 	callbackHandler.ServeHTTP(context.Background(), w, req)
 	assert.Equal(t, "failure handler called", w.Body.String())
+}
 
-	w = httptest.NewRecorder()
-	req, _ = http.NewRequest("GET", "/?state=any_state", nil)
-	callbackHandler.ServeHTTP(context.Background(), w, req)
-	assert.Equal(t, "failure handler called", w.Body.String())
+func TestCallbackHandler_ProviderError(t *testing.T) {
+	// exchanges fail if attempted, the test server is never called
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: "http://127.0.0.1:0/token"}}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if providerErr, ok := err.(ProviderError); assert.True(t, ok) {
+			assert.Equal(t, "access_denied", providerErr.Code)
+			assert.Equal(t, "The user denied the request", providerErr.Description)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler called with an error param, assert that:
+	// - failure handler is called with a ProviderError, with or without state
+	// - the error is reported before any exchange, even with a code
+	callbackHandler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	for _, target := range []string{
+		"/?error=access_denied&error_description=The+user+denied+the+request",
+		"/?error=access_denied&error_description=The+user+denied+the+request&state=d4e5f6",
+		"/?error=access_denied&error_description=The+user+denied+the+request&code=any_code&state=d4e5f6",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target, nil)
+		callbackHandler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestCallbackHandler_MissingCode(t *testing.T) {
+	// exchanges fail if attempted, the test server is never called
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: "http://127.0.0.1:0/token"}}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrMissingCode, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler called without code or error params, assert that:
+	// - failure handler is called with ErrMissingCode before any exchange
+	for _, options := range []CallbackOptions{{}, {DisableStateCheck: true}} {
+		callbackHandler := CallbackHandlerWithOptions(config, options, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+		for _, target := range []string{"/", "/?state=d4e5f6"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", target, nil)
+			callbackHandler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+			assert.Equal(t, "failure handler called", w.Body.String())
+		}
+	}
 }
 
 func TestCallbackHandler_MissingCtxState(t *testing.T) {
@@ -177,6 +873,194 @@ func TestCallbackHandler_StateMismatch(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestStateEqual(t *testing.T) {
+	cases := []struct {
+		state, ownerState string
+		expected          bool
+	}{
+		{"d4e5f6", "d4e5f6", true},
+		{"d4e5f6", "d4e5f7", false},
+		{"d4e5f6", "d4e5f", false},
+		{"d4e5f6", "", false},
+		{"", "d4e5f6", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, stateEqual(c.state, c.ownerState))
+	}
+}
+
+func TestCallbackHandler_StateLengthMismatch(t *testing.T) {
+	config := &oauth2.Config{}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInvalidState, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	// assert that a state param which prefixes the ctx state is rejected
+	callbackHandler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestCallbackHandler_DisableStateCheck(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`)
+	defer server.Close()
+	logger := &recordingLogger{}
+	defer func(l gologin.Logger) { gologin.DefaultLogger = l }(gologin.DefaultLogger)
+	gologin.DefaultLogger = logger
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "2YotnFZFEjr1zCsicMWpAA", token.AccessToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+	options := CallbackOptions{DisableStateCheck: true}
+	callbackHandler := CallbackHandlerWithOptions(config, options, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	// assert that a warning is logged when the state check is disabled
+	if assert.Len(t, logger.messages, 1) {
+		assert.Contains(t, logger.messages[0], "state check is disabled")
+	}
+
+	// CallbackHandler with DisableStateCheck, assert that:
+	// - success handler is called despite a mismatched or missing state
+	for _, target := range []string{"/?code=any_code&state=d4e5f6", "/?code=any_code"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target, nil)
+		ctx := WithState(context.Background(), "differentState")
+		callbackHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+	}
+}
+
+func TestCallbackHandler_StateCheckEnabledByDefault(t *testing.T) {
+	config := &oauth2.Config{}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInvalidState, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	// assert that zero valued CallbackOptions still check the state
+	callbackHandler := CallbackHandlerWithOptions(config, CallbackOptions{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "differentState")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestCallbackHandler_RequireTLS(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInsecureCallback, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	options := CallbackOptions{RequireTLS: true}
+	callbackHandler := CallbackHandlerWithOptions(config, options, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+
+	cases := []struct {
+		tls            bool
+		forwardedProto string
+		expected       string
+	}{
+		{false, "", "failure handler called"},
+		{false, "http", "failure handler called"},
+		{true, "", "success handler called"},
+		{false, "https", "success handler called"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		if c.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		if c.forwardedProto != "" {
+			req.Header.Set("X-Forwarded-Proto", c.forwardedProto)
+		}
+		callbackHandler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+func TestCallbackHandler_AllowedNetworks(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	networks, err := ParseNetworks([]string{"192.0.2.0/24", "2001:db8::/32"})
+	assert.Nil(t, err)
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrCallbackIPNotAllowed, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	cases := []struct {
+		trustForwardedFor bool
+		remoteAddr        string
+		forwardedFor      string
+		expected          string
+	}{
+		{false, "192.0.2.10:1234", "", "success handler called"},
+		{false, "[2001:db8::1]:1234", "", "success handler called"},
+		{false, "198.51.100.7:1234", "", "failure handler called"},
+		// X-Forwarded-For is ignored unless trusted
+		{false, "198.51.100.7:1234", "192.0.2.10", "failure handler called"},
+		{true, "198.51.100.7:1234", "192.0.2.10", "success handler called"},
+		{true, "192.0.2.10:1234", "198.51.100.7", "failure handler called"},
+	}
+	for _, c := range cases {
+		options := CallbackOptions{AllowedNetworks: networks, TrustForwardedFor: c.trustForwardedFor}
+		callbackHandler := CallbackHandlerWithOptions(config, options, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+		req.RemoteAddr = c.remoteAddr
+		if c.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", c.forwardedFor)
+		}
+		callbackHandler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"192.0.2.0/24"})
+	assert.Nil(t, err)
+	if assert.Len(t, networks, 1) {
+		assert.Equal(t, "192.0.2.0/24", networks[0].String())
+	}
+	_, err = ParseNetworks([]string{"192.0.2.0/24", "192.0.2.1"})
+	assert.NotNil(t, err)
+}
+
 func TestCallbackHandler_ExchangeError(t *testing.T) {
 	_, server := testutils.NewErrorServer("OAuth2 Service Down", http.StatusInternalServerError)
 	defer server.Close()
@@ -206,3 +1090,280 @@ func TestCallbackHandler_ExchangeError(t *testing.T) {
 	callbackHandler.ServeHTTP(ctx, w, req)
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
+
+func TestCallbackHandler_BaseContext(t *testing.T) {
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	server := NewTestServerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// token endpoint hangs until the test ends
+		close(requested)
+		<-release
+	})
+	defer server.Close()
+	defer close(release)
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	base, cancel := context.WithCancel(context.Background())
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.NotNil(t, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	go func() {
+		<-requested
+		cancel()
+	}()
+
+	// CallbackHandler with a BaseContext cancelled mid-exchange, assert that:
+	// - the token exchange is cancelled and the failure handler is called
+	options := CallbackOptions{BaseContext: base}
+	callbackHandler := CallbackHandlerWithOptions(config, options, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	done := make(chan struct{})
+	go func() {
+		callbackHandler.ServeHTTP(ctx, w, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the callback to be cancelled")
+	}
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+// IssuerHandler
+
+func TestIssuerHandler(t *testing.T) {
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrIssuerMismatch, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := IssuerHandler("https://auth.example.com", goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	cases := []struct {
+		url      string
+		expected string
+	}{
+		// matching iss
+		{"/?code=any_code&state=d4e5f6&iss=https%3A%2F%2Fauth.example.com", "success handler called"},
+		// mismatching iss
+		{"/?code=any_code&state=d4e5f6&iss=https%3A%2F%2Fattacker.example.com", "failure handler called"},
+		// absent iss
+		{"/?code=any_code&state=d4e5f6", "failure handler called"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", c.url, nil)
+		handler.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+func TestCallbackHandler_JSONBody(t *testing.T) {
+	jsonData := `{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`
+	server := NewAccessTokenServer(t, jsonData)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "2YotnFZFEjr1zCsicMWpAA", token.AccessToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CallbackHandler reads the code and state from a posted JSON body
+	callbackHandler := CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/callback", strings.NewReader(`{"code": "any_code", "state": "d4e5f6"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	ctx := WithState(context.Background(), "d4e5f6")
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_JSONBodyErrors(t *testing.T) {
+	config := &oauth2.Config{}
+	cases := []struct {
+		body        string
+		expectedErr string
+	}{
+		{`{"code": "any_code", "state": "wrong"}`, ErrInvalidState.Error()},
+		{`{"code": "any_code"}`, "oauth2: Request missing code or state"},
+		{`not-json`, "oauth2: Invalid JSON callback body"},
+	}
+	for _, c := range cases {
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(ctx)
+			if assert.NotNil(t, err) {
+				assert.Equal(t, c.expectedErr, err.Error())
+			}
+			fmt.Fprintf(w, "failure handler called")
+		}
+		callbackHandler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/callback", strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := WithState(context.Background(), "d4e5f6")
+		callbackHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestIssuerHandler_JSONBody(t *testing.T) {
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		// assert the JSON body parameters remain readable
+		code, state, err := parseCallback(req)
+		assert.Nil(t, err)
+		assert.Equal(t, "any_code", code)
+		assert.Equal(t, "d4e5f6", state)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := IssuerHandler("https://issuer.example.com", goji.HandlerFunc(next), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/callback", strings.NewReader(`{"code": "any_code", "state": "d4e5f6", "iss": "https://issuer.example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLoginHandlerWithOptions_ResponseMode(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID:    "client_id",
+		RedirectURL: "redirect_url",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+	handler := LoginHandlerWithOptions(config, LoginOptions{ResponseMode: ResponseModeFormPost}, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(WithState(context.Background(), "state_val"), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://api.example.com/authorize?client_id=client_id&redirect_uri=redirect_url&response_mode=form_post&response_type=code&state=state_val", w.HeaderMap.Get("Location"))
+}
+
+func TestLoginHandlerWithOptions_IncludeGrantedScopes(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID:    "client_id",
+		RedirectURL: "redirect_url",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+	handler := LoginHandlerWithOptions(config, LoginOptions{IncludeGrantedScopes: true}, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := gologin.WithScopes(WithState(context.Background(), "state_val"), []string{"calendar"})
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://api.example.com/authorize?client_id=client_id&include_granted_scopes=true&redirect_uri=redirect_url&response_type=code&scope=calendar&state=state_val", w.HeaderMap.Get("Location"))
+}
+
+func TestLoginHandlerWithOptions_Nonce(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID:    "client_id",
+		RedirectURL: "redirect_url",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+	handler := LoginHandlerWithOptions(config, LoginOptions{Nonce: true}, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(WithState(context.Background(), "state_val"), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	// base64url SHA-256 of "state_val"
+	assert.Equal(t, "https://api.example.com/authorize?client_id=client_id&nonce="+StateNonce("state_val")+"&redirect_uri=redirect_url&response_type=code&state=state_val", w.HeaderMap.Get("Location"))
+	assert.NotEqual(t, StateNonce("state_val"), StateNonce("other_state"))
+}
+
+func TestCallbackHandler_FormPost(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "2YotnFZFEjr1zCsicMWpAA", token.AccessToken)
+		// the id_token is read from the form body
+		idToken, err := IDTokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "header.payload.signature", idToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CallbackHandler reads the code, state, and id_token from the form body
+	options := CallbackOptions{ResponseMode: ResponseModeFormPost}
+	handler := CallbackHandlerWithOptions(config, options, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	form := url.Values{"code": {"any_code"}, "state": {"d4e5f6"}, "id_token": {"header.payload.signature"}}
+	req, _ := http.NewRequest("POST", "/callback", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_FormPostRejectsQuery(t *testing.T) {
+	options := CallbackOptions{ResponseMode: ResponseModeFormPost}
+	cases := []struct {
+		method      string
+		expectedErr error
+	}{
+		// query callbacks are rejected in form_post mode
+		{"GET", ErrResponseMode},
+		// query parameters of a POST are ignored
+		{"POST", ErrMissingCode},
+	}
+	for _, c := range cases {
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, c.expectedErr, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+		handler := CallbackHandlerWithOptions(&oauth2.Config{}, options, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(c.method, "/callback?code=any_code&state=d4e5f6", strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestHandlers_MethodNotAllowed(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	cases := []struct {
+		handler       goji.Handler
+		method        string
+		expectedAllow string
+	}{
+		{LoginHandler(config, nil), "POST", "GET"},
+		{CallbackHandler(config, success, nil), "PUT", "GET, POST"},
+		{DeviceAuthHandler(&DeviceFlow{}, nil), "GET", "POST"},
+		{DeviceTokenHandler(&DeviceFlow{}, success, nil), "GET", "POST"},
+	}
+	for _, c := range cases {
+		// handlers with the default failure handler, assert that:
+		// - wrong methods get a 405 with the allowed methods
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(c.method, "/", nil)
+		c.handler.ServeHTTP(WithState(context.Background(), "d4e5f6"), w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, c.expectedAllow, w.Header().Get("Allow"))
+	}
+}
@@ -1,8 +1,10 @@
 package oauth2
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,3 +30,18 @@ func NewAccessTokenServer(t *testing.T, json string) *httptest.Server {
 func NewTestServerFunc(handler func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(handler))
 }
+
+// fakeCodec is a gologin Codec which "signs" string values with a prefix.
+type fakeCodec struct{}
+
+func (c fakeCodec) Encode(name string, value interface{}) (string, error) {
+	return "signed:" + value.(string), nil
+}
+
+func (c fakeCodec) Decode(name, value string, dst interface{}) error {
+	if !strings.HasPrefix(value, "signed:") {
+		return errors.New("fakeCodec: invalid signature")
+	}
+	*dst.(*string) = strings.TrimPrefix(value, "signed:")
+	return nil
+}
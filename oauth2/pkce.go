@@ -0,0 +1,94 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// PKCEHandler checks for a PKCE verifier cookie. If found, the code verifier
+// is read and added to the ctx. Otherwise, a new random code verifier is
+// added to the ctx and to a (short-lived) "-pkce" cookie issued to the
+// requester.
+//
+// Implements Proof Key for Code Exchange (RFC 7636). When the ctx has a code
+// verifier, LoginHandler sends its S256 code challenge and CallbackHandler
+// sends it with the token request. Like StateHandler, wrap both the login
+// and callback handlers.
+//
+// PKCEHandler panics if the CookieConfig is invalid.
+func PKCEHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	config.Name = config.Name + "-pkce"
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		verifier, err := readPKCECookie(config, req)
+		if err != nil {
+			verifier = randomVerifier()
+			value, err := internal.EncodeCookieValue(config, verifier)
+			if err != nil {
+				ctx = gologin.WithError(ctx, err)
+				gologin.DefaultFailureHandler.ServeHTTPC(ctx, w, req)
+				return
+			}
+			http.SetCookie(w, internal.NewRequestCookie(config, req, value))
+		}
+		ctx = WithPKCEVerifier(ctx, verifier)
+		success.ServeHTTPC(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// readPKCECookie reads the code verifier from the PKCE cookie, decoding it
+// with the CookieConfig Codec, if set.
+func readPKCECookie(config gologin.CookieConfig, req *http.Request) (string, error) {
+	cookie, err := req.Cookie(internal.CookieName(config))
+	if err != nil {
+		return "", err
+	}
+	return internal.DecodeCookieValue(config, cookie.Value)
+}
+
+// randomVerifier returns a base64url encoded random 32 byte code verifier.
+func randomVerifier() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// s256Challenge returns the S256 code challenge of the code verifier.
+func s256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// pkceChallengeOptions returns the auth URL options which send the code
+// challenge of the ctx code verifier, if any.
+func pkceChallengeOptions(ctx context.Context) []oauth2.AuthCodeOption {
+	verifier, err := PKCEVerifierFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", s256Challenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+}
+
+// pkceVerifierOptions returns the token request options which send the ctx
+// code verifier, if any.
+func pkceVerifierOptions(ctx context.Context) []oauth2.AuthCodeOption {
+	verifier, err := PKCEVerifierFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	return []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("code_verifier", verifier)}
+}
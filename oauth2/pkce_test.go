@@ -0,0 +1,95 @@
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestPKCEHandler(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	var verifier string
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		var err error
+		verifier, err = PKCEVerifierFromContext(ctx)
+		assert.Nil(t, err)
+	}
+
+	// PKCEHandler without a PKCE cookie, assert that:
+	// - a code verifier is added to the ctx
+	// - a "-pkce" cookie with the code verifier is issued
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	PKCEHandler(config, goji.HandlerFunc(success)).ServeHTTP(context.Background(), w, req)
+	assert.Len(t, verifier, 43)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, config.Name+"-pkce", cookies[0].Name)
+		assert.Equal(t, verifier, cookies[0].Value)
+	}
+
+	// PKCEHandler with a PKCE cookie, assert that:
+	// - the cookie code verifier is added to the ctx
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/callback", nil)
+	req.AddCookie(cookies[0])
+	issued := verifier
+	PKCEHandler(config, goji.HandlerFunc(success)).ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, issued, verifier)
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestLoginHandler_PKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://api.example.com/authorize"},
+	}
+
+	// LoginHandler with a ctx code verifier, assert that:
+	// - the redirect url has the RFC 7636 S256 code challenge
+	loginHandler := LoginHandler(config, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithState(context.Background(), "state_val")
+	ctx = WithPKCEVerifier(ctx, verifier)
+	loginHandler.ServeHTTP(ctx, w, req)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", location.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+}
+
+func TestCallbackHandler_PKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	server := NewTestServerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, verifier, req.PostFormValue("code_verifier"))
+		w.Header().Set(contentType, jsonContentType)
+		fmt.Fprintf(w, `{"access_token": "2YotnFZFEjr1zCsicMWpAA", "token_type": "Bearer"}`)
+	})
+	defer server.Close()
+
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: server.URL}}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CallbackHandler with a ctx code verifier, assert that:
+	// - the code verifier is sent with the token request
+	callbackHandler := CallbackHandler(config, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	ctx = WithPKCEVerifier(ctx, verifier)
+	callbackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
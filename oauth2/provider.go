@@ -0,0 +1,119 @@
+package oauth2
+
+import (
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Provider is an OAuth2 login provider. Provider packages export a Provider
+// for use with HandleCallback.
+type Provider struct {
+	// Name identifies the provider (e.g. "github").
+	Name string
+	// CallbackHandler is the provider's CallbackHandler constructor, which
+	// checks the state, exchanges the auth code, and fetches the user.
+	CallbackHandler func(config *oauth2.Config, success, failure goji.Handler) goji.Handler
+	// AuthStyle is how the provider requires client credentials be sent on
+	// token exchange, for providers which reject the oauth2 default. Zero
+	// leaves the config's AuthStyle unchanged.
+	AuthStyle oauth2.AuthStyle
+}
+
+// Configure returns the config for use with the Provider. If the Provider
+// requires an AuthStyle and the config's Endpoint leaves it to be
+// auto-detected, a copy of the config using the Provider's AuthStyle is
+// returned.
+func (p Provider) Configure(config *oauth2.Config) *oauth2.Config {
+	if p.AuthStyle == oauth2.AuthStyleAutoDetect || config.Endpoint.AuthStyle != oauth2.AuthStyleAutoDetect {
+		return config
+	}
+	configured := *config
+	configured.Endpoint.AuthStyle = p.AuthStyle
+	return &configured
+}
+
+// Login is the provider-agnostic result of a successful OAuth2 login.
+type Login struct {
+	// Provider is the Name of the Provider the user logged in with.
+	Provider string
+	// User is the provider user as added to the ctx by gologin.WithUser.
+	User interface{}
+	// Token is the OAuth2 Token obtained from the provider.
+	Token *oauth2.Token
+}
+
+// HandleCallback handles OAuth2 redirection URI requests using the given
+// Provider's CallbackHandler and adds a Login to the ctx. If authentication
+// succeeds, handling delegates to the success handler, otherwise to the
+// failure handler.
+//
+// Apps supporting several providers can use HandleCallback to write a single
+// success handler which reads the Login from the ctx. The config is
+// configured for the Provider with Provider.Configure.
+func HandleCallback(config *oauth2.Config, provider Provider, success, failure goji.Handler) goji.Handler {
+	success = loginHandler(provider.Name, success, failure)
+	return provider.CallbackHandler(provider.Configure(config), success, failure)
+}
+
+// loginHandler is a ContextHandler that reads the Token and provider user from
+// the ctx and adds a Login to the ctx. If successful, the success handler is
+// called. Otherwise, the failure handler is called.
+func loginHandler(provider string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		user, err := gologin.UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		ctx = WithLogin(ctx, &Login{Provider: provider, User: user, Token: token})
+		success.ServeHTTPC(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// LoginHook is called with the Login after a successful authentication, for
+// example to publish a login event to a message bus. Returning an error
+// aborts the login.
+type LoginHook func(ctx context.Context, login Login) error
+
+// OnLoginHandler is a ContextHandler that reads the Login added by
+// HandleCallback from the ctx and calls the onLogin hook before the success
+// handler. If the hook returns an error, the failure handler is called
+// instead.
+//
+//	success = oauth2Login.OnLoginHandler(publishLogin, success, failure)
+//	oauth2Login.HandleCallback(config, github.Provider, success, failure)
+func OnLoginHandler(onLogin LoginHook, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		login, err := LoginFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		if err := onLogin(ctx, *login); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTPC(ctx, w, req)
+			return
+		}
+		success.ServeHTTPC(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
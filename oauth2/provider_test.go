@@ -0,0 +1,97 @@
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestProvider_Configure(t *testing.T) {
+	provider := Provider{Name: "example", AuthStyle: oauth2.AuthStyleInParams}
+	config := &oauth2.Config{ClientID: "client-id"}
+
+	// assert the Provider AuthStyle is used when the config auto-detects
+	configured := provider.Configure(config)
+	assert.Equal(t, oauth2.AuthStyleInParams, configured.Endpoint.AuthStyle)
+	assert.Equal(t, "client-id", configured.ClientID)
+	// assert the caller's config is not modified
+	assert.Equal(t, oauth2.AuthStyleAutoDetect, config.Endpoint.AuthStyle)
+
+	// assert an explicit config AuthStyle is kept
+	explicit := &oauth2.Config{Endpoint: oauth2.Endpoint{AuthStyle: oauth2.AuthStyleInHeader}}
+	assert.Equal(t, explicit, provider.Configure(explicit))
+
+	// assert Providers without an AuthStyle leave the config unchanged
+	assert.Equal(t, config, Provider{Name: "example"}.Configure(config))
+}
+
+func TestOnLoginHandler(t *testing.T) {
+	expectedLogin := &Login{Provider: "example", User: "example-user", Token: &oauth2.Token{AccessToken: "access_token"}}
+	var called []string
+	onLogin := func(ctx context.Context, login Login) error {
+		called = append(called, "hook")
+		assert.Equal(t, *expectedLogin, login)
+		return nil
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		called = append(called, "success")
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// OnLoginHandler calls the hook with the Login, assert that:
+	// - the hook runs before the success handler
+	handler := OnLoginHandler(onLogin, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithLogin(context.Background(), expectedLogin)
+	handler.ServeHTTPC(ctx, w, req)
+	assert.Equal(t, []string{"hook", "success"}, called)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestOnLoginHandler_HookError(t *testing.T) {
+	hookErr := errors.New("unable to publish login")
+	onLogin := func(ctx context.Context, login Login) error {
+		return hookErr
+	}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, hookErr, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// OnLoginHandler hook returns an error, assert that:
+	// - the login is aborted and the failure handler is called
+	handler := OnLoginHandler(onLogin, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithLogin(context.Background(), &Login{Provider: "example"})
+	handler.ServeHTTPC(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestOnLoginHandler_MissingLogin(t *testing.T) {
+	onLogin := func(ctx context.Context, login Login) error {
+		t.Errorf("unexpected call to onLogin hook")
+		return nil
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "oauth2: Context missing Login", gologin.ErrorFromContext(ctx).Error())
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := OnLoginHandler(onLogin, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTPC(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
@@ -0,0 +1,154 @@
+package oauth2
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Token store errors
+var (
+	ErrTokenNotFound   = errors.New("oauth2: token not found")
+	ErrTokenDecryption = errors.New("oauth2: unable to decrypt token")
+)
+
+// TokenStore persists Tokens by session ID.
+type TokenStore interface {
+	Save(ctx context.Context, sessionID string, token *oauth2.Token) error
+	Load(ctx context.Context, sessionID string) (*oauth2.Token, error)
+}
+
+// Cipher encrypts and decrypts stored Tokens. The additional data (the
+// session ID) is authenticated but not encrypted, so a ciphertext only
+// decrypts with the additional data it was encrypted with.
+type Cipher interface {
+	Encrypt(plaintext, additionalData []byte) ([]byte, error)
+	Decrypt(ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// Storage stores encrypted Token values by session ID, for example in a
+// database or cache. Get returns ErrTokenNotFound for unknown session IDs.
+type Storage interface {
+	Get(ctx context.Context, sessionID string) ([]byte, error)
+	Set(ctx context.Context, sessionID string, value []byte) error
+}
+
+// aesGCM is an AES-GCM Cipher which prefixes ciphertexts with a random nonce.
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher returns an AES-GCM Cipher with the given 16, 24, or 32 byte
+// key for AES-128, AES-192, or AES-256.
+func NewAESGCMCipher(key []byte) (Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCM{aead: aead}, nil
+}
+
+// Encrypt encrypts the plaintext with a random nonce and authenticates the
+// additional data.
+func (c *aesGCM) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt decrypts and authenticates the ciphertext and additional data.
+func (c *aesGCM) Decrypt(ciphertext, additionalData []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, ErrTokenDecryption
+	}
+	plaintext, err := c.aead.Open(nil, ciphertext[:size], ciphertext[size:], additionalData)
+	if err != nil {
+		return nil, ErrTokenDecryption
+	}
+	return plaintext, nil
+}
+
+// EncryptedTokenStore is a TokenStore which encrypts Tokens with a Cipher so
+// they are never stored in plaintext. Token Extra fields are not stored.
+type EncryptedTokenStore struct {
+	cipher  Cipher
+	storage Storage
+}
+
+// NewEncryptedTokenStore returns an EncryptedTokenStore which stores Tokens
+// encrypted by the Cipher in the Storage. If storage is nil, Tokens are
+// stored in memory.
+func NewEncryptedTokenStore(cipher Cipher, storage Storage) *EncryptedTokenStore {
+	if storage == nil {
+		storage = &memoryStorage{values: make(map[string][]byte)}
+	}
+	return &EncryptedTokenStore{cipher: cipher, storage: storage}
+}
+
+// Save encrypts and stores the Token for the session ID. The ciphertext is
+// bound to the session ID, so it cannot be loaded for another session.
+func (s *EncryptedTokenStore) Save(ctx context.Context, sessionID string, token *oauth2.Token) error {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := s.cipher.Encrypt(plaintext, []byte(sessionID))
+	if err != nil {
+		return err
+	}
+	return s.storage.Set(ctx, sessionID, ciphertext)
+}
+
+// Load loads and decrypts the Token for the session ID.
+func (s *EncryptedTokenStore) Load(ctx context.Context, sessionID string) (*oauth2.Token, error) {
+	ciphertext, err := s.storage.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := s.cipher.Decrypt(ciphertext, []byte(sessionID))
+	if err != nil {
+		return nil, err
+	}
+	token := new(oauth2.Token)
+	if err := json.Unmarshal(plaintext, token); err != nil {
+		return nil, ErrTokenDecryption
+	}
+	return token, nil
+}
+
+// memoryStorage is an in-memory Storage.
+type memoryStorage struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (s *memoryStorage) Get(ctx context.Context, sessionID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[sessionID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return value, nil
+}
+
+func (s *memoryStorage) Set(ctx context.Context, sessionID string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[sessionID] = value
+	return nil
+}
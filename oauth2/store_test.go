@@ -0,0 +1,71 @@
+package oauth2
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+var testTokenKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptedTokenStore(t *testing.T) {
+	cipher, err := NewAESGCMCipher(testTokenKey)
+	assert.Nil(t, err)
+	storage := &memoryStorage{values: make(map[string][]byte)}
+	store := NewEncryptedTokenStore(cipher, storage)
+	expectedToken := &oauth2.Token{
+		AccessToken:  "access_token",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh_token",
+		Expiry:       time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	assert.Nil(t, store.Save(context.Background(), "session-id", expectedToken))
+	// assert the token is not stored in plaintext
+	assert.False(t, bytes.Contains(storage.values["session-id"], []byte("access_token")))
+	token, err := store.Load(context.Background(), "session-id")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedToken.AccessToken, token.AccessToken)
+	assert.Equal(t, expectedToken.RefreshToken, token.RefreshToken)
+	assert.True(t, expectedToken.Expiry.Equal(token.Expiry))
+}
+
+func TestEncryptedTokenStore_WrongKey(t *testing.T) {
+	storage := &memoryStorage{values: make(map[string][]byte)}
+	cipher, _ := NewAESGCMCipher(testTokenKey)
+	assert.Nil(t, NewEncryptedTokenStore(cipher, storage).Save(context.Background(), "session-id", &oauth2.Token{AccessToken: "access_token"}))
+
+	wrongCipher, _ := NewAESGCMCipher([]byte("fedcba9876543210fedcba9876543210"))
+	token, err := NewEncryptedTokenStore(wrongCipher, storage).Load(context.Background(), "session-id")
+	assert.Nil(t, token)
+	assert.Equal(t, ErrTokenDecryption, err)
+}
+
+func TestEncryptedTokenStore_SwappedSessionID(t *testing.T) {
+	cipher, _ := NewAESGCMCipher(testTokenKey)
+	storage := &memoryStorage{values: make(map[string][]byte)}
+	store := NewEncryptedTokenStore(cipher, storage)
+	assert.Nil(t, store.Save(context.Background(), "victim-session", &oauth2.Token{AccessToken: "access_token"}))
+
+	// a ciphertext copied to another session ID does not decrypt
+	storage.values["attacker-session"] = storage.values["victim-session"]
+	token, err := store.Load(context.Background(), "attacker-session")
+	assert.Nil(t, token)
+	assert.Equal(t, ErrTokenDecryption, err)
+}
+
+func TestEncryptedTokenStore_NotFound(t *testing.T) {
+	cipher, _ := NewAESGCMCipher(testTokenKey)
+	token, err := NewEncryptedTokenStore(cipher, nil).Load(context.Background(), "unknown")
+	assert.Nil(t, token)
+	assert.Equal(t, ErrTokenNotFound, err)
+}
+
+func TestNewAESGCMCipher_InvalidKey(t *testing.T) {
+	_, err := NewAESGCMCipher([]byte("short"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/quasor/gologin/internal"
+)
+
+// DefaultClockSkew is the default maximum clock skew tolerated when validating
+// ID token exp, iat, and nbf claims, so hosts with small clock drift don't
+// reject valid tokens.
+const DefaultClockSkew = 60 * time.Second
+
+// Errors returned when validating ID token time claims.
+var (
+	ErrTokenExpired     = errors.New("oidc: ID token is expired")
+	ErrTokenNotYetValid = errors.New("oidc: ID token is not valid yet")
+	ErrTokenIssuedAt    = errors.New("oidc: ID token issued in the future")
+)
+
+// ErrInvalidAudience is returned when an ID token was not issued to the
+// client.
+var ErrInvalidAudience = errors.New("oidc: ID token has an invalid audience")
+
+// Claims are the standard ID token claims.
+type Claims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        Audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	Expiry          int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	NotBefore       int64    `json:"nbf"`
+	Nonce           string   `json:"nonce"`
+}
+
+// ClaimsValidator validates ID token claims beyond the standard checks, for
+// example to require a groups claim. It is called with all of the claims
+// after the signature, audience, and time checks pass, and its error denies
+// the login.
+type ClaimsValidator func(claims map[string]interface{}) error
+
+// ValidateClaims decodes the JSON ID token payload into a claims map and
+// calls the ClaimsValidator with it. A nil ClaimsValidator accepts any
+// claims.
+func ValidateClaims(payload []byte, validate ClaimsValidator) error {
+	if validate == nil {
+		return nil
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	return validate(claims)
+}
+
+// Audience is the aud claim, which may be a single string or an array of
+// strings.
+type Audience []string
+
+// UnmarshalJSON decodes a string or array of strings aud claim.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = Audience(multiple)
+	return nil
+}
+
+// Contains returns true if the audience includes the client ID.
+func (a Audience) Contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAudience checks that the aud claim contains the client ID. If the
+// token has multiple audiences, the azp claim must be present and, whenever
+// azp is present, it must be the client ID.
+// https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+func ValidateAudience(claims *Claims, clientID string) error {
+	if clientID == "" || !claims.Audience.Contains(clientID) {
+		return ErrInvalidAudience
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty == "" {
+		return ErrInvalidAudience
+	}
+	if claims.AuthorizedParty != "" && claims.AuthorizedParty != clientID {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// ValidateTime checks the exp, iat, and nbf claims against the current time,
+// tolerating up to skew of clock drift. The exp claim is required while iat
+// and nbf are optional.
+func ValidateTime(claims *Claims, skew time.Duration) error {
+	now := internal.DefaultClock.Now()
+	if !now.Before(time.Unix(claims.Expiry, 0).Add(skew)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(skew).Before(time.Unix(claims.NotBefore, 0)) {
+		return ErrTokenNotYetValid
+	}
+	if claims.IssuedAt != 0 && now.Add(skew).Before(time.Unix(claims.IssuedAt, 0)) {
+		return ErrTokenIssuedAt
+	}
+	return nil
+}
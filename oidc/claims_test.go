@@ -0,0 +1,112 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// withTestClock sets the internal DefaultClock to testNow and returns a func
+// to restore it.
+func withTestClock() func() {
+	original := internal.DefaultClock
+	internal.DefaultClock = internal.NewFakeClock(testNow)
+	return func() { internal.DefaultClock = original }
+}
+
+func TestValidateTime(t *testing.T) {
+	defer withTestClock()()
+	claims := &Claims{
+		Expiry:    testNow.Add(time.Hour).Unix(),
+		IssuedAt:  testNow.Unix(),
+		NotBefore: testNow.Unix(),
+	}
+	assert.Nil(t, ValidateTime(claims, DefaultClockSkew))
+}
+
+func TestValidateTime_ExpiredWithinSkew(t *testing.T) {
+	defer withTestClock()()
+	claims := &Claims{Expiry: testNow.Add(-30 * time.Second).Unix()}
+	assert.Nil(t, ValidateTime(claims, DefaultClockSkew))
+	assert.Equal(t, ErrTokenExpired, ValidateTime(claims, 0))
+}
+
+func TestValidateTime_ExpiredBeyondSkew(t *testing.T) {
+	defer withTestClock()()
+	claims := &Claims{Expiry: testNow.Add(-90 * time.Second).Unix()}
+	assert.Equal(t, ErrTokenExpired, ValidateTime(claims, DefaultClockSkew))
+	// missing exp
+	assert.Equal(t, ErrTokenExpired, ValidateTime(&Claims{}, DefaultClockSkew))
+}
+
+func TestValidateTime_FutureClaims(t *testing.T) {
+	defer withTestClock()()
+	exp := testNow.Add(time.Hour).Unix()
+	within := testNow.Add(30 * time.Second).Unix()
+	beyond := testNow.Add(90 * time.Second).Unix()
+	assert.Nil(t, ValidateTime(&Claims{Expiry: exp, NotBefore: within, IssuedAt: within}, DefaultClockSkew))
+	assert.Equal(t, ErrTokenNotYetValid, ValidateTime(&Claims{Expiry: exp, NotBefore: beyond}, DefaultClockSkew))
+	assert.Equal(t, ErrTokenIssuedAt, ValidateTime(&Claims{Expiry: exp, IssuedAt: beyond}, DefaultClockSkew))
+}
+
+func TestAudience_UnmarshalJSON(t *testing.T) {
+	claims := new(Claims)
+	assert.Nil(t, json.Unmarshal([]byte(`{"aud":"client-id"}`), claims))
+	assert.Equal(t, Audience{"client-id"}, claims.Audience)
+	assert.Nil(t, json.Unmarshal([]byte(`{"aud":["client-id","other"]}`), claims))
+	assert.Equal(t, Audience{"client-id", "other"}, claims.Audience)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"aud":42}`), claims))
+}
+
+func TestValidateAudience(t *testing.T) {
+	// single string aud
+	assert.Nil(t, ValidateAudience(&Claims{Audience: Audience{"client-id"}}, "client-id"))
+	// array aud with azp
+	claims := &Claims{Audience: Audience{"client-id", "other"}, AuthorizedParty: "client-id"}
+	assert.Nil(t, ValidateAudience(claims, "client-id"))
+}
+
+func TestValidateAudience_Invalid(t *testing.T) {
+	cases := []*Claims{
+		// wrong aud
+		{Audience: Audience{"other"}},
+		// missing aud
+		{},
+		// array aud without azp
+		{Audience: Audience{"client-id", "other"}},
+		// array aud with a different azp
+		{Audience: Audience{"client-id", "other"}, AuthorizedParty: "other"},
+		// single aud with a different azp
+		{Audience: Audience{"client-id"}, AuthorizedParty: "other"},
+	}
+	for _, claims := range cases {
+		assert.Equal(t, ErrInvalidAudience, ValidateAudience(claims, "client-id"))
+	}
+	assert.Equal(t, ErrInvalidAudience, ValidateAudience(&Claims{Audience: Audience{""}}, ""))
+}
+
+func TestValidateClaims(t *testing.T) {
+	payload := []byte(`{"sub": "248289761001", "groups": ["staff"], "hd": "example.com"}`)
+	requireDomain := func(claims map[string]interface{}) error {
+		if claims["hd"] != "example.com" {
+			return errors.New("app: wrong domain")
+		}
+		return nil
+	}
+	requireGroups := func(claims map[string]interface{}) error {
+		if _, ok := claims["roles"]; !ok {
+			return errors.New("app: missing roles claim")
+		}
+		return nil
+	}
+	assert.Nil(t, ValidateClaims(payload, nil))
+	assert.Nil(t, ValidateClaims(payload, requireDomain))
+	assert.Equal(t, errors.New("app: missing roles claim"), ValidateClaims(payload, requireGroups))
+	assert.NotNil(t, ValidateClaims([]byte("not-json"), requireDomain))
+}
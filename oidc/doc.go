@@ -0,0 +1,3 @@
+// Package oidc provides OpenID Connect ID token claim validation (time and
+// audience checks) shared by providers which verify ID tokens.
+package oidc
@@ -0,0 +1,49 @@
+package gologin
+
+import (
+	"regexp"
+)
+
+// RedactErrors controls whether the DefaultFailureHandler redacts secret
+// query parameter values (see RedactedParams) from the error messages it
+// responds with. Errors in the ctx are never modified, and AccessLogEntry
+// errors are always redacted. Defaults to true.
+var RedactErrors = true
+
+// RedactedParams are the query parameters whose values are redacted from
+// error messages, since errors (e.g. from url.Error) may include URLs.
+var RedactedParams = []string{"code", "access_token", "refresh_token", "id_token", "state", "client_secret"}
+
+const redacted = "REDACTED"
+
+var paramPattern = regexp.MustCompile(`([?&;\s"']|^)([A-Za-z_]+)=([^&;\s"']*)`)
+
+// redactErrorMessage returns the message of err with RedactedParams values
+// redacted, unless RedactErrors is false.
+func redactErrorMessage(err error) string {
+	if !RedactErrors {
+		return err.Error()
+	}
+	return RedactString(err.Error())
+}
+
+// RedactString replaces the values of RedactedParams query parameters in s
+// with "REDACTED".
+func RedactString(s string) string {
+	return paramPattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := paramPattern.FindStringSubmatch(match)
+		if !isRedactedParam(parts[2]) || parts[3] == "" {
+			return match
+		}
+		return parts[1] + parts[2] + "=" + redacted
+	})
+}
+
+func isRedactedParam(name string) bool {
+	for _, param := range RedactedParams {
+		if name == param {
+			return true
+		}
+	}
+	return false
+}
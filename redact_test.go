@@ -0,0 +1,62 @@
+package gologin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRedactString(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"no secrets here", "no secrets here"},
+		{"https://example.com/callback?code=abc123&state=d4e5f6", "https://example.com/callback?code=REDACTED&state=REDACTED"},
+		{`Get "https://api.example.com/me?access_token=EAAB&fields=id": EOF`, `Get "https://api.example.com/me?access_token=REDACTED&fields=id": EOF`},
+		{"refresh_token=r1 client_secret=s3cret", "refresh_token=REDACTED client_secret=REDACTED"},
+		// non-secret parameters and similarly named parameters are kept
+		{"?zipcode=94107&page=2", "?zipcode=94107&page=2"},
+		{"?code=", "?code="},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, RedactString(c.input))
+	}
+}
+
+func TestDefaultFailureHandler_Redacts(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://graph.example.com/me?access_token=EAAB1234", Err: errors.New("timeout")}
+	ctx := WithError(context.Background(), err)
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	DefaultFailureHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Get \"https://graph.example.com/me?access_token=REDACTED\": timeout\n", w.Body.String())
+	assert.NotContains(t, w.Body.String(), "EAAB1234")
+}
+
+func TestWithError_KeepsIdentity(t *testing.T) {
+	// assert errors are stored unchanged, even if their messages have secrets
+	expectedErr := &url.Error{Op: "Get", URL: "https://example.com/callback?code=abc123", Err: errors.New("timeout")}
+	ctx := WithError(context.Background(), expectedErr)
+	assert.True(t, expectedErr == ErrorFromContext(ctx))
+	otherErr := fmt.Errorf("some error")
+	ctx = WithError(context.Background(), otherErr)
+	assert.True(t, otherErr == ErrorFromContext(ctx))
+}
+
+func TestDefaultFailureHandler_RedactionDisabled(t *testing.T) {
+	RedactErrors = false
+	defer func() { RedactErrors = true }()
+	ctx := WithError(context.Background(), errors.New("?code=abc123"))
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	DefaultFailureHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "?code=abc123\n", w.Body.String())
+}
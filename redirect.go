@@ -0,0 +1,114 @@
+package gologin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// RedirectIfAuthenticated redirects requests for which isAuthenticated
+// returns true to the redirectURL. Otherwise, handling delegates to the next
+// handler.
+//
+// Wrap a LoginHandler to avoid re-running a login flow for visitors who
+// already have a valid session. Checking the session is left to the caller
+// since gologin is not a session system.
+func RedirectIfAuthenticated(isAuthenticated func(req *http.Request) bool, redirectURL string, next goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if isAuthenticated(req) {
+			Redirect(w, req, redirectURL, RedirectOptions{})
+			return
+		}
+		next.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// RedirectHeader is the response header which carries the redirect URL when
+// RedirectOptions.EmitHeader is set.
+const RedirectHeader = "X-Gologin-Redirect"
+
+// RedirectOptions configures how redirects are written.
+type RedirectOptions struct {
+	// EmitHeader responds 200 OK with the redirect URL in the
+	// X-Gologin-Redirect header and a JSON body {"redirect_url": "..."},
+	// instead of a 302 Found. Single page apps which complete login with
+	// fetch cannot follow redirects transparently and navigate themselves.
+	EmitHeader bool
+}
+
+// Redirect replies to the request with a redirect to redirectURL. By default
+// a 302 Found is written, see RedirectOptions to emit the URL for single page
+// apps instead.
+func Redirect(w http.ResponseWriter, req *http.Request, redirectURL string, options RedirectOptions) {
+	if !options.EmitHeader {
+		http.Redirect(w, req, redirectURL, http.StatusFound)
+		return
+	}
+	w.Header().Set(RedirectHeader, redirectURL)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"redirect_url": redirectURL})
+}
+
+// RedirectHandler returns a goji.Handler which redirects requests to the
+// redirectURL with a 302 Found. Use it as the success handler of a
+// CallbackHandler to send users on once login completes.
+func RedirectHandler(redirectURL string) goji.Handler {
+	return RedirectHandlerWithOptions(redirectURL, RedirectOptions{})
+}
+
+// RedirectHandlerWithOptions returns a goji.Handler which redirects requests
+// to the redirectURL as configured by the RedirectOptions.
+func RedirectHandlerWithOptions(redirectURL string, options RedirectOptions) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		Redirect(w, req, redirectURL, options)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// ErrUnsafeReturnURL is returned when a return URL (e.g. a "next" parameter
+// to redirect to after login) is too long, has a non-http(s) scheme, or
+// contains control characters.
+var ErrUnsafeReturnURL = errors.New("gologin: unsafe return URL")
+
+// MaxReturnURLLength is the maximum length of return URLs accepted by
+// ValidateReturnURL.
+var MaxReturnURLLength = 2048
+
+// ValidateReturnURL parses a return URL which a requester asked to be sent
+// to after login, so it can be carried through the login flow. Returns
+// ErrUnsafeReturnURL if the URL is longer than MaxReturnURLLength, contains
+// control characters or backslashes, cannot be parsed, is scheme-relative
+// (e.g. "//evil.example"), or has a scheme other than http or https.
+// Relative URLs (e.g. "/settings") are accepted.
+//
+// Checking that absolute URLs point to the app's own host is left to the
+// caller.
+func ValidateReturnURL(returnURL string) (*url.URL, error) {
+	if len(returnURL) > MaxReturnURLLength {
+		return nil, ErrUnsafeReturnURL
+	}
+	for i := 0; i < len(returnURL); i++ {
+		// browsers treat backslashes as slashes (e.g. "/\\evil.example")
+		if c := returnURL[i]; c < 0x20 || c == 0x7f || c == '\\' {
+			return nil, ErrUnsafeReturnURL
+		}
+	}
+	u, err := url.Parse(returnURL)
+	if err != nil {
+		return nil, ErrUnsafeReturnURL
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsafeReturnURL
+	}
+	if u.Scheme == "" && (u.Host != "" || strings.HasPrefix(returnURL, "//")) {
+		return nil, ErrUnsafeReturnURL
+	}
+	return u, nil
+}
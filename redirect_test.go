@@ -0,0 +1,124 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRedirectIfAuthenticated(t *testing.T) {
+	isAuthenticated := func(req *http.Request) bool {
+		_, err := req.Cookie("session")
+		return err == nil
+	}
+	handler := RedirectIfAuthenticated(isAuthenticated, "/profile", testutils.AssertSuccessNotCalled(t))
+
+	// RedirectIfAuthenticated with a session, assert that:
+	// - next handler is not called
+	// - requests are redirected to the redirect URL
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "any"})
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/profile", w.HeaderMap.Get("Location"))
+}
+
+func TestRedirectIfAuthenticated_Unauthenticated(t *testing.T) {
+	isAuthenticated := func(req *http.Request) bool {
+		return false
+	}
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "next handler called")
+	}
+	handler := RedirectIfAuthenticated(isAuthenticated, "/profile", goji.HandlerFunc(next))
+
+	// RedirectIfAuthenticated without a session, assert that:
+	// - next handler (e.g. LoginHandler) is called
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "next handler called", w.Body.String())
+}
+
+func TestRedirectHandler(t *testing.T) {
+	handler := RedirectHandler("/dashboard")
+
+	// RedirectHandler defaults to a 302 Found redirect
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/dashboard", w.HeaderMap.Get("Location"))
+	assert.Equal(t, "", w.HeaderMap.Get(RedirectHeader))
+}
+
+func TestRedirectHandlerWithOptions_EmitHeader(t *testing.T) {
+	handler := RedirectHandlerWithOptions("/dashboard?tab=1", RedirectOptions{EmitHeader: true})
+
+	// RedirectHandlerWithOptions with EmitHeader, assert that:
+	// - responds 200 OK without a Location
+	// - redirect URL is in the X-Gologin-Redirect header and JSON body
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.HeaderMap.Get("Location"))
+	assert.Equal(t, "/dashboard?tab=1", w.HeaderMap.Get(RedirectHeader))
+	assert.Equal(t, "application/json", w.HeaderMap.Get("Content-Type"))
+	assert.Equal(t, `{"redirect_url":"/dashboard?tab=1"}`+"\n", w.Body.String())
+}
+
+func TestValidateReturnURL(t *testing.T) {
+	for _, returnURL := range []string{"/settings?tab=profile", "https://app.example.com/settings", "http://localhost:8080/"} {
+		u, err := ValidateReturnURL(returnURL)
+		assert.Nil(t, err, returnURL)
+		if assert.NotNil(t, u, returnURL) {
+			assert.Equal(t, returnURL, u.String())
+		}
+	}
+}
+
+func TestValidateReturnURL_Unsafe(t *testing.T) {
+	cases := []string{
+		// oversized
+		"/" + strings.Repeat("a", MaxReturnURLLength),
+		// non-http(s) schemes
+		"javascript:alert(1)",
+		"JavaScript:alert(1)",
+		"data:text/html,<script>alert(1)</script>",
+		// control characters
+		"/settings\r\nSet-Cookie: a=b",
+		"/settings\x00",
+		"/settings\x7f",
+		// unparseable
+		"http://[::1",
+		// scheme-relative
+		"//evil.example/x",
+		"///evil.example",
+		// backslashes, which browsers treat as slashes
+		"/\\evil.example",
+		"\\\\evil.example",
+	}
+	for _, returnURL := range cases {
+		u, err := ValidateReturnURL(returnURL)
+		assert.Nil(t, u, returnURL)
+		assert.Equal(t, ErrUnsafeReturnURL, err, returnURL)
+	}
+}
+
+func TestValidateReturnURL_MaxLength(t *testing.T) {
+	defer func(max int) { MaxReturnURLLength = max }(MaxReturnURLLength)
+	MaxReturnURLLength = 10
+	_, err := ValidateReturnURL("/0123456789")
+	assert.Equal(t, ErrUnsafeReturnURL, err)
+	_, err = ValidateReturnURL("/012345678")
+	assert.Nil(t, err)
+}
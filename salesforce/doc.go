@@ -0,0 +1,5 @@
+// Package salesforce provides Salesforce OAuth2 login and callback handlers.
+//
+// Salesforce token responses carry the org's instance_url and an id URL for
+// the user's identity resource, which is fetched instead of a fixed host.
+package salesforce
@@ -0,0 +1,161 @@
+package salesforce
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"user_id": "005x0000001", "organization_id": "00Dx0000002", "username": "marc@acme.example", "email": "marc@acme.example", "display_name": "Marc Cloud"}`
+	expectedUser := &User{
+		UserID:         "005x0000001",
+		OrganizationID: "00Dx0000002",
+		Username:       "marc@acme.example",
+		Email:          "marc@acme.example",
+		DisplayName:    "Marc Cloud",
+		InstanceURL:    "https://acme.my.salesforce.com",
+	}
+	proxyClient, server := newSalesforceTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		salesforceUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, salesforceUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler with a token carrying id and instance_url, assert that:
+	// - the identity is fetched from the token's id URL
+	// - the User, with the instance URL, is added to the ctx
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestSalesforceHandler_MissingIDURL(t *testing.T) {
+	tokens := []*oauth2.Token{
+		{AccessToken: "any-token"},
+		(&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id": "http://login.salesforce.com/id/00D/005"}),
+	}
+	for _, token := range tokens {
+		ctx := oauth2Login.WithToken(context.Background(), token)
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, ErrUnableToGetSalesforceUser, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// SalesforceHandler without an https id URL extra, assert that:
+		// - failure handler is called
+		salesforceHandler := salesforceHandler(&oauth2.Config{}, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		salesforceHandler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestSalesforceHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// SalesforceHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	salesforceHandler := salesforceHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	salesforceHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestSalesforceHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Salesforce Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id": "https://login.salesforce.com/id/00Dx0000002/005x0000001",
+	})
+	ctx = oauth2Login.WithToken(ctx, token)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetSalesforceUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// SalesforceHandler cannot get Salesforce User, assert that:
+	// - failure handler is called
+	// - error cannot get Salesforce User added to the failure handler ctx
+	salesforceHandler := salesforceHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	salesforceHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{UserID: "005x0000001"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetSalesforceUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetSalesforceUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetSalesforceUser, validateResponse(&User{}, validResponse, nil))
+}
+
+func TestSalesforceHandler_UserInfoURL(t *testing.T) {
+	server := testutils.NewTestServerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/id/00Dx0000002/005x0000001", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"user_id": "005x0000001", "organization_id": "00Dx0000002", "username": "alyssa@example.com"}`)
+	})
+	defer server.Close()
+	// the userinfo URL points at the local test server directly
+	ctx := gologin.WithUserInfoURL(context.Background(), server.URL+"/id/00Dx0000002/005x0000001")
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id": "https://login.salesforce.com/id/00Dx0000002/005x0000001",
+	})
+	ctx = oauth2Login.WithToken(ctx, token)
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "005x0000001", user.UserID)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := salesforceHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
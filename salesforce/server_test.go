@@ -0,0 +1,35 @@
+package salesforce
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newSalesforceTestServer returns a new httptest.Server which mocks the
+// Salesforce token endpoint and an org identity resource, and a client which
+// proxies requests to the server. The identity resource responds with the
+// given json data. The caller must close the server.
+func newSalesforceTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/services/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{
+			"access_token": "00Dx!AQ4AQ",
+			"token_type": "Bearer",
+			"instance_url": "https://acme.my.salesforce.com",
+			"id": "https://login.salesforce.com/id/00Dx0000002/005x0000001"
+		}`)
+	})
+	mux.HandleFunc("/id/00Dx0000002/005x0000001", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer 00Dx!AQ4AQ" {
+			http.Error(w, "Bad_OAuth_Token", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
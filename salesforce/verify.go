@@ -0,0 +1,78 @@
+package salesforce
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+// Endpoint is the Salesforce production OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://login.salesforce.com/services/oauth2/authorize",
+	TokenURL: "https://login.salesforce.com/services/oauth2/token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("salesforce", Endpoint)
+}
+
+// SandboxEndpoint is the Salesforce sandbox OAuth2 endpoint.
+var SandboxEndpoint = oauth2.Endpoint{
+	AuthURL:  "https://test.salesforce.com/services/oauth2/authorize",
+	TokenURL: "https://test.salesforce.com/services/oauth2/token",
+}
+
+// User is a Salesforce user identity.
+type User struct {
+	UserID         string `json:"user_id"`
+	OrganizationID string `json:"organization_id"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	DisplayName    string `json:"display_name"`
+	// InstanceURL is the org's API base URL from the token response
+	InstanceURL string `json:"-"`
+}
+
+// Identity returns the Salesforce identity keyed by the organization and user
+// IDs, since user IDs are only unique within an org.
+func (u *User) Identity() gologin.Identity {
+	identity := gologin.Identity{Provider: Provider.Name}
+	if u.UserID != "" {
+		identity.ID = u.OrganizationID + "/" + u.UserID
+	}
+	return identity
+}
+
+// EmailAddress returns the Salesforce user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// client is a Salesforce client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL overrides the identity URL, if set
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).ResponseDecoder(internal.JSONDecoder{})
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Identity gets the User from the identity URL given as the token's "id".
+// https://help.salesforce.com/s/articleView?id=sf.remoteaccess_using_openid.htm
+func (c *client) Identity(idURL string) (*User, *http.Response, error) {
+	user := new(User)
+	if c.userInfoURL != "" {
+		idURL = c.userInfoURL
+	}
+	resp, err := c.sling.New().Get(idURL).ReceiveSuccess(user)
+	return user, resp, err
+}
@@ -0,0 +1,8 @@
+// Package session provides server-side login sessions for users who logged
+// in with a gologin provider.
+//
+// A Manager issues a session cookie holding a random session ID after login
+// and reads the logged-in user back out of the Store on later requests.
+// gologin does not persist sessions itself; provide a Store backed by your
+// database or cache. For development, a MemoryStore keeps sessions in memory.
+package session
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// IssueHandler is a ContextHandler that reads the provider user from the ctx
+// and issues a Session for it. If successful, the success handler is called.
+// Otherwise, the failure handler is called.
+//
+// Use it as (or chain it before) the success handler of a provider
+// CallbackHandler.
+func (m *Manager) IssueHandler(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := gologin.UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		if _, err := m.Issue(ctx, w, req, user); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// RequireLogin protects the next handler from requests without a Session.
+// Requests with a Session have its user added to the ctx with
+// gologin.WithUser. Requests without one, or whose Session is bound to a
+// different client (see Options), are redirected to the redirectURL
+// (e.g. a login page) or, if it is empty, receive a 401 Unauthorized.
+func (m *Manager) RequireLogin(redirectURL string, next goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		session, err := m.Get(ctx, req)
+		if err != nil {
+			if redirectURL != "" {
+				http.Redirect(w, req, redirectURL, http.StatusFound)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		ctx = gologin.WithUser(ctx, session.User)
+		next.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
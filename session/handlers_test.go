@@ -0,0 +1,98 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestIssueHandler(t *testing.T) {
+	store := newMapStore()
+	m := New(store, DefaultCookieConfig)
+	expectedUser := &testUser{ID: "42"}
+	ctx := gologin.WithUser(context.Background(), expectedUser)
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := m.IssueHandler(goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	// assert a session cookie was set and the user stored
+	cookies := readCookies(w)
+	if assert.Len(t, cookies, 1) {
+		session, err := store.Get(ctx, cookies[0].Value)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, session.User)
+	}
+}
+
+func TestIssueHandler_MissingUser(t *testing.T) {
+	m := New(newMapStore(), DefaultCookieConfig)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "Context missing provider user", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := m.IssueHandler(testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestRequireLogin(t *testing.T) {
+	m := New(newMapStore(), DefaultCookieConfig)
+	expectedUser := &testUser{ID: "42"}
+	req := issueSession(t, m, expectedUser)
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := gologin.UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, user)
+		fmt.Fprintf(w, "next handler called")
+	}
+	handler := m.RequireLogin("", goji.HandlerFunc(next))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "next handler called", w.Body.String())
+}
+
+func TestRequireLogin_Unauthorized(t *testing.T) {
+	m := New(newMapStore(), DefaultCookieConfig)
+	handler := m.RequireLogin("", testutils.AssertSuccessNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequireLogin_Redirect(t *testing.T) {
+	m := New(newMapStore(), DefaultCookieConfig)
+	handler := m.RequireLogin("/login", testutils.AssertSuccessNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login", w.HeaderMap.Get("Location"))
+}
+
+func TestRequireLogin_BindingMismatch(t *testing.T) {
+	m := NewWithOptions(newMapStore(), DefaultCookieConfig, Options{BindUserAgent: true})
+	req := issueSession(t, m, &testUser{ID: "42"})
+	req.Header.Set("User-Agent", "curl/7.64.1")
+	handler := m.RequireLogin("", testutils.AssertSuccessNotCalled(t))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
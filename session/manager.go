@@ -0,0 +1,168 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/net/context"
+)
+
+// Manager issues and reads login sessions, storing them in a Store and their
+// IDs in session cookies.
+type Manager struct {
+	store   Store
+	config  gologin.CookieConfig
+	options Options
+}
+
+// Options configures a Manager.
+type Options struct {
+	// BindIP binds sessions to the client IP (the req.RemoteAddr host), so
+	// requests from another IP are rejected. Mobile clients change IPs
+	// frequently, so enable it with care. Behind a proxy, use middleware
+	// which sets RemoteAddr to the real client IP.
+	BindIP bool
+	// BindUserAgent binds sessions to the client User-Agent header.
+	BindUserAgent bool
+	// MaxAge is the age after which Get rejects a Session, regardless of
+	// its cookie. Defaults to the CookieConfig MaxAge, if positive.
+	MaxAge time.Duration
+}
+
+// New returns a Manager which stores Sessions in the Store and issues session
+// cookies with the CookieConfig. New panics if the CookieConfig is invalid.
+func New(store Store, config gologin.CookieConfig) *Manager {
+	return NewWithOptions(store, config, Options{})
+}
+
+// NewWithOptions returns a Manager like New, configured by the given Options.
+func NewWithOptions(store Store, config gologin.CookieConfig, options Options) *Manager {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	return &Manager{store: store, config: config, options: options}
+}
+
+// Issue saves a new Session for the user and sets its session cookie.
+func (m *Manager) Issue(ctx context.Context, w http.ResponseWriter, req *http.Request, user interface{}) (*Session, error) {
+	session := &Session{
+		ID:        randomID(),
+		User:      user,
+		CreatedAt: internal.DefaultClock.Now(),
+		Binding:   m.binding(req),
+	}
+	value, err := internal.EncodeCookieValue(m.config, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Save(ctx, session); err != nil {
+		return nil, err
+	}
+	http.SetCookie(w, internal.NewRequestCookie(m.config, req, value))
+	return session, nil
+}
+
+// Get returns the Session of the request's session cookie or ErrNoSession.
+// If the Session is older than the max age, ErrSessionExpired is returned.
+// If the Session is bound to client attributes which changed,
+// ErrSessionBindingMismatch is returned.
+func (m *Manager) Get(ctx context.Context, req *http.Request) (*Session, error) {
+	session, err := m.lookup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if maxAge := m.maxAge(); maxAge > 0 && internal.DefaultClock.Now().Sub(session.CreatedAt) > maxAge {
+		return nil, ErrSessionExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(session.Binding), []byte(m.binding(req))) != 1 {
+		return nil, ErrSessionBindingMismatch
+	}
+	return session, nil
+}
+
+// lookup returns the Session of the request's session cookie, without
+// checking its binding, or ErrNoSession.
+func (m *Manager) lookup(ctx context.Context, req *http.Request) (*Session, error) {
+	cookie, err := req.Cookie(internal.CookieName(m.config))
+	if err != nil {
+		return nil, ErrNoSession
+	}
+	id, err := internal.DecodeCookieValue(m.config, cookie.Value)
+	if err != nil || id == "" {
+		return nil, ErrNoSession
+	}
+	return m.store.Get(ctx, id)
+}
+
+// UserFromRequest returns the logged-in user of the request's session or
+// ErrNoSession.
+func (m *Manager) UserFromRequest(req *http.Request) (interface{}, error) {
+	session, err := m.Get(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	return session.User, nil
+}
+
+// Destroy deletes the request's Session, if any, and expires its session
+// cookie.
+func (m *Manager) Destroy(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	session, err := m.lookup(ctx, req)
+	if err == nil {
+		if err := m.store.Delete(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	expired := m.config
+	expired.MaxAge = -1
+	http.SetCookie(w, internal.NewRequestCookie(expired, req, ""))
+	return nil
+}
+
+// maxAge returns the Options MaxAge or the CookieConfig MaxAge. Returns 0 if
+// sessions do not expire.
+func (m *Manager) maxAge() time.Duration {
+	if m.options.MaxAge > 0 {
+		return m.options.MaxAge
+	}
+	if m.config.MaxAge > 0 {
+		return time.Duration(m.config.MaxAge) * time.Second
+	}
+	return 0
+}
+
+// binding returns a hash of the request's client attributes selected by the
+// Options or "" if sessions are not bound.
+func (m *Manager) binding(req *http.Request) string {
+	if !m.options.BindIP && !m.options.BindUserAgent {
+		return ""
+	}
+	h := sha256.New()
+	if m.options.BindIP {
+		ip, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			ip = req.RemoteAddr
+		}
+		io.WriteString(h, "ip:"+ip+"\n")
+	}
+	if m.options.BindUserAgent {
+		io.WriteString(h, "ua:"+req.UserAgent()+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// randomID returns a base64url encoded random 32 byte session ID.
+func randomID() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,217 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// mapStore is a Store for tests.
+type mapStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{sessions: make(map[string]*Session)}
+}
+
+func (s *mapStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNoSession
+	}
+	return session, nil
+}
+
+func (s *mapStore) Save(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *mapStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+type testUser struct {
+	ID string
+}
+
+// issueSession issues a session for the user and returns a request carrying
+// its session cookie.
+func issueSession(t *testing.T, m *Manager, user interface{}) *http.Request {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	_, err := m.Issue(context.Background(), w, req, user)
+	assert.Nil(t, err)
+	next, _ := http.NewRequest("GET", "/", nil)
+	for _, cookie := range readCookies(w) {
+		next.AddCookie(cookie)
+	}
+	return next
+}
+
+func readCookies(w *httptest.ResponseRecorder) []*http.Cookie {
+	resp := http.Response{Header: w.HeaderMap}
+	return resp.Cookies()
+}
+
+func TestManager_UserFromRequest(t *testing.T) {
+	store := newMapStore()
+	m := New(store, DefaultCookieConfig)
+	expectedUser := &testUser{ID: "42"}
+	req := issueSession(t, m, expectedUser)
+
+	user, err := m.UserFromRequest(req)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, user)
+	assert.Len(t, store.sessions, 1)
+}
+
+func TestManager_UserFromRequest_NoSession(t *testing.T) {
+	m := New(newMapStore(), DefaultCookieConfig)
+	req, _ := http.NewRequest("GET", "/", nil)
+	user, err := m.UserFromRequest(req)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrNoSession, err)
+
+	// unknown session ID
+	req.AddCookie(&http.Cookie{Name: DefaultCookieConfig.Name, Value: "unknown"})
+	user, err = m.UserFromRequest(req)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrNoSession, err)
+}
+
+func TestManager_Destroy(t *testing.T) {
+	store := newMapStore()
+	m := New(store, DefaultCookieConfig)
+	req := issueSession(t, m, &testUser{ID: "42"})
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, m.Destroy(context.Background(), w, req))
+	assert.Len(t, store.sessions, 0)
+	cookies := readCookies(w)
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, DefaultCookieConfig.Name, cookies[0].Name)
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	}
+	_, err := m.UserFromRequest(req)
+	assert.Equal(t, ErrNoSession, err)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	config := DefaultCookieConfig
+	config.UseHostPrefix = true
+	config.Domain = "example.com"
+	assert.Panics(t, func() {
+		New(newMapStore(), config)
+	})
+	assert.Equal(t, gologin.ErrHostPrefixDomain, config.Validate())
+}
+
+func TestManager_Binding(t *testing.T) {
+	m := NewWithOptions(newMapStore(), DefaultCookieConfig, Options{BindIP: true, BindUserAgent: true})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:52100"
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	expectedUser := &testUser{ID: "42"}
+	session, err := m.Issue(context.Background(), w, req, expectedUser)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, session.Binding)
+	cookies := readCookies(w)
+
+	cases := []struct {
+		remoteAddr  string
+		userAgent   string
+		expectedErr error
+	}{
+		// matching binding (the client port may change)
+		{"203.0.113.7:52101", "Mozilla/5.0", nil},
+		// changed IP
+		{"198.51.100.2:52100", "Mozilla/5.0", ErrSessionBindingMismatch},
+		// changed User-Agent
+		{"203.0.113.7:52100", "curl/7.64.1", ErrSessionBindingMismatch},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.remoteAddr
+		req.Header.Set("User-Agent", c.userAgent)
+		req.AddCookie(cookies[0])
+		user, err := m.UserFromRequest(req)
+		assert.Equal(t, c.expectedErr, err)
+		if c.expectedErr == nil {
+			assert.Equal(t, expectedUser, user)
+		} else {
+			assert.Nil(t, user)
+		}
+	}
+}
+
+func TestManager_BindUserAgentOnly(t *testing.T) {
+	m := NewWithOptions(newMapStore(), DefaultCookieConfig, Options{BindUserAgent: true})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:52100"
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	_, err := m.Issue(context.Background(), w, req, &testUser{ID: "42"})
+	assert.Nil(t, err)
+
+	// a changed IP is allowed when only the User-Agent is bound
+	next, _ := http.NewRequest("GET", "/", nil)
+	next.RemoteAddr = "198.51.100.2:52100"
+	next.Header.Set("User-Agent", "Mozilla/5.0")
+	next.AddCookie(readCookies(w)[0])
+	_, err = m.UserFromRequest(next)
+	assert.Nil(t, err)
+}
+
+func TestManager_Expired(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	m := New(newMapStore(), DefaultCookieConfig)
+	expectedUser := &testUser{ID: "42"}
+	req := issueSession(t, m, expectedUser)
+
+	// a session within the cookie MaxAge is valid
+	clock.Advance(7*24*time.Hour - time.Second)
+	user, err := m.UserFromRequest(req)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, user)
+
+	// a session older than the cookie MaxAge is rejected, even if the
+	// client kept sending the cookie
+	clock.Advance(2 * time.Second)
+	user, err = m.UserFromRequest(req)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrSessionExpired, err)
+}
+
+func TestManager_ExpiredMaxAgeOption(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	m := NewWithOptions(newMapStore(), DefaultCookieConfig, Options{MaxAge: time.Hour})
+	req := issueSession(t, m, &testUser{ID: "42"})
+
+	clock.Advance(30 * time.Minute)
+	_, err := m.UserFromRequest(req)
+	assert.Nil(t, err)
+
+	// the Options MaxAge takes precedence over the cookie MaxAge
+	clock.Advance(time.Hour)
+	_, err = m.UserFromRequest(req)
+	assert.Equal(t, ErrSessionExpired, err)
+}
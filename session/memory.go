@@ -0,0 +1,152 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/net/context"
+)
+
+// MaxMemorySessions caps the number of sessions a MemoryStore created by
+// NewMemoryStore holds. When full, saving a new session evicts the session
+// saved longest ago.
+var MaxMemorySessions = 10000
+
+// MemoryStore is an in-memory Store which expires sessions after a TTL. It is
+// intended for development and tests only: sessions are lost on restart and
+// are not shared between server instances, so use a distributed Store (e.g.
+// backed by Redis) in production.
+type MemoryStore struct {
+	ttl         time.Duration
+	maxSessions int
+	mu          sync.Mutex
+	sessions    map[string]memoryEntry
+	stop        chan struct{}
+	stopOnce    sync.Once
+	evicting    sync.WaitGroup
+}
+
+// memoryEntry is a stored Session and when it was saved.
+type memoryEntry struct {
+	session *Session
+	saved   time.Time
+}
+
+// NewMemoryStore returns a MemoryStore whose sessions expire ttl after they
+// are saved. Expired sessions are evicted in the background, call Close to
+// stop eviction. If ttl is not positive, sessions don't expire.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		ttl:         ttl,
+		maxSessions: MaxMemorySessions,
+		sessions:    make(map[string]memoryEntry),
+		stop:        make(chan struct{}),
+	}
+	if ttl > 0 {
+		s.evicting.Add(1)
+		go s.evictLoop(ttl)
+	}
+	return s
+}
+
+// Get returns the Session with the given ID or ErrNoSession if it is unknown
+// or expired.
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNoSession
+	}
+	if s.expired(entry, internal.DefaultClock.Now()) {
+		delete(s.sessions, id)
+		return nil, ErrNoSession
+	}
+	return entry.session, nil
+}
+
+// Save stores the Session until the TTL elapses.
+func (s *MemoryStore) Save(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := internal.DefaultClock.Now()
+	if _, ok := s.sessions[session.ID]; !ok && len(s.sessions) >= s.maxSessions {
+		s.evictExpired(now)
+		if len(s.sessions) >= s.maxSessions {
+			s.evictOldest()
+		}
+	}
+	s.sessions[session.ID] = memoryEntry{session: session, saved: now}
+	return nil
+}
+
+// Delete removes the Session with the given ID, if any.
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired sessions
+// which have not been evicted yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// Close stops the background eviction of expired sessions and waits for it
+// to return.
+func (s *MemoryStore) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.evicting.Wait()
+}
+
+// evictLoop evicts expired sessions every interval until the store is
+// closed.
+func (s *MemoryStore) evictLoop(interval time.Duration) {
+	defer s.evicting.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			s.evictExpired(internal.DefaultClock.Now())
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// evictExpired deletes expired sessions. The caller must hold the lock.
+func (s *MemoryStore) evictExpired(now time.Time) {
+	for id, entry := range s.sessions {
+		if s.expired(entry, now) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// evictOldest deletes the session saved longest ago. The caller must hold
+// the lock.
+func (s *MemoryStore) evictOldest() {
+	var oldestID string
+	var oldest time.Time
+	for id, entry := range s.sessions {
+		if oldestID == "" || entry.saved.Before(oldest) {
+			oldestID, oldest = id, entry.saved
+		}
+	}
+	delete(s.sessions, oldestID)
+}
+
+// expired returns true if the entry has expired at the given time.
+func (s *MemoryStore) expired(entry memoryEntry, now time.Time) bool {
+	return s.ttl > 0 && !now.Before(entry.saved.Add(s.ttl))
+}
@@ -0,0 +1,139 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin/internal"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// useFakeClock sets the DefaultClock to a FakeClock and returns it with a
+// func which restores the original clock.
+func useFakeClock() (*internal.FakeClock, func()) {
+	original := internal.DefaultClock
+	clock := internal.NewFakeClock(time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC))
+	internal.DefaultClock = clock
+	return clock, func() { internal.DefaultClock = original }
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	ctx := context.Background()
+	session := &Session{ID: "abc", User: &testUser{ID: "1"}}
+
+	assert.Nil(t, store.Save(ctx, session))
+	got, err := store.Get(ctx, "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, session, got)
+
+	assert.Nil(t, store.Delete(ctx, "abc"))
+	_, err = store.Get(ctx, "abc")
+	assert.Equal(t, ErrNoSession, err)
+}
+
+func TestMemoryStore_Expire(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	ctx := context.Background()
+
+	store.Save(ctx, &Session{ID: "abc"})
+	clock.Advance(59 * time.Minute)
+	_, err := store.Get(ctx, "abc")
+	assert.Nil(t, err)
+	// assert expired sessions are not returned and are removed
+	clock.Advance(time.Minute)
+	_, err = store.Get(ctx, "abc")
+	assert.Equal(t, ErrNoSession, err)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestMemoryStore_EvictExpired(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	ctx := context.Background()
+
+	store.Save(ctx, &Session{ID: "old"})
+	clock.Advance(30 * time.Minute)
+	store.Save(ctx, &Session{ID: "new"})
+	clock.Advance(30 * time.Minute)
+	store.mu.Lock()
+	store.evictExpired(internal.DefaultClock.Now())
+	store.mu.Unlock()
+	assert.Equal(t, 1, store.Len())
+	_, err := store.Get(ctx, "new")
+	assert.Nil(t, err)
+}
+
+func TestMemoryStore_MaxSessions(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	store.maxSessions = 2
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		store.Save(ctx, &Session{ID: id})
+		clock.Advance(time.Minute)
+	}
+	// assert the oldest session was evicted to stay within the cap
+	assert.Equal(t, 2, store.Len())
+	_, err := store.Get(ctx, "a")
+	assert.Equal(t, ErrNoSession, err)
+	_, err = store.Get(ctx, "c")
+	assert.Nil(t, err)
+}
+
+func TestMemoryStore_NoTTL(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	store := NewMemoryStore(0)
+	defer store.Close()
+	ctx := context.Background()
+
+	store.Save(ctx, &Session{ID: "abc"})
+	clock.Advance(365 * 24 * time.Hour)
+	_, err := store.Get(ctx, "abc")
+	assert.Nil(t, err)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	// short ttl so background eviction runs concurrently too
+	store := NewMemoryStore(time.Millisecond)
+	defer store.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := fmt.Sprintf("%d-%d", i, j)
+				store.Save(ctx, &Session{ID: id})
+				store.Get(ctx, id)
+				store.Delete(ctx, id)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestMemoryStore_Manager(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	manager := New(store, DefaultCookieConfig)
+	req := issueSession(t, manager, &testUser{ID: "1"})
+	user, err := manager.UserFromRequest(req)
+	assert.Nil(t, err)
+	assert.Equal(t, &testUser{ID: "1"}, user)
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// Session errors
+var (
+	ErrNoSession              = errors.New("session: no session")
+	ErrSessionBindingMismatch = errors.New("session: session binding does not match the client")
+	ErrSessionExpired         = errors.New("session: session is older than its max age")
+)
+
+// DefaultCookieConfig configures session cookies which last a week.
+var DefaultCookieConfig = gologin.CookieConfig{
+	Name:     "gologin-session",
+	Path:     "/",
+	MaxAge:   7 * 24 * 60 * 60, // 1 week
+	HTTPOnly: true,
+	Secure:   true, // HTTPS only
+}
+
+// Session is a login session.
+type Session struct {
+	// ID is the random session ID stored in the session cookie
+	ID string
+	// User is the provider user who logged in
+	User interface{}
+	// CreatedAt is when the session was issued
+	CreatedAt time.Time
+	// Binding is a hash of the client attributes the session is bound to, if
+	// any (see Options)
+	Binding string
+}
+
+// Store stores Sessions by ID. Get returns ErrNoSession for unknown IDs.
+type Store interface {
+	Get(ctx context.Context, id string) (*Session, error)
+	Save(ctx context.Context, session *Session) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,30 @@
+package shopify
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	shopKey key = iota
+)
+
+// WithShop returns a copy of ctx that stores the Shopify Shop.
+func WithShop(ctx context.Context, shop *Shop) context.Context {
+	ctx = gologin.WithUser(ctx, shop)
+	return context.WithValue(ctx, shopKey, shop)
+}
+
+// ShopFromContext returns the Shopify Shop from the ctx.
+func ShopFromContext(ctx context.Context) (*Shop, error) {
+	shop, ok := ctx.Value(shopKey).(*Shop)
+	if !ok {
+		return nil, fmt.Errorf("shopify: Context missing Shopify Shop")
+	}
+	return shop, nil
+}
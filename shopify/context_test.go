@@ -0,0 +1,32 @@
+package shopify
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextShop(t *testing.T) {
+	expectedShop := &Shop{ID: 690933842, Name: "Super Toys"}
+	ctx := WithShop(context.Background(), expectedShop)
+	shop, err := ShopFromContext(ctx)
+	assert.Equal(t, expectedShop, shop)
+	assert.Nil(t, err)
+}
+
+func TestContextShop_Error(t *testing.T) {
+	shop, err := ShopFromContext(context.Background())
+	assert.Nil(t, shop)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "shopify: Context missing Shopify Shop", err.Error())
+	}
+}
+
+func TestShop_Identity(t *testing.T) {
+	ctx := WithShop(context.Background(), &Shop{ID: 690933842})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "shopify", ID: "690933842"}, identity)
+}
@@ -0,0 +1,7 @@
+// Package shopify provides Shopify OAuth2 login and callback handlers.
+//
+// Shopify OAuth is shop-scoped: the authorize, token, and Admin API hosts are
+// the shop's own {shop}.myshopify.com domain. Use Endpoint(shop) as the
+// oauth2.Config Endpoint. Callback requests are signed by Shopify with the
+// app secret and are verified before the auth code is exchanged.
+package shopify
@@ -0,0 +1,154 @@
+package shopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Shopify login errors
+var (
+	ErrUnableToGetShopifyShop = errors.New("shopify: unable to get Shopify Shop")
+	ErrShopifyHMACInvalid     = errors.New("shopify: invalid callback hmac")
+)
+
+// Provider is the Shopify OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{Name: "shopify", CallbackHandler: CallbackHandler}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Shopify login requests by reading the state value
+// from the ctx and redirecting requests to the shop's AuthURL with that state
+// value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Shopify redirection URI requests and adds the
+// Shopify access token and Shop to the ctx. The callback query hmac is
+// verified with the config ClientSecret before the auth code is exchanged.
+// If authentication succeeds, handling delegates to the success handler,
+// otherwise to the failure handler.
+//
+// The config Endpoint must be the shop's Endpoint.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = shopifyHandler(config, success, failure)
+	success = oauth2Login.CallbackHandler(config, success, failure)
+	return HMACHandler(config.ClientSecret, success, failure)
+}
+
+// HMACHandler verifies the hmac parameter Shopify adds to requests it
+// redirects to the app, using the app's client secret. If valid, handling
+// delegates to the success handler, otherwise ErrShopifyHMACInvalid is added
+// to the ctx and the failure handler is called.
+// https://shopify.dev/docs/apps/auth/oauth/getting-started#step-2-verify-the-installation-request
+func HMACHandler(clientSecret string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if !validHMAC(clientSecret, req.URL.Query()) {
+			ctx = gologin.WithError(ctx, ErrShopifyHMACInvalid)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// shopifyHandler is a ContextHandler that gets the OAuth2 Token from the ctx
+// to get the corresponding Shopify Shop. If successful, the Shop is added to
+// the ctx and the success handler is called. Otherwise, the failure handler is
+// called.
+func shopifyHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		shopifyClient, err := newClient(httpClient, config.Endpoint, token.AccessToken, gologin.UserInfoURLFromContext(ctx))
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		shopifyClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		shop, resp, err := shopifyClient.Shop()
+		err = validateResponse(shop, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithShop(ctx, shop)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validHMAC returns true if the query's hmac parameter is the hex encoded
+// HMAC-SHA256 of the remaining parameters, sorted and joined as "k=v" pairs
+// with "&".
+func validHMAC(clientSecret string, query url.Values) bool {
+	expected, err := hex.DecodeString(query.Get("hmac"))
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	mac.Write([]byte(hmacMessage(query)))
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// hmacMessage returns the message Shopify signs from the query parameters.
+func hmacMessage(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		if name == "hmac" || name == "signature" {
+			continue
+		}
+		pairs = append(pairs, name+"="+strings.Join(values, ","))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// validateResponse returns an error if the given Shopify Shop, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(shop *Shop, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetShopifyShop
+	}
+	if shop == nil || shop.ID == 0 {
+		return ErrUnableToGetShopifyShop
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package shopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const testShopJSON = `{"shop": {"id": 690933842, "name": "Super Toys", "email": "owner@supertoys.example", "domain": "supertoys.example", "myshopify_domain": "super-toys.myshopify.com", "shop_owner": "Steve Jobs"}}`
+
+var testShop = &Shop{
+	ID:              690933842,
+	Name:            "Super Toys",
+	Email:           "owner@supertoys.example",
+	Domain:          "supertoys.example",
+	MyshopifyDomain: "super-toys.myshopify.com",
+	ShopOwner:       "Steve Jobs",
+}
+
+// signQuery adds the Shopify hmac parameter to the query.
+func signQuery(secret string, query url.Values) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(hmacMessage(query)))
+	query.Set("hmac", hex.EncodeToString(mac.Sum(nil)))
+	return query.Encode()
+}
+
+func testConfig(t *testing.T) *oauth2.Config {
+	endpoint, err := Endpoint("super-toys")
+	assert.Nil(t, err)
+	return &oauth2.Config{ClientID: "client-id", ClientSecret: "hush", Endpoint: endpoint}
+}
+
+func TestEndpoint(t *testing.T) {
+	for _, shop := range []string{"super-toys", "super-toys.myshopify.com"} {
+		endpoint, err := Endpoint(shop)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://super-toys.myshopify.com/admin/oauth/authorize", endpoint.AuthURL)
+		assert.Equal(t, "https://super-toys.myshopify.com/admin/oauth/access_token", endpoint.TokenURL)
+	}
+	for _, shop := range []string{"", "evil.example.com", "super-toys.myshopify.com.evil.example", "a/b"} {
+		_, err := Endpoint(shop)
+		assert.Equal(t, ErrInvalidShop, err)
+	}
+}
+
+func TestCallbackHandler(t *testing.T) {
+	proxyClient, server := newShopifyTestServer(testShopJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		shop, err := ShopFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, testShop, shop)
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "shpat_token", token.AccessToken)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler with a valid hmac, assert that:
+	// - the auth code is exchanged at the shop's token endpoint
+	// - the Shop is obtained from the shop's Admin API
+	// - success handler is called with the Shop in the ctx
+	query := signQuery("hush", url.Values{
+		"code":      {"any_code"},
+		"shop":      {"super-toys.myshopify.com"},
+		"state":     {"d4e5f6"},
+		"timestamp": {"1337178173"},
+	})
+	handler := CallbackHandler(testConfig(t), goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/callback?"+query, nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_InvalidHMAC(t *testing.T) {
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		assert.Equal(t, ErrShopifyHMACInvalid, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := CallbackHandler(testConfig(t), testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+
+	signed, _ := url.ParseQuery(signQuery("hush", url.Values{"code": {"any_code"}, "shop": {"super-toys.myshopify.com"}, "state": {"d4e5f6"}}))
+	tampered := url.Values{}
+	for k, v := range signed {
+		tampered[k] = v
+	}
+	tampered.Set("shop", "evil.myshopify.com")
+	queries := []string{
+		// missing hmac
+		"code=any_code&shop=super-toys.myshopify.com&state=d4e5f6",
+		// signed with the wrong secret
+		signQuery("wrong", url.Values{"code": {"any_code"}, "shop": {"super-toys.myshopify.com"}, "state": {"d4e5f6"}}),
+		// parameters modified after signing
+		tampered.Encode(),
+	}
+	for _, query := range queries {
+		// CallbackHandler with an invalid hmac, assert that:
+		// - failure handler is called with ErrShopifyHMACInvalid
+		// - the auth code is not exchanged
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/callback?"+query, nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestShopifyHandler_MissingCtxToken(t *testing.T) {
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// ShopifyHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	shopifyHandler := shopifyHandler(testConfig(t), success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	shopifyHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestShopifyHandler_ErrorGettingShop(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Shopify Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetShopifyShop, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// ShopifyHandler cannot get Shopify Shop, assert that:
+	// - failure handler is called
+	// - error cannot get Shopify Shop added to the failure handler ctx
+	shopifyHandler := shopifyHandler(testConfig(t), success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	shopifyHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validShop := &Shop{ID: 690933842}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validShop, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetShopifyShop, validateResponse(validShop, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetShopifyShop, validateResponse(validShop, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetShopifyShop, validateResponse(&Shop{}, validResponse, nil))
+}
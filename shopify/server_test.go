@@ -0,0 +1,30 @@
+package shopify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newShopifyTestServer returns a new httptest.Server which mocks the Shopify
+// token and shop endpoints and a client which proxies requests to the server.
+// The shop endpoint responds with the given json data. The caller must close
+// the server.
+func newShopifyTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/admin/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "shpat_token", "scope": "read_products"}`)
+	})
+	mux.HandleFunc("/admin/api/2023-10/shop.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Shopify-Access-Token") == "" {
+			http.Error(w, "missing access token", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
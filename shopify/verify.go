@@ -0,0 +1,100 @@
+package shopify
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const apiVersion = "2023-10"
+
+// ErrInvalidShop is returned for shop domains which are not myshopify.com
+// subdomains.
+var ErrInvalidShop = errors.New("shopify: invalid shop domain")
+
+var shopPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]*\.myshopify\.com$`)
+
+// Endpoint returns the OAuth2 endpoint of the given shop, which may be given
+// as "example.myshopify.com" or "example".
+func Endpoint(shop string) (oauth2.Endpoint, error) {
+	if shopPattern.MatchString(shop + ".myshopify.com") {
+		shop = shop + ".myshopify.com"
+	}
+	if !shopPattern.MatchString(shop) {
+		return oauth2.Endpoint{}, ErrInvalidShop
+	}
+	return oauth2.Endpoint{
+		AuthURL:   "https://" + shop + "/admin/oauth/authorize",
+		TokenURL:  "https://" + shop + "/admin/oauth/access_token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	}, nil
+}
+
+// Shop is a Shopify shop, the identity of a Shopify login.
+type Shop struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Domain          string `json:"domain"`
+	MyshopifyDomain string `json:"myshopify_domain"`
+	ShopOwner       string `json:"shop_owner"`
+}
+
+// Identity returns the Shopify identity keyed by the shop ID.
+func (s *Shop) Identity() gologin.Identity {
+	identity := gologin.Identity{Provider: Provider.Name}
+	if s.ID != 0 {
+		identity.ID = strconv.FormatInt(s.ID, 10)
+	}
+	return identity
+}
+
+// EmailAddress returns the shop's email address, if any.
+func (s *Shop) EmailAddress() (string, bool) {
+	return s.Email, s.Email != ""
+}
+
+// shopResponse is a Shopify Admin API shop response.
+type shopResponse struct {
+	Shop *Shop `json:"shop"`
+}
+
+// client is a Shopify Admin API client for obtaining the Shop.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+// newClient returns a client for the Admin API of the shop whose OAuth2
+// endpoint is given.
+func newClient(httpClient *http.Client, endpoint oauth2.Endpoint, accessToken string, userInfoURL string) (*client, error) {
+	tokenURL, err := url.Parse(endpoint.TokenURL)
+	if err != nil || !shopPattern.MatchString(tokenURL.Host) {
+		return nil, ErrInvalidShop
+	}
+	base := sling.New().Client(httpClient).Base("https://"+tokenURL.Host+"/admin/api/"+apiVersion+"/").
+		Set("X-Shopify-Access-Token", accessToken).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "shop.json"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}, nil
+}
+
+// Shop gets the authorized shop.
+// https://shopify.dev/docs/api/admin-rest/2023-10/resources/shop
+func (c *client) Shop() (*Shop, *http.Response, error) {
+	shopResp := new(shopResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(shopResp)
+	return shopResp.Shop, resp, err
+}
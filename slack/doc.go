@@ -0,0 +1,6 @@
+// Package slack provides Sign in with Slack OAuth2 login and callback
+// handlers.
+//
+// Slack API methods respond with a 200 status even on failure, with the
+// outcome reported by the "ok" field of the response body.
+package slack
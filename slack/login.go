@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Slack login errors
+var (
+	ErrUnableToGetSlackUser = errors.New("slack: unable to get Slack User")
+)
+
+// Provider is the Slack OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{Name: "slack", CallbackHandler: CallbackHandler}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Slack login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Slack redirection URI requests and adds the Slack
+// access token and User to the ctx. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = slackHandler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// slackHandler is a ContextHandler that gets the OAuth2 Token from the ctx
+// to get the corresponding Slack User. If successful, the User is added to
+// the ctx and the success handler is called. Otherwise, the failure handler
+// is called.
+func slackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		slackClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		slackClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		identityResp, resp, err := slackClient.Identity()
+		err = validateResponse(identityResp, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		user := identityResp.User
+		user.Team = identityResp.Team
+		ctx = WithUser(ctx, &user)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Slack identity response,
+// raw http.Response, or error are unexpected. Slack reports failures with a
+// 200 status and "ok": false, so the ok field is checked rather than only the
+// status code. Returns nil if they are valid.
+func validateResponse(identityResp *identityResponse, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetSlackUser
+	}
+	if identityResp == nil || !identityResp.Ok || identityResp.User.ID == "" {
+		return ErrUnableToGetSlackUser
+	}
+	return nil
+}
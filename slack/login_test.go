@@ -0,0 +1,140 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestSlackHandler(t *testing.T) {
+	jsonData := `{"ok": true, "user": {"name": "Sonny Whether", "id": "U0G9QF9C6", "email": "bobby@slack.com"}, "team": {"id": "T0G9PQBBK", "name": "Captain Fabian's Naval Supply"}}`
+	expectedUser := &User{
+		ID:    "U0G9QF9C6",
+		Name:  "Sonny Whether",
+		Email: "bobby@slack.com",
+		Team:  Team{ID: "T0G9PQBBK", Name: "Captain Fabian's Naval Supply"},
+	}
+	proxyClient, server := newSlackTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		slackUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, slackUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// SlackHandler assert that:
+	// - Token is read from the ctx and passed to the Slack API
+	// - Slack User and Team are obtained from the Slack API
+	// - success handler is called
+	// - Slack User is added to the ctx of the success handler
+	slackHandler := slackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	slackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestSlackHandler_NotOk(t *testing.T) {
+	proxyClient, server := newSlackTestServer(`{"ok": false, "error": "invalid_auth"}`)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetSlackUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// SlackHandler gets a 200 "ok": false response, assert that:
+	// - failure handler is called
+	// - error cannot get Slack User added to the failure handler ctx
+	slackHandler := slackHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	slackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestSlackHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// SlackHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	slackHandler := slackHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	slackHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestSlackHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Slack Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetSlackUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// SlackHandler cannot get Slack User, assert that:
+	// - failure handler is called
+	// - error cannot get Slack User added to the failure handler ctx
+	slackHandler := slackHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	slackHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validIdentity := &identityResponse{Ok: true, User: User{ID: "U0G9QF9C6"}}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validIdentity, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetSlackUser, validateResponse(validIdentity, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetSlackUser, validateResponse(validIdentity, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetSlackUser, validateResponse(&identityResponse{Ok: false, Error: "invalid_auth"}, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetSlackUser, validateResponse(&identityResponse{Ok: true}, validResponse, nil))
+}
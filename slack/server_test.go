@@ -0,0 +1,22 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newSlackTestServer returns a new httptest.Server which mocks the Slack
+// users.identity method and a client which proxies requests to the server.
+// The server responds with a 200 status and the given json data, as Slack
+// does for both successes and failures. The caller must close the server.
+func newSlackTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/api/users.identity", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,87 @@
+package slack
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const slackAPI = "https://slack.com/api/"
+
+// Endpoint is the Sign in with Slack OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://slack.com/oauth/authorize",
+	TokenURL: "https://slack.com/api/oauth.access",
+}
+
+func init() {
+	gologin.RegisterEndpoint("slack", Endpoint)
+}
+
+// Scopes are the scopes required to read the identity of the signed-in
+// user and their team, including their email address.
+var Scopes = []string{"identity.basic", "identity.email", "identity.team"}
+
+// User is a Slack user and the team (workspace) they signed in to. Email is
+// only present with the identity.email scope and Team Name with the
+// identity.team scope.
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Team  Team   `json:"team"`
+}
+
+// Team is a Slack team (workspace).
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Identity returns the Slack identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Slack user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// identityResponse is a Slack users.identity response. Ok is false and Error
+// describes the failure if the request failed.
+type identityResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+	User  User   `json:"user"`
+	Team  Team   `json:"team"`
+}
+
+// client is a Slack client for obtaining the signed-in User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(slackAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users.identity"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Identity gets the identity of the signed-in user and their team.
+// https://api.slack.com/methods/users.identity
+func (c *client) Identity() (*identityResponse, *http.Response, error) {
+	identityResp := new(identityResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(identityResp)
+	return identityResp, resp, err
+}
@@ -0,0 +1,30 @@
+package stripe
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	accountKey key = iota
+)
+
+// WithAccount returns a copy of ctx that stores the Stripe Account.
+func WithAccount(ctx context.Context, account *Account) context.Context {
+	ctx = gologin.WithUser(ctx, account)
+	return context.WithValue(ctx, accountKey, account)
+}
+
+// AccountFromContext returns the Stripe Account from the ctx.
+func AccountFromContext(ctx context.Context) (*Account, error) {
+	account, ok := ctx.Value(accountKey).(*Account)
+	if !ok {
+		return nil, fmt.Errorf("stripe: Context missing Stripe Account")
+	}
+	return account, nil
+}
@@ -0,0 +1,32 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextAccount(t *testing.T) {
+	expectedAccount := &Account{ID: "acct_1032D82eZvKYlo2C", Email: "site@stripe.com"}
+	ctx := WithAccount(context.Background(), expectedAccount)
+	account, err := AccountFromContext(ctx)
+	assert.Equal(t, expectedAccount, account)
+	assert.Nil(t, err)
+}
+
+func TestContextAccount_Error(t *testing.T) {
+	account, err := AccountFromContext(context.Background())
+	assert.Nil(t, account)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "stripe: Context missing Stripe Account", err.Error())
+	}
+}
+
+func TestAccount_Identity(t *testing.T) {
+	ctx := WithAccount(context.Background(), &Account{ID: "acct_1032D82eZvKYlo2C"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "stripe", ID: "acct_1032D82eZvKYlo2C"}, identity)
+}
@@ -0,0 +1,6 @@
+// Package stripe provides Stripe Connect OAuth2 login and callback handlers.
+//
+// The connected Stripe account is the identity of a Stripe Connect login. Its
+// ID is read from the token response's stripe_user_id. Chain an
+// AccountHandler to fetch the account's details with the platform secret key.
+package stripe
@@ -0,0 +1,131 @@
+package stripe
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Stripe login errors
+var (
+	ErrUnableToGetStripeAccount = errors.New("stripe: unable to get Stripe Account")
+)
+
+// Provider is the Stripe Connect OAuth2 Provider for use with oauth2
+// HandleCallback. Stripe requires the platform secret key (the config
+// ClientSecret) in the token request body.
+var Provider = oauth2Login.Provider{
+	Name:            "stripe",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInParams,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Stripe Connect login requests by reading the state
+// value from the ctx and redirecting requests to the AuthURL with that state
+// value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Stripe Connect redirection URI requests and adds
+// the access token and an Account with the connected account ID from the
+// token's stripe_user_id to the ctx. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = stripeHandler(success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// stripeHandler is a ContextHandler that gets the OAuth2 Token from the ctx
+// and reads the connected account ID from its stripe_user_id. If present, an
+// Account is added to the ctx and the success handler is called. Otherwise,
+// the failure handler is called.
+func stripeHandler(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		accountID, ok := token.Extra("stripe_user_id").(string)
+		if !ok || accountID == "" {
+			ctx = gologin.WithError(ctx, ErrUnableToGetStripeAccount)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithAccount(ctx, &Account{ID: accountID})
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// AccountHandler is a ContextHandler that reads the Account from the ctx and
+// fetches the connected account's details from the Stripe API using the
+// platform secret key. If successful, the detailed Account replaces it in the
+// ctx and the success handler is called. Otherwise, the failure handler is
+// called.
+//
+// Chain it after a CallbackHandler.
+func AccountHandler(secretKey string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		account, err := AccountFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		stripeClient := newClient(httpClient, secretKey, gologin.UserInfoURLFromContext(ctx))
+		stripeClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		account, resp, err := stripeClient.Account(account.ID)
+		err = validateResponse(account, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithAccount(ctx, account)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Stripe Account, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(account *Account, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetStripeAccount
+	}
+	if account == nil || account.ID == "" {
+		return ErrUnableToGetStripeAccount
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package stripe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		// assert the platform secret key is sent in the request body
+		assert.Equal(t, "sk_test_platform", r.PostFormValue("client_secret"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "sk_test_connected", "token_type": "bearer", "stripe_user_id": "acct_1032D82eZvKYlo2C", "stripe_publishable_key": "pk_test_connected"}`)
+	})
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: "ca_platform", ClientSecret: "sk_test_platform", Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		account, err := AccountFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &Account{ID: "acct_1032D82eZvKYlo2C"}, account)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler with a token carrying stripe_user_id, assert that:
+	// - an Account with the connected account ID is added to the ctx
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestStripeHandler_MissingStripeUserID(t *testing.T) {
+	ctx := oauth2Login.WithToken(context.Background(), &oauth2.Token{AccessToken: "sk_test_connected"})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetStripeAccount, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := stripeHandler(testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestStripeHandler_MissingCtxToken(t *testing.T) {
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := stripeHandler(testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestAccountHandler(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/v1/accounts/acct_1032D82eZvKYlo2C", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk_test_platform", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "acct_1032D82eZvKYlo2C", "email": "site@stripe.com", "business_profile": {"name": "Stripe.com"}, "created": 1385798567}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = WithAccount(ctx, &Account{ID: "acct_1032D82eZvKYlo2C"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		account, err := AccountFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &Account{ID: "acct_1032D82eZvKYlo2C", Email: "site@stripe.com", BusinessName: "Stripe.com", Created: time.Unix(1385798567, 0).UTC()}, account)
+		assert.Equal(t, time.Date(2013, time.November, 30, 8, 2, 47, 0, time.UTC), account.AccountCreated())
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// AccountHandler assert that:
+	// - the account is fetched with the platform secret key
+	// - the detailed Account replaces the Account in the ctx
+	handler := AccountHandler("sk_test_platform", goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestAccountHandler_ErrorGettingAccount(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Stripe Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = WithAccount(ctx, &Account{ID: "acct_1032D82eZvKYlo2C"})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetStripeAccount, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := AccountHandler("sk_test_platform", testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validAccount := &Account{ID: "acct_1032D82eZvKYlo2C"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validAccount, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetStripeAccount, validateResponse(validAccount, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetStripeAccount, validateResponse(validAccount, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetStripeAccount, validateResponse(&Account{}, validResponse, nil))
+}
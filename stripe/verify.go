@@ -0,0 +1,95 @@
+package stripe
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const stripeAPI = "https://api.stripe.com/v1/"
+
+// Endpoint is the Stripe Connect OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://connect.stripe.com/oauth/authorize",
+	TokenURL:  "https://connect.stripe.com/oauth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("stripe", Endpoint)
+}
+
+// Account is a connected Stripe account.
+type Account struct {
+	ID           string
+	Email        string
+	BusinessName string
+	// Created is when the account was created
+	Created time.Time
+}
+
+// AccountCreated returns when the Stripe account was created.
+func (a *Account) AccountCreated() time.Time {
+	return a.Created
+}
+
+// Identity returns the Stripe identity keyed by the account ID.
+func (a *Account) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: a.ID}
+}
+
+// EmailAddress returns the Stripe account's email address, if any.
+func (a *Account) EmailAddress() (string, bool) {
+	return a.Email, a.Email != ""
+}
+
+// accountResponse is a Stripe API account response.
+type accountResponse struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	// Created is in Unix epoch seconds
+	Created         gologin.Timestamp `json:"created"`
+	BusinessProfile struct {
+		Name string `json:"name"`
+	} `json:"business_profile"`
+}
+
+// client is a Stripe API client for obtaining an Account.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL overrides the account URL, if set
+	userInfoURL string
+}
+
+// newClient returns a Stripe API client authenticated with the platform
+// secret key.
+func newClient(httpClient *http.Client, secretKey, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(stripeAPI).
+		Set("Authorization", "Bearer "+secretKey).ResponseDecoder(internal.JSONDecoder{})
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Account gets the connected account with the given ID.
+// https://stripe.com/docs/api/accounts/retrieve
+func (c *client) Account(id string) (*Account, *http.Response, error) {
+	accountResp := new(accountResponse)
+	accountURL := "accounts/" + id
+	if c.userInfoURL != "" {
+		accountURL = c.userInfoURL
+	}
+	resp, err := c.sling.New().Get(accountURL).ReceiveSuccess(accountResp)
+	account := &Account{
+		ID:           accountResp.ID,
+		Email:        accountResp.Email,
+		BusinessName: accountResp.BusinessProfile.Name,
+		Created:      accountResp.Created.Time,
+	}
+	return account, resp, err
+}
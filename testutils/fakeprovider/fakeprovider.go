@@ -0,0 +1,318 @@
+// Package fakeprovider provides an in-memory OAuth2 authorization server for
+// tests and examples, so complete login flows (state, PKCE, token exchange,
+// userinfo, and refresh) run without network access.
+package fakeprovider
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// Client credentials accepted by the Server.
+const (
+	ClientID     = "fake-client-id"
+	ClientSecret = "fake-client-secret"
+)
+
+// Endpoint paths of the Server, which errors may be injected into.
+const (
+	AuthorizeEndpoint = "/authorize"
+	TokenEndpoint     = "/token"
+	UserInfoEndpoint  = "/userinfo"
+)
+
+// expiresIn is the lifetime in seconds of issued access tokens
+const expiresIn = 3600
+
+// User is a fake provider user, as returned by the userinfo endpoint.
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// grant is an authorization of the client by a user.
+type grant struct {
+	userID      string
+	scopes      []string
+	redirectURI string
+	challenge   string
+}
+
+// Server is an in-memory OAuth2 authorization server with authorize, token,
+// and userinfo endpoints.
+//
+// Authorization requests are approved without a login page, as the user
+// whose ID is the login_hint parameter or else as the first user. Auth codes
+// are single use and bound to the redirect URI and S256 PKCE code challenge,
+// if any. Tokens may be refreshed with the refresh_token grant.
+type Server struct {
+	// URL is the base URL of the Server
+	URL    string
+	server *httptest.Server
+
+	mu            sync.Mutex
+	users         []User
+	scopes        map[string]bool
+	codes         map[string]grant
+	accessTokens  map[string]grant
+	refreshTokens map[string]grant
+	errors        map[string]string
+	issued        int
+}
+
+// NewServer starts a Server with the given users. The caller must Close the
+// Server.
+func NewServer(users ...User) *Server {
+	s := &Server{
+		users:         users,
+		codes:         map[string]grant{},
+		accessTokens:  map[string]grant{},
+		refreshTokens: map[string]grant{},
+		errors:        map[string]string{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(AuthorizeEndpoint, s.authorize)
+	mux.HandleFunc(TokenEndpoint, s.token)
+	mux.HandleFunc(UserInfoEndpoint, s.userInfo)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the Server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Config returns an oauth2.Config for the Server's client which requests
+// the given scopes.
+func (s *Server) Config(redirectURL string, scopes ...string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   s.URL + AuthorizeEndpoint,
+			TokenURL:  s.URL + TokenEndpoint,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	}
+}
+
+// UserInfoURL returns the URL of the userinfo endpoint.
+func (s *Server) UserInfoURL() string {
+	return s.URL + UserInfoEndpoint
+}
+
+// SetScopes restricts the scopes the Server grants. Authorization requests
+// for other scopes are rejected with "invalid_scope". By default, any scopes
+// are granted.
+func (s *Server) SetScopes(scopes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopes = map[string]bool{}
+	for _, scope := range scopes {
+		s.scopes[scope] = true
+	}
+}
+
+// InjectError makes the next request to the endpoint fail with the OAuth2
+// error code (e.g. "access_denied"). The authorize endpoint redirects with
+// the error parameter, the token endpoint responds with a 400 JSON error,
+// and the userinfo endpoint responds with a 401.
+func (s *Server) InjectError(endpoint, code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[endpoint] = code
+}
+
+// injectedError returns and clears the error injected into the endpoint, if
+// any.
+func (s *Server) injectedError(endpoint string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := s.errors[endpoint]
+	delete(s.errors, endpoint)
+	return code
+}
+
+// newToken returns a new unique code or token value. Values only need to be
+// unique, since the Server is not exposed outside of tests.
+func (s *Server) newToken(prefix string) string {
+	s.issued++
+	return fmt.Sprintf("%s-%d", prefix, s.issued)
+}
+
+func (s *Server) authorize(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if query.Get("client_id") != ClientID || query.Get("redirect_uri") == "" || err != nil {
+		// never redirect to unverified redirect URIs
+		http.Error(w, "invalid client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := url.Values{}
+	if state := query.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	code, errCode := s.authorizeCode(query)
+	if injected := s.injectedError(AuthorizeEndpoint); injected != "" {
+		errCode = injected
+	}
+	if errCode != "" {
+		params.Set("error", errCode)
+	} else {
+		params.Set("code", code)
+	}
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, req, redirectURI.String(), http.StatusFound)
+}
+
+// authorizeCode issues an auth code for the authorization request query or
+// returns an OAuth2 error code.
+func (s *Server) authorizeCode(query url.Values) (code, errCode string) {
+	if query.Get("response_type") != "code" {
+		return "", "unsupported_response_type"
+	}
+	if method := query.Get("code_challenge_method"); query.Get("code_challenge") != "" && method != "S256" {
+		return "", "invalid_request"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scopes := strings.Fields(query.Get("scope"))
+	for _, scope := range scopes {
+		if s.scopes != nil && !s.scopes[scope] {
+			return "", "invalid_scope"
+		}
+	}
+	user, ok := s.lookupUser(query.Get("login_hint"))
+	if !ok {
+		return "", "access_denied"
+	}
+	code = s.newToken("code")
+	s.codes[code] = grant{
+		userID:      user.ID,
+		scopes:      scopes,
+		redirectURI: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+	}
+	return code, ""
+}
+
+// lookupUser returns the user with the ID or the first user if the ID is
+// empty. The caller must hold the lock.
+func (s *Server) lookupUser(id string) (User, bool) {
+	for _, user := range s.users {
+		if id == "" || user.ID == id {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func (s *Server) token(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req.ParseForm()
+	clientID, clientSecret, ok := req.BasicAuth()
+	if !ok {
+		clientID, clientSecret = req.PostForm.Get("client_id"), req.PostForm.Get("client_secret")
+	}
+	if clientID != ClientID || clientSecret != ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if injected := s.injectedError(TokenEndpoint); injected != "" {
+		writeError(w, http.StatusBadRequest, injected)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var g grant
+	switch req.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := req.PostForm.Get("code")
+		g, ok = s.codes[code]
+		// auth codes are single use
+		delete(s.codes, code)
+		if !ok || g.redirectURI != req.PostForm.Get("redirect_uri") || !verifyChallenge(g.challenge, req.PostForm.Get("code_verifier")) {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	case "refresh_token":
+		refreshToken := req.PostForm.Get("refresh_token")
+		g, ok = s.refreshTokens[refreshToken]
+		// refresh tokens are rotated
+		delete(s.refreshTokens, refreshToken)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	accessToken, refreshToken := s.newToken("access"), s.newToken("refresh")
+	s.accessTokens[accessToken] = g
+	s.refreshTokens[refreshToken] = g
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    expiresIn,
+		"refresh_token": refreshToken,
+		"scope":         strings.Join(g.scopes, " "),
+	})
+}
+
+// verifyChallenge returns true if the code verifier matches the S256 code
+// challenge or no challenge was sent with the authorization request.
+func verifyChallenge(challenge, verifier string) bool {
+	if challenge == "" {
+		return true
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, req *http.Request) {
+	if injected := s.injectedError(UserInfoEndpoint); injected != "" {
+		writeError(w, http.StatusUnauthorized, injected)
+		return
+	}
+	accessToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	g, ok := s.accessTokens[accessToken]
+	var user User
+	if ok {
+		user, ok = s.lookupUser(g.userID)
+	}
+	s.mu.Unlock()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// writeError writes an OAuth2 JSON error response.
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
@@ -0,0 +1,216 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const redirectURL = "https://app.example.com/callback"
+
+var testUsers = []User{
+	{ID: "1", Name: "Ada Lovelace", Email: "ada@example.com"},
+	{ID: "2", Name: "Grace Hopper", Email: "grace@example.com"},
+}
+
+// noRedirectClient returns the redirect responses of the authorize endpoint
+// instead of following them to the (unreachable) redirect URL.
+var noRedirectClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// login drives a complete login with the gologin oauth2 state, PKCE, login,
+// and callback handlers against the Server and returns the callback response
+// recorder. Extra authorize parameters (e.g. login_hint) may be given.
+func login(t *testing.T, server *Server, config *oauth2.Config, authParams url.Values, success, failure goji.Handler) *httptest.ResponseRecorder {
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	loginHandler := oauth2Login.StateHandler(cookieConfig, oauth2Login.PKCEHandler(cookieConfig, oauth2Login.LoginHandler(config, failure)))
+	callbackHandler := oauth2Login.StateHandler(cookieConfig, oauth2Login.PKCEHandler(cookieConfig, oauth2Login.CallbackHandler(config, success, failure)))
+
+	// login redirects to the authorize endpoint and issues state and PKCE cookies
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "https://app.example.com/login", nil)
+	loginHandler.ServeHTTP(context.Background(), w, req)
+	if !assert.Equal(t, http.StatusFound, w.Code) {
+		return w
+	}
+	cookies := w.Result().Cookies()
+	authURL, _ := url.Parse(w.HeaderMap.Get("Location"))
+	query := authURL.Query()
+	for key, values := range authParams {
+		query[key] = values
+	}
+	authURL.RawQuery = query.Encode()
+
+	// the authorize endpoint redirects to the callback
+	resp, err := noRedirectClient.Get(authURL.String())
+	if !assert.Nil(t, err) {
+		return w
+	}
+	resp.Body.Close()
+	if !assert.Equal(t, http.StatusFound, resp.StatusCode) {
+		return w
+	}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", resp.Header.Get("Location"), nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	callbackHandler.ServeHTTP(context.Background(), w, req)
+	return w
+}
+
+// getUser gets the User of the token from the Server's userinfo endpoint.
+func getUser(server *Server, client *http.Client) (*User, error) {
+	resp, err := client.Get(server.UserInfoURL())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo status %d", resp.StatusCode)
+	}
+	user := new(User)
+	err = json.NewDecoder(resp.Body).Decode(user)
+	return user, err
+}
+
+func TestLogin(t *testing.T) {
+	server := NewServer(testUsers...)
+	defer server.Close()
+	config := server.Config(redirectURL, "profile", "email")
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"profile", "email"}, gologin.ScopesFromContext(ctx))
+		user, err := getUser(server, config.Client(ctx, token))
+		assert.Nil(t, err)
+		assert.Equal(t, &testUsers[0], user)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected call to failure handler: %v", gologin.ErrorFromContext(ctx))
+	}
+
+	// assert that a complete login with state and PKCE succeeds as the first
+	// user, who may then be fetched with the token
+	w := login(t, server, config, nil, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLogin_LoginHint(t *testing.T) {
+	server := NewServer(testUsers...)
+	defer server.Close()
+	config := server.Config(redirectURL)
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, _ := oauth2Login.TokenFromContext(ctx)
+		user, err := getUser(server, config.Client(ctx, token))
+		assert.Nil(t, err)
+		assert.Equal(t, &testUsers[1], user)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected call to failure handler: %v", gologin.ErrorFromContext(ctx))
+	}
+	w := login(t, server, config, url.Values{"login_hint": {"2"}}, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLogin_Errors(t *testing.T) {
+	cases := []struct {
+		scopes     []string
+		authParams url.Values
+		endpoint   string
+	}{
+		// injected errors
+		{nil, nil, AuthorizeEndpoint},
+		{nil, nil, TokenEndpoint},
+		// unsupported scope
+		{[]string{"admin"}, nil, ""},
+		// unknown user
+		{nil, url.Values{"login_hint": {"404"}}, ""},
+		// PKCE code challenge which does not match the verifier
+		{nil, url.Values{"code_challenge": {"wrong-challenge"}}, ""},
+	}
+	for _, c := range cases {
+		server := NewServer(testUsers...)
+		server.SetScopes("profile", "email")
+		if c.endpoint != "" {
+			server.InjectError(c.endpoint, "server_error")
+		}
+		config := server.Config(redirectURL, c.scopes...)
+		success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			t.Errorf("unexpected call to success handler")
+		}
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.NotNil(t, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+		w := login(t, server, config, c.authParams, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+		assert.Equal(t, "failure handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestUserInfo_InjectedError(t *testing.T) {
+	server := NewServer(testUsers...)
+	defer server.Close()
+	config := server.Config(redirectURL)
+	server.InjectError(UserInfoEndpoint, "invalid_token")
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, _ := oauth2Login.TokenFromContext(ctx)
+		client := config.Client(ctx, token)
+		_, err := getUser(server, client)
+		assert.NotNil(t, err)
+		// injected errors only fail the next request
+		user, err := getUser(server, client)
+		assert.Nil(t, err)
+		assert.Equal(t, &testUsers[0], user)
+		fmt.Fprintf(w, "success handler called")
+	}
+	w := login(t, server, config, nil, goji.HandlerFunc(success), nil)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestRefresh(t *testing.T) {
+	server := NewServer(testUsers...)
+	defer server.Close()
+	config := server.Config(redirectURL)
+	ctx := context.Background()
+	var token *oauth2.Token
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, _ = oauth2Login.TokenFromContext(ctx)
+	}
+	login(t, server, config, nil, goji.HandlerFunc(success), nil)
+	if !assert.NotNil(t, token) {
+		return
+	}
+
+	// expire the token, so the TokenSource refreshes it
+	expired := *token
+	expired.Expiry = time.Now().Add(-time.Minute)
+	refreshed, err := config.TokenSource(ctx, &expired).Token()
+	if assert.Nil(t, err) {
+		assert.NotEqual(t, token.AccessToken, refreshed.AccessToken)
+		assert.NotEqual(t, token.RefreshToken, refreshed.RefreshToken)
+		user, err := getUser(server, config.Client(ctx, refreshed))
+		assert.Nil(t, err)
+		assert.Equal(t, &testUsers[0], user)
+	}
+	// refresh tokens are rotated
+	_, err = config.TokenSource(ctx, &expired).Token()
+	assert.NotNil(t, err)
+}
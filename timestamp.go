@@ -0,0 +1,71 @@
+package gologin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TimestampLayouts are the time layouts ParseTimestamp tries when no layouts
+// are given: RFC 3339 (with or without fractional seconds), dates, and the
+// Ruby format of Twitter API v1.1.
+var TimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02",
+	time.RubyDate,
+}
+
+// ParseTimestamp parses a provider timestamp with the given layouts, or the
+// TimestampLayouts if none are given. Unix epoch seconds (e.g. "1385798567")
+// are accepted too. Times are returned in UTC.
+func ParseTimestamp(value string, layouts ...string) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = TimestampLayouts
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("gologin: unrecognized timestamp %q", value)
+}
+
+// Timestamp is a provider profile time (e.g. when an account was created)
+// which decodes from strings in any of the TimestampLayouts and from Unix
+// epoch seconds, as a number or string.
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes a timestamp string or epoch seconds number. A null or
+// empty string leaves the zero time.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		t.Time = time.Unix(int64(seconds), 0).UTC()
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("gologin: invalid timestamp %s", data)
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
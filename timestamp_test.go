@@ -0,0 +1,76 @@
+package gologin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		// RFC 3339
+		{"2016-12-14T20:32:28Z", time.Date(2016, time.December, 14, 20, 32, 28, 0, time.UTC)},
+		{"2013-12-14T04:35:55.000Z", time.Date(2013, time.December, 14, 4, 35, 55, 0, time.UTC)},
+		{"2018-11-14T19:13:22.585390+00:00", time.Date(2018, time.November, 14, 19, 13, 22, 585390000, time.UTC)},
+		{"2019-06-01T09:58:03+02:00", time.Date(2019, time.June, 1, 7, 58, 3, 0, time.UTC)},
+		// dates
+		{"2010-02-07", time.Date(2010, time.February, 7, 0, 0, 0, 0, time.UTC)},
+		// Ruby dates
+		{"Mon Nov 29 21:18:15 +0000 2010", time.Date(2010, time.November, 29, 21, 18, 15, 0, time.UTC)},
+		// epoch seconds
+		{"1385798567", time.Date(2013, time.November, 30, 8, 2, 47, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		parsed, err := ParseTimestamp(c.value)
+		assert.Nil(t, err, c.value)
+		assert.Equal(t, c.expected, parsed, c.value)
+	}
+	_, err := ParseTimestamp("last tuesday")
+	assert.NotNil(t, err)
+}
+
+func TestParseTimestamp_Layouts(t *testing.T) {
+	// only the given layouts are tried
+	parsed, err := ParseTimestamp("14/12/2016", "02/01/2006")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2016, time.December, 14, 0, 0, 0, 0, time.UTC), parsed)
+	_, err = ParseTimestamp("2016-12-14", "02/01/2006")
+	assert.NotNil(t, err)
+}
+
+func TestTimestamp_UnmarshalJSON(t *testing.T) {
+	expected := time.Date(2013, time.November, 30, 8, 2, 47, 0, time.UTC)
+	cases := []struct {
+		json     string
+		expected time.Time
+	}{
+		{`{"created": "2013-11-30T08:02:47Z"}`, expected},
+		{`{"created": 1385798567}`, expected},
+		{`{"created": "1385798567"}`, expected},
+		{`{"created": "Sat Nov 30 08:02:47 +0000 2013"}`, expected},
+		{`{"created": "2013-11-30"}`, time.Date(2013, time.November, 30, 0, 0, 0, 0, time.UTC)},
+		{`{"created": ""}`, time.Time{}},
+		{`{"created": null}`, time.Time{}},
+		{`{}`, time.Time{}},
+	}
+	for _, c := range cases {
+		var user struct {
+			Created Timestamp `json:"created"`
+		}
+		err := json.Unmarshal([]byte(c.json), &user)
+		assert.Nil(t, err, c.json)
+		assert.Equal(t, c.expected, user.Created.Time, c.json)
+	}
+
+	for _, data := range []string{`{"created": "yesterday"}`, `{"created": true}`} {
+		var user struct {
+			Created Timestamp `json:"created"`
+		}
+		assert.NotNil(t, json.Unmarshal([]byte(data), &user), data)
+	}
+}
@@ -0,0 +1,122 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// MissingFieldError is returned when a required POST form field is empty.
+type MissingFieldError struct {
+	Field string
+}
+
+func (e MissingFieldError) Error() string {
+	return fmt.Sprintf("gologin: missing field %s", e.Field)
+}
+
+// ValidationError is returned when required POST form fields are empty and
+// TokenPostOptions ReportAllMissing is set. Missing lists every empty field.
+type ValidationError struct {
+	Missing []string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("gologin: missing fields %s", strings.Join(e.Missing, ", "))
+}
+
+// InvalidTokenError is returned by a BearerChallengeHandler when a posted
+// token fails verification. Err is the verification error.
+type InvalidTokenError struct {
+	Err error
+}
+
+func (e InvalidTokenError) Error() string {
+	return e.Err.Error()
+}
+
+// TokenPostOptions configures a TokenPostHandler.
+type TokenPostOptions struct {
+	// ReportAllMissing fails with a ValidationError listing every empty
+	// field, rather than a MissingFieldError for the first one, so clients
+	// can report all of them at once.
+	ReportAllMissing bool
+	// BearerChallenge responds to API clients whose posted token fails
+	// verification with a 401 Bearer challenge (see BearerChallengeHandler),
+	// rather than a plain 400.
+	BearerChallenge bool
+}
+
+// TokenPostHandler reads the given form fields from a POST request and calls
+// verify with their values. If every field is present and verify succeeds,
+// the success handler is called with the ctx returned by verify. Otherwise,
+// the failure handler is called with the error added to the ctx. Non-POST
+// requests fail with ErrMethodNotAllowed.
+//
+// TokenPostHandler generalizes the mobile token login handlers so providers,
+// or passwordless schemes, can accept posted credentials.
+func TokenPostHandler(fields []string, verify func(ctx context.Context, values map[string]string) (context.Context, error), success, failure goji.Handler) goji.Handler {
+	return TokenPostHandlerWithOptions(fields, verify, TokenPostOptions{}, success, failure)
+}
+
+// TokenPostHandlerWithOptions reads and verifies posted form fields like
+// TokenPostHandler, configured by the given TokenPostOptions.
+func TokenPostHandlerWithOptions(fields []string, verify func(ctx context.Context, values map[string]string) (context.Context, error), options TokenPostOptions, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	verifyFailure := failure
+	if options.BearerChallenge {
+		verifyFailure = BearerChallengeHandler(failure)
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		values := make(map[string]string, len(fields))
+		var missing []string
+		for _, field := range fields {
+			value := req.PostForm.Get(field)
+			if value == "" {
+				if !options.ReportAllMissing {
+					ctx = WithError(ctx, MissingFieldError{Field: field})
+					failure.ServeHTTP(ctx, w, req)
+					return
+				}
+				missing = append(missing, field)
+			}
+			values[field] = value
+		}
+		if len(missing) > 0 {
+			ctx = WithError(ctx, ValidationError{Missing: missing})
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx, err := verify(ctx, values)
+		if err != nil {
+			ctx = WithError(ctx, err)
+			verifyFailure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return MethodHandler([]string{"POST"}, goji.HandlerFunc(fn), failure)
+}
+
+// BearerChallengeHandler wraps a failure handler for token verification
+// failures. It sets the "WWW-Authenticate: Bearer error=invalid_token" header
+// (RFC 6750 3.1) and wraps the ctx error in an InvalidTokenError, which the
+// DefaultFailureHandler responds to with a 401 status code, before calling
+// the failure handler.
+func BearerChallengeHandler(failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		ctx = WithError(ctx, InvalidTokenError{Err: ErrorFromContext(ctx)})
+		failure.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
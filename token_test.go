@@ -0,0 +1,176 @@
+package gologin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type testKey int
+
+const valuesKey testKey = 0
+
+var testFields = []string{"email", "code"}
+
+func newPostRequest(form url.Values) *http.Request {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestTokenPostHandler(t *testing.T) {
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		return context.WithValue(ctx, valuesKey, values), nil
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		values := ctx.Value(valuesKey).(map[string]string)
+		assert.Equal(t, map[string]string{"email": "a@example.com", "code": "123456"}, values)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := TokenPostHandler(testFields, verify, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+
+	// TokenPostHandler with all fields posted, assert that:
+	// - verify receives the field values
+	// - success handler is called with the ctx returned by verify
+	w := httptest.NewRecorder()
+	req := newPostRequest(url.Values{"email": {"a@example.com"}, "code": {"123456"}})
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestTokenPostHandler_NonPost(t *testing.T) {
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		t.Errorf("unexpected call to verify")
+		return ctx, nil
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrMethodNotAllowed, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := TokenPostHandler(testFields, verify, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestTokenPostHandler_MissingFields(t *testing.T) {
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		t.Errorf("unexpected call to verify")
+		return ctx, nil
+	}
+	handler := TokenPostHandler(testFields, verify, testutils.AssertSuccessNotCalled(t), nil)
+
+	cases := []struct {
+		form     url.Values
+		expected error
+	}{
+		{url.Values{}, MissingFieldError{Field: "email"}},
+		{url.Values{"email": {"a@example.com"}}, MissingFieldError{Field: "code"}},
+		{url.Values{"email": {"a@example.com"}, "code": {""}}, MissingFieldError{Field: "code"}},
+	}
+	for _, c := range cases {
+		// assert that the default failure handler reports the missing field
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(context.Background(), w, newPostRequest(c.form))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, c.expected.Error()+"\n", w.Body.String())
+	}
+}
+
+func TestTokenPostHandler_ReportAllMissing(t *testing.T) {
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		t.Errorf("unexpected call to verify")
+		return ctx, nil
+	}
+	options := TokenPostOptions{ReportAllMissing: true}
+	handler := TokenPostHandlerWithOptions(testFields, verify, options, testutils.AssertSuccessNotCalled(t), nil)
+
+	cases := []struct {
+		form     url.Values
+		expected error
+	}{
+		{url.Values{}, ValidationError{Missing: []string{"email", "code"}}},
+		{url.Values{"email": {"a@example.com"}}, ValidationError{Missing: []string{"code"}}},
+	}
+	for _, c := range cases {
+		// assert that the default failure handler reports every missing field
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(context.Background(), w, newPostRequest(c.form))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, c.expected.Error()+"\n", w.Body.String())
+	}
+}
+
+func TestTokenPostHandler_VerifyError(t *testing.T) {
+	verifyErr := errors.New("invalid code")
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		return ctx, verifyErr
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, verifyErr, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := TokenPostHandler(testFields, verify, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+
+	// TokenPostHandler with a failing verify, assert that:
+	// - failure handler is called with the verify error
+	// - success handler is not called
+	w := httptest.NewRecorder()
+	req := newPostRequest(url.Values{"email": {"a@example.com"}, "code": {"000000"}})
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestTokenPostHandler_BearerChallenge(t *testing.T) {
+	verifyErr := errors.New("invalid code")
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		return ctx, verifyErr
+	}
+	options := TokenPostOptions{BearerChallenge: true}
+	handler := TokenPostHandlerWithOptions(testFields, verify, options, testutils.AssertSuccessNotCalled(t), nil)
+
+	// TokenPostHandler with BearerChallenge and a failing verify, assert that:
+	// - the default failure handler responds with a 401
+	// - the RFC 6750 WWW-Authenticate challenge is set
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(context.Background(), w, newPostRequest(url.Values{"email": {"a@example.com"}, "code": {"123456"}}))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.HeaderMap.Get("WWW-Authenticate"))
+	assert.Equal(t, "invalid code\n", w.Body.String())
+
+	// missing fields are still bad requests without a challenge
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(context.Background(), w, newPostRequest(url.Values{}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "", w.HeaderMap.Get("WWW-Authenticate"))
+}
+
+func TestTokenPostHandler_BearerChallengeError(t *testing.T) {
+	verifyErr := errors.New("invalid code")
+	verify := func(ctx context.Context, values map[string]string) (context.Context, error) {
+		return ctx, verifyErr
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := ErrorFromContext(ctx)
+		assert.Equal(t, InvalidTokenError{Err: verifyErr}, err)
+		if invalidToken, ok := err.(InvalidTokenError); assert.True(t, ok) {
+			assert.Equal(t, verifyErr, invalidToken.Err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+	options := TokenPostOptions{BearerChallenge: true}
+	handler := TokenPostHandlerWithOptions(testFields, verify, options, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(context.Background(), w, newPostRequest(url.Values{"email": {"a@example.com"}, "code": {"123456"}}))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
@@ -0,0 +1,65 @@
+package gologin
+
+import (
+	"net/http"
+)
+
+// UserAgent is the User-Agent header value gologin handlers set on requests
+// to providers (e.g. token exchanges and user info requests). Some providers
+// rate limit or block default Go user agents. Set to "" to leave the
+// User-Agent unchanged.
+var UserAgent = "gologin/0.1"
+
+// RequestHook, if set, is called with each request gologin handlers make to
+// providers (e.g. token exchanges and user info requests) before it is sent.
+// It may modify the request, such as adding headers or rewriting the URL to
+// route through an egress proxy or regional mirror. The request is a copy,
+// so changes don't affect the caller's request. When rewriting the host, set
+// req.Host too, since it overrides the URL host in the Host header.
+var RequestHook func(req *http.Request)
+
+// hookClient returns a copy of the http.Client (or of the default client if
+// nil) whose requests set the UserAgent and are passed to the RequestHook,
+// like provider requests. The internal UserAgentClient can't be used since
+// internal imports gologin.
+func hookClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := new(http.Client)
+	*c = *client
+	c.Transport = &hookTransport{base: client.Transport}
+	return c
+}
+
+// hookTransport is an http.RoundTripper which sets the UserAgent and applies
+// the RequestHook before calling the base RoundTripper.
+type hookTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip sets the User-Agent header on a copy of the request, applies the
+// RequestHook, and calls through to the base RoundTripper.
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers should not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	if UserAgent != "" {
+		r.Header.Set("User-Agent", UserAgent)
+	}
+	if RequestHook != nil {
+		// only hooks may rewrite the URL
+		u := *req.URL
+		r.URL = &u
+		RequestHook(r)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
@@ -6,6 +6,7 @@ import (
 
 	"goji.io"
 	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
 	oauth1Login "github.com/quasor/gologin/oauth1"
 	"github.com/dghubble/oauth1"
 	"golang.org/x/net/context"
@@ -53,7 +54,8 @@ func tumblrHandler(config *oauth1.Config, success, failure goji.Handler) goji.Ha
 			return
 		}
 		httpClient := config.Client(ctx, oauth1.NewToken(accessToken, accessSecret))
-		tumblrClient := newClient(httpClient)
+		tumblrClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		tumblrClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
 		user, resp, err := tumblrClient.UserInfo()
 		err = validateResponse(user, resp, err)
 		if err != nil {
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
 )
 
 const tumblrAPI = "https://api.tumblr.com/v2/"
@@ -25,6 +27,13 @@ type User struct {
 	Likes     int64  `json:"likes"`
 }
 
+// Identity returns the Tumblr identity keyed by the user name. Tumblr does
+// not expose an immutable user ID, so the identity changes if the user
+// renames their account.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: "tumblr", ID: u.Name}
+}
+
 // meta is a metadata struct Tumblr includes in responses.
 type meta struct {
 	Status  int    `json:"status"`
@@ -34,17 +43,23 @@ type meta struct {
 // client is a Tumblr client for obtaining a User.
 type client struct {
 	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
 }
 
-func newClient(httpClient *http.Client) *client {
-	base := sling.New().Client(httpClient).Base(tumblrAPI)
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(tumblrAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "user/info"
+	}
 	return &client{
-		sling: base,
+		sling:       base,
+		userInfoURL: userInfoURL,
 	}
 }
 
 func (c *client) UserInfo() (*User, *http.Response, error) {
 	userResp := new(userInfoResponse)
-	resp, err := c.sling.New().Get("user/info").ReceiveSuccess(userResp)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(userResp)
 	return &userResp.Response.User, resp, err
 }
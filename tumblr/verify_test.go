@@ -0,0 +1,13 @@
+package tumblr
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_Identity(t *testing.T) {
+	user := &User{Name: "gopher"}
+	assert.Equal(t, gologin.Identity{Provider: "tumblr", ID: "gopher"}, user.Identity())
+}
@@ -0,0 +1,46 @@
+package twitch
+
+import (
+	"fmt"
+
+	"github.com/quasor/gologin"
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	userKey key = iota
+	validationKey
+)
+
+// WithUser returns a copy of ctx that stores the Twitch User.
+func WithUser(ctx context.Context, user *User) context.Context {
+	ctx = gologin.WithUser(ctx, user)
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the Twitch User from the ctx.
+func UserFromContext(ctx context.Context) (*User, error) {
+	user, ok := ctx.Value(userKey).(*User)
+	if !ok {
+		return nil, fmt.Errorf("twitch: Context missing Twitch User")
+	}
+	return user, nil
+}
+
+// WithValidation returns a copy of ctx that stores the Twitch token
+// Validation.
+func WithValidation(ctx context.Context, validation *Validation) context.Context {
+	return context.WithValue(ctx, validationKey, validation)
+}
+
+// ValidationFromContext returns the Twitch token Validation from the ctx.
+func ValidationFromContext(ctx context.Context) (*Validation, error) {
+	validation, ok := ctx.Value(validationKey).(*Validation)
+	if !ok {
+		return nil, fmt.Errorf("twitch: Context missing Twitch token Validation")
+	}
+	return validation, nil
+}
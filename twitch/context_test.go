@@ -0,0 +1,66 @@
+package twitch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "141981764"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "twitch: Context missing Twitch User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "141981764"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "twitch", ID: "141981764"}, identity)
+}
+
+func TestUser_PictureURL(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "141981764", ProfileImageURL: "https://static-cdn.jtvnw.net/user-default-pictures/profile.png"})
+	picture, ok := gologin.UserPicture(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "https://static-cdn.jtvnw.net/user-default-pictures/profile.png", picture)
+}
+
+func TestContextValidation(t *testing.T) {
+	expectedValidation := &Validation{UserID: "141981764", Scopes: []string{"user:read:email"}}
+	ctx := WithValidation(context.Background(), expectedValidation)
+	validation, err := ValidationFromContext(ctx)
+	assert.Equal(t, expectedValidation, validation)
+	assert.Nil(t, err)
+}
+
+func TestContextValidation_Error(t *testing.T) {
+	validation, err := ValidationFromContext(context.Background())
+	assert.Nil(t, validation)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "twitch: Context missing Twitch token Validation", err.Error())
+	}
+}
+
+func TestUser_AccountCreated(t *testing.T) {
+	// RFC 3339 created at timestamps
+	user := new(User)
+	err := json.Unmarshal([]byte(`{"id": "141981764", "created_at": "2016-12-14T20:32:28Z"}`), user)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2016, time.December, 14, 20, 32, 28, 0, time.UTC), user.AccountCreated())
+	assert.True(t, (&User{}).AccountCreated().IsZero())
+}
@@ -0,0 +1,6 @@
+// Package twitch provides Twitch OAuth2 login and callback handlers.
+//
+// Twitch requires client credentials be sent in the token request body and
+// the client ID be sent with Helix API requests. Callbacks may optionally
+// validate the token to require scopes were granted.
+package twitch
@@ -0,0 +1,157 @@
+package twitch
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Twitch login errors
+var (
+	ErrUnableToGetTwitchUser = errors.New("twitch: unable to get Twitch User")
+	ErrUnableToValidateToken = errors.New("twitch: unable to validate Twitch token")
+	ErrInsufficientScopes    = errors.New("twitch: token was not granted the required scopes")
+)
+
+// Provider is the Twitch OAuth2 Provider for use with oauth2 HandleCallback.
+// Twitch requires client credentials in the token request body.
+var Provider = oauth2Login.Provider{
+	Name:            "twitch",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInParams,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles Twitch login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles Twitch redirection URI requests and adds the Twitch
+// access token and User to the ctx. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+//
+// Configs which auto-detect the AuthStyle use AuthStyleInParams.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	return CallbackHandlerWithOptions(config, CallbackOptions{}, success, failure)
+}
+
+// CallbackOptions configures a Twitch CallbackHandler.
+type CallbackOptions struct {
+	// ValidateToken validates the token with the Twitch validate endpoint
+	// and adds the Validation, which includes the token Expiry, to the ctx.
+	ValidateToken bool
+	// RequiredScopes validates the token (as with ValidateToken) and fails
+	// with ErrInsufficientScopes unless all of the scopes were granted.
+	// Users may deselect requested scopes on the Twitch consent page.
+	RequiredScopes []string
+}
+
+// CallbackHandlerWithOptions handles Twitch redirection URI requests like
+// CallbackHandler, configured by the given CallbackOptions.
+func CallbackHandlerWithOptions(config *oauth2.Config, options CallbackOptions, success, failure goji.Handler) goji.Handler {
+	config = oauth2Login.Provider{AuthStyle: oauth2.AuthStyleInParams}.Configure(config)
+	success = twitchHandler(config, success, failure)
+	if options.ValidateToken || len(options.RequiredScopes) > 0 {
+		success = validateHandler(options.RequiredScopes, success, failure)
+	}
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// validateHandler is a ContextHandler that validates the OAuth2 Token from the
+// ctx. If the token is valid and was granted the required scopes, the
+// Validation is added to the ctx and the success handler is called.
+// Otherwise, the failure handler is called.
+func validateHandler(requiredScopes []string, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		// Twitch expects "OAuth <token>", not the oauth2 Client's "Bearer <token>"
+		httpClient, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		validation, resp, err := validate(httpClient, internal.NewJSONDecoder(ctx), token.AccessToken)
+		if err != nil || resp.StatusCode != http.StatusOK || validation.UserID == "" {
+			ctx = gologin.WithError(ctx, ErrUnableToValidateToken)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		if !validation.HasScopes(requiredScopes) {
+			ctx = gologin.WithError(ctx, ErrInsufficientScopes)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithValidation(ctx, validation)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// twitchHandler is a ContextHandler that gets the OAuth2 Token from the ctx to
+// get the corresponding Twitch User. If successful, the User is added to the
+// ctx and the success handler is called. Otherwise, the failure handler is
+// called.
+func twitchHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		twitchClient := newClient(httpClient, config.ClientID, gologin.UserInfoURLFromContext(ctx))
+		twitchClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		usersResp, resp, err := twitchClient.Users()
+		err = validateResponse(usersResp, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, &usersResp.Data[0])
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Twitch users response, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(usersResp *usersResponse, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetTwitchUser
+	}
+	if usersResp == nil || len(usersResp.Data) != 1 || usersResp.Data[0].ID == "" {
+		return ErrUnableToGetTwitchUser
+	}
+	return nil
+}
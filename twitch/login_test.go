@@ -0,0 +1,213 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testUsersJSON      = `{"data": [{"id": "141981764", "login": "twitchdev", "display_name": "TwitchDev", "type": "", "broadcaster_type": "partner", "profile_image_url": "https://static-cdn.jtvnw.net/jtv_user_pictures/twitchdev-profile_image.png", "email": "twitchdev@example.com", "created_at": "2016-12-14T20:32:28Z"}]}`
+	testValidationJSON = `{"client_id": "client-id", "login": "twitchdev", "scopes": ["channel:read:subscriptions", "user:read:email"], "user_id": "141981764", "expires_in": 14124}`
+)
+
+// newTestConfig returns a Twitch config with an Endpoint without an
+// AuthStyle, so the Twitch default must be applied.
+func newTestConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: Endpoint.AuthURL, TokenURL: Endpoint.TokenURL},
+	}
+}
+
+func TestCallbackHandler(t *testing.T) {
+	expectedUser := &User{
+		ID:              "141981764",
+		Login:           "twitchdev",
+		DisplayName:     "TwitchDev",
+		Email:           "twitchdev@example.com",
+		ProfileImageURL: "https://static-cdn.jtvnw.net/jtv_user_pictures/twitchdev-profile_image.png",
+		CreatedAt:       gologin.Timestamp{Time: time.Date(2016, time.December, 14, 20, 32, 28, 0, time.UTC)},
+	}
+	proxyClient, server := newTwitchTestServer(testUsersJSON, testValidationJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		twitchUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, twitchUser)
+		// the token is not validated by default
+		_, err = ValidationFromContext(ctx)
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler exchanges the code with body credentials, assert that:
+	// - the users request sends the Client-Id header (required by the server)
+	// - the Twitch User is added to the ctx of the success handler
+	handler := CallbackHandler(newTestConfig(), goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandlerWithOptions_SufficientScopes(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	defer func(clock internal.Clock) { internal.DefaultClock = clock }(internal.DefaultClock)
+	internal.DefaultClock = internal.NewFakeClock(now)
+	proxyClient, server := newTwitchTestServer(testUsersJSON, testValidationJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		_, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		validation, err := ValidationFromContext(ctx)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "141981764", validation.UserID)
+			assert.Equal(t, []string{"channel:read:subscriptions", "user:read:email"}, validation.Scopes)
+			assert.Equal(t, now.Add(14124*time.Second), validation.Expiry)
+		}
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandlerWithOptions with required scopes which were granted,
+	// assert that:
+	// - the token is validated with the "OAuth <token>" Authorization
+	// - the Validation, with the token Expiry, is added to the ctx
+	options := CallbackOptions{RequiredScopes: []string{"user:read:email", "channel:read:subscriptions"}}
+	handler := CallbackHandlerWithOptions(newTestConfig(), options, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandlerWithOptions_InsufficientScopes(t *testing.T) {
+	// the user deselected channel:read:subscriptions on the consent page
+	validationJSON := `{"client_id": "client-id", "login": "twitchdev", "scopes": ["user:read:email"], "user_id": "141981764", "expires_in": 14124}`
+	proxyClient, server := newTwitchTestServer(testUsersJSON, validationJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrInsufficientScopes, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandlerWithOptions with required scopes which were not all
+	// granted, assert that:
+	// - failure handler is called with ErrInsufficientScopes
+	options := CallbackOptions{RequiredScopes: []string{"user:read:email", "channel:read:subscriptions"}}
+	handler := CallbackHandlerWithOptions(newTestConfig(), options, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateHandler_InvalidToken(t *testing.T) {
+	proxyClient, server := newTwitchTestServer(testUsersJSON, testValidationJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "revoked-token"})
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToValidateToken, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// validateHandler with a token Twitch rejects, assert that:
+	// - failure handler is called with ErrUnableToValidateToken
+	handler := validateHandler(nil, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestTwitchHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TwitchHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	twitchHandler := twitchHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	twitchHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestTwitchHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Twitch Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetTwitchUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TwitchHandler cannot get Twitch User, assert that:
+	// - failure handler is called
+	// - error cannot get Twitch User added to the failure handler ctx
+	twitchHandler := twitchHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	twitchHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUsers := &usersResponse{Data: []User{{ID: "141981764"}}}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUsers, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitchUser, validateResponse(validUsers, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetTwitchUser, validateResponse(validUsers, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitchUser, validateResponse(&usersResponse{}, validResponse, nil))
+}
+
+func TestValidation_HasScopes(t *testing.T) {
+	validation := &Validation{Scopes: []string{"user:read:email", "chat:read"}}
+	assert.True(t, validation.HasScopes(nil))
+	assert.True(t, validation.HasScopes([]string{"chat:read"}))
+	assert.True(t, validation.HasScopes([]string{"chat:read", "user:read:email"}))
+	assert.False(t, validation.HasScopes([]string{"chat:read", "chat:edit"}))
+	assert.False(t, (&Validation{}).HasScopes([]string{"chat:read"}))
+}
@@ -0,0 +1,49 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newTwitchTestServer returns a new httptest.Server which mocks the Twitch
+// token endpoint, requiring client credentials in the body, the token
+// validation endpoint, which responds with the given validation json data,
+// and the Helix users endpoint, requiring the Client-Id header, which
+// responds with the given users json data. It also returns a client which
+// proxies requests to the server. The caller must close the server.
+func newTwitchTestServer(usersJSON, validationJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _, basicAuth := r.BasicAuth()
+		if basicAuth || r.PostFormValue("client_id") != "client-id" || r.PostFormValue("client_secret") != "client-secret" {
+			http.Error(w, `{"status": 403, "message": "invalid client secret"}`, http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "twitch-token", "token_type": "bearer", "expires_in": 14124, "scope": ["user:read:email"]}`)
+	})
+	mux.HandleFunc("/oauth2/validate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "OAuth twitch-token" {
+			http.Error(w, `{"status": 401, "message": "invalid access token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, validationJSON)
+	})
+	mux.HandleFunc("/helix/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Client-Id") != "client-id" {
+			http.Error(w, `{"error": "Unauthorized", "status": 401, "message": "Client-Id header required"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer twitch-token" {
+			http.Error(w, `{"error": "Unauthorized", "status": 401, "message": "Invalid OAuth token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, usersJSON)
+	})
+	return client, server
+}
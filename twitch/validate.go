@@ -0,0 +1,53 @@
+package twitch
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin/internal"
+)
+
+// ValidateURL is the Twitch token validation endpoint.
+var ValidateURL = "https://id.twitch.tv/oauth2/validate"
+
+// Validation is a Twitch token validation response.
+type Validation struct {
+	ClientID string   `json:"client_id"`
+	Login    string   `json:"login"`
+	UserID   string   `json:"user_id"`
+	Scopes   []string `json:"scopes"`
+	// ExpiresIn is the token lifetime in seconds when it was validated
+	ExpiresIn int64 `json:"expires_in"`
+	// Expiry is when the token expires, computed from ExpiresIn
+	Expiry time.Time `json:"-"`
+}
+
+// HasScopes returns true if the token was granted all of the scopes.
+func (v *Validation) HasScopes(scopes []string) bool {
+	granted := make(map[string]bool, len(v.Scopes))
+	for _, scope := range v.Scopes {
+		granted[scope] = true
+	}
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// validate validates the access token, which Twitch expects as
+// "OAuth <token>".
+// https://dev.twitch.tv/docs/authentication/validate-tokens/
+func validate(httpClient *http.Client, decoder sling.ResponseDecoder, accessToken string) (*Validation, *http.Response, error) {
+	validation := new(Validation)
+	resp, err := sling.New().Client(httpClient).ResponseDecoder(decoder).
+		Get(ValidateURL).
+		Set("Authorization", "OAuth "+accessToken).
+		ReceiveSuccess(validation)
+	if err == nil && validation.ExpiresIn > 0 {
+		validation.Expiry = internal.DefaultClock.Now().Add(time.Duration(validation.ExpiresIn) * time.Second)
+	}
+	return validation, resp, err
+}
@@ -0,0 +1,86 @@
+package twitch
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const helixAPI = "https://api.twitch.tv/helix/"
+
+// Endpoint is the Twitch OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://id.twitch.tv/oauth2/authorize",
+	TokenURL:  "https://id.twitch.tv/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("twitch", Endpoint)
+}
+
+// User is a Twitch user. Email is only present with the user:read:email
+// scope.
+type User struct {
+	ID              string            `json:"id"`
+	Login           string            `json:"login"`
+	DisplayName     string            `json:"display_name"`
+	Email           string            `json:"email"`
+	ProfileImageURL string            `json:"profile_image_url"`
+	CreatedAt       gologin.Timestamp `json:"created_at"`
+}
+
+// Identity returns the Twitch identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Twitch user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// AccountCreated returns when the Twitch account was created.
+func (u *User) AccountCreated() time.Time {
+	return u.CreatedAt.Time
+}
+
+// PictureURL returns the Twitch user's profile image URL.
+func (u *User) PictureURL() string {
+	return u.ProfileImageURL
+}
+
+// usersResponse is a Helix API response, which wraps Users in data.
+type usersResponse struct {
+	Data []User `json:"data"`
+}
+
+// client is a Twitch Helix client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, clientID, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(helixAPI).Set("Client-Id", clientID).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Users gets the authenticated User, as the only User of the response.
+// https://dev.twitch.tv/docs/api/reference/#get-users
+func (c *client) Users() (*usersResponse, *http.Response, error) {
+	usersResp := new(usersResponse)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(usersResp)
+	return usersResp, resp, err
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/dghubble/go-twitter/twitter"
+	"github.com/quasor/gologin"
 	"golang.org/x/net/context"
 )
 
@@ -16,6 +17,7 @@ const (
 
 // WithUser returns a copy of ctx that stores the Twitter User.
 func WithUser(ctx context.Context, user *twitter.User) context.Context {
+	ctx = gologin.WithUser(ctx, &providerUser{user})
 	return context.WithValue(ctx, userKey, user)
 }
 
@@ -41,9 +41,19 @@ func CallbackHandler(config *oauth1.Config, success, failure goji.Handler) goji.
 // If successful, the User is added to the ctx and the success handler is
 // called. Otherwise, the failure handler is called.
 func twitterHandler(config *oauth1.Config, success, failure goji.Handler) goji.Handler {
+	return verifyCredentialsHandler(config, success, failure, failure)
+}
+
+// verifyCredentialsHandler handles requests like twitterHandler, but calls
+// the invalid handler instead of the failure handler when Twitter rejects the
+// access token/secret with a 401 Unauthorized response.
+func verifyCredentialsHandler(config *oauth1.Config, success, failure, invalid goji.Handler) goji.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
+	if invalid == nil {
+		invalid = gologin.DefaultFailureHandler
+	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
 		accessToken, accessSecret, err := oauth1Login.AccessTokenFromContext(ctx)
 		if err != nil {
@@ -59,6 +69,11 @@ func twitterHandler(config *oauth1.Config, success, failure goji.Handler) goji.H
 			IncludeEmail:    twitter.Bool(false),
 		}
 		user, resp, err := twitterClient.Accounts.VerifyCredentials(accountVerifyParams)
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			ctx = gologin.WithError(ctx, ErrUnableToGetTwitterUser)
+			invalid.ServeHTTP(ctx, w, req)
+			return
+		}
 		err = validateResponse(user, resp, err)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
@@ -0,0 +1,44 @@
+package twitter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth1Login "github.com/quasor/gologin/oauth1"
+	"github.com/quasor/gologin/testutils"
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestLoginHandler_RequestTokenError(t *testing.T) {
+	_, server := testutils.NewErrorServer("Rate limit exceeded", http.StatusTooManyRequests)
+	defer server.Close()
+	config := &oauth1.Config{
+		Endpoint: oauth1.Endpoint{
+			RequestTokenURL: server.URL,
+			AuthorizeURL:    "https://api.twitter.com/oauth/authenticate",
+		},
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if requestTokenErr, ok := err.(oauth1Login.RequestTokenError); assert.True(t, ok) {
+			assert.Equal(t, http.StatusTooManyRequests, requestTokenErr.StatusCode)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LoginHandler cannot get a request token from Twitter, assert that:
+	// - the requester is not redirected to authorize
+	// - failure handler is called with the Twitter status
+	handler := LoginHandler(config, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	assert.Empty(t, w.HeaderMap.Get("Location"))
+}
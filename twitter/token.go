@@ -6,6 +6,7 @@ import (
 
 	"goji.io"
 	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
 	oauth1Login "github.com/quasor/gologin/oauth1"
 	"github.com/dghubble/oauth1"
 	"golang.org/x/net/context"
@@ -27,38 +28,52 @@ var (
 // token/secret and User are added to the ctx and the success handler is
 // called. Otherwise, the failure handler is called.
 func TokenHandler(config *oauth1.Config, success, failure goji.Handler) goji.Handler {
-	success = twitterHandler(config, success, failure)
+	return TokenHandlerWithOptions(config, gologin.TokenPostOptions{}, success, failure)
+}
+
+// TokenHandlerWithOptions handles Twitter access token/secret posts like
+// TokenHandler, configured by the given gologin TokenPostOptions. With
+// ReportAllMissing, missing fields are reported together in a gologin
+// ValidationError, instead of as ErrMissingToken or ErrMissingTokenSecret.
+// With BearerChallenge, tokens which Twitter rejects as invalid credentials
+// are rejected with a 401 Bearer challenge. Other Twitter API failures are
+// passed to the failure handler unchanged.
+func TokenHandlerWithOptions(config *oauth1.Config, options gologin.TokenPostOptions, success, failure goji.Handler) goji.Handler {
+	invalid := failure
+	if options.BearerChallenge {
+		invalid = gologin.BearerChallengeHandler(failure)
+	}
+	success = verifyCredentialsHandler(config, success, failure, invalid)
+	fields := []string{accessTokenField, accessTokenSecretField}
+	return gologin.TokenPostHandlerWithOptions(fields, verifyToken, options, success, missingTokenHandler(failure))
+}
+
+// missingTokenHandler wraps a failure handler to report gologin
+// MissingFieldErrors for the token or token secret fields as ErrMissingToken
+// or ErrMissingTokenSecret.
+func missingTokenHandler(failure goji.Handler) goji.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		if req.Method != "POST" {
-			ctx = gologin.WithError(ctx, fmt.Errorf("Method not allowed"))
-			failure.ServeHTTP(ctx, w, req)
-			return
-		}
-		req.ParseForm()
-		accessToken := req.PostForm.Get(accessTokenField)
-		accessSecret := req.PostForm.Get(accessTokenSecretField)
-		err := validateToken(accessToken, accessSecret)
-		if err != nil {
-			ctx = gologin.WithError(ctx, err)
-			failure.ServeHTTP(ctx, w, req)
-			return
+		if err, ok := gologin.ErrorFromContext(ctx).(gologin.MissingFieldError); ok {
+			switch err.Field {
+			case accessTokenField:
+				ctx = gologin.WithError(ctx, ErrMissingToken)
+			case accessTokenSecretField:
+				ctx = gologin.WithError(ctx, ErrMissingTokenSecret)
+			}
 		}
-		ctx = oauth1Login.WithAccessToken(ctx, accessToken, accessSecret)
-		success.ServeHTTP(ctx, w, req)
+		failure.ServeHTTP(ctx, w, req)
 	}
 	return goji.HandlerFunc(fn)
 }
 
-// validateToken returns an error if the token or token secret is missing.
-func validateToken(token, tokenSecret string) error {
-	if token == "" {
-		return ErrMissingToken
-	}
-	if tokenSecret == "" {
-		return ErrMissingTokenSecret
-	}
-	return nil
+// verifyToken adds the posted access token/secret to the ctx. The token is
+// verified by the twitterHandler which wraps the success handler.
+func verifyToken(ctx context.Context, values map[string]string) (context.Context, error) {
+	ctx = oauth1Login.WithAccessToken(ctx, values[accessTokenField], values[accessTokenSecretField])
+	// provider requests set the gologin User-Agent
+	ctx = internal.WithUserAgentClient(ctx, oauth1.HTTPClient)
+	return ctx, nil
 }
@@ -91,8 +91,8 @@ func TestTokenHandler_NonPost(t *testing.T) {
 	assert.Nil(t, err)
 	// assert that default (nil) failure handler returns a 405 Method Not Allowed
 	if assert.NotNil(t, resp) {
-		// TODO: change to 405
-		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "POST", resp.Header.Get("Allow"))
 	}
 }
 
@@ -126,3 +126,60 @@ func TestTokenHandler_InvalidFields(t *testing.T) {
 	assert.Nil(t, err)
 	testutils.AssertBodyString(t, resp.Body, ErrMissingTokenSecret.Error()+"\n")
 }
+
+func TestTokenHandlerWithOptions_ReportAllMissing(t *testing.T) {
+	config := &oauth1.Config{}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		// assert that both missing fields are reported together
+		err := gologin.ErrorFromContext(ctx)
+		assert.Equal(t, gologin.ValidationError{Missing: []string{accessTokenField, accessTokenSecretField}}, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+	options := gologin.TokenPostOptions{ReportAllMissing: true}
+	ts := httptest.NewServer(ctxh.NewHandler(TokenHandlerWithOptions(config, options, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))))
+	defer ts.Close()
+
+	resp, err := http.PostForm(ts.URL, url.Values{})
+	assert.Nil(t, err)
+	testutils.AssertBodyString(t, resp.Body, "failure handler called")
+}
+
+func TestTokenHandlerWithOptions_BearerChallenge(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Invalid or expired token", http.StatusUnauthorized)
+	defer server.Close()
+	// oauth1 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth1.HTTPClient, proxyClient)
+
+	config := &oauth1.Config{}
+	options := gologin.TokenPostOptions{BearerChallenge: true}
+	handler := TokenHandlerWithOptions(config, options, testutils.AssertSuccessNotCalled(t), nil)
+	ts := httptest.NewServer(ctxh.NewHandlerWithContext(ctx, handler))
+	defer ts.Close()
+	// assert that a token Twitter rejects is rejected with a 401 challenge
+	resp, err := http.PostForm(ts.URL, url.Values{accessTokenField: {testTwitterToken}, accessTokenSecretField: {testTwitterTokenSecret}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, `Bearer error="invalid_token"`, resp.Header.Get("WWW-Authenticate"))
+		testutils.AssertBodyString(t, resp.Body, ErrUnableToGetTwitterUser.Error()+"\n")
+	}
+}
+
+func TestTokenHandlerWithOptions_BearerChallengeTwitterDown(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Twitter Verify Credentials Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth1 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth1.HTTPClient, proxyClient)
+
+	config := &oauth1.Config{}
+	options := gologin.TokenPostOptions{BearerChallenge: true}
+	handler := TokenHandlerWithOptions(config, options, testutils.AssertSuccessNotCalled(t), nil)
+	ts := httptest.NewServer(ctxh.NewHandlerWithContext(ctx, handler))
+	defer ts.Close()
+	// assert that Twitter API failures are not reported as invalid tokens
+	resp, err := http.PostForm(ts.URL, url.Values{accessTokenField: {testTwitterToken}, accessTokenSecretField: {testTwitterTokenSecret}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "", resp.Header.Get("WWW-Authenticate"))
+		testutils.AssertBodyString(t, resp.Body, ErrUnableToGetTwitterUser.Error()+"\n")
+	}
+}
@@ -0,0 +1,42 @@
+package twitter
+
+import (
+	"time"
+
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/quasor/gologin"
+)
+
+// providerUser wraps a Twitter User to implement the gologin provider user
+// interfaces. It is the provider-agnostic user WithUser adds to the ctx.
+type providerUser struct {
+	*twitter.User
+}
+
+// Identity returns the Twitter identity keyed by the user ID, which is
+// stable across screen name changes.
+func (u *providerUser) Identity() gologin.Identity {
+	return gologin.Identity{Provider: "twitter", ID: u.IDStr}
+}
+
+// AccountCreated returns when the Twitter account was created.
+func (u *providerUser) AccountCreated() time.Time {
+	created, _ := gologin.ParseTimestamp(u.CreatedAt, time.RubyDate)
+	return created
+}
+
+// AccountVerified returns true if Twitter has verified the account.
+func (u *providerUser) AccountVerified() bool {
+	return u.Verified
+}
+
+// EmailAddress returns the Twitter user's email address, if any. Twitter
+// only includes it for apps with the email permission.
+func (u *providerUser) EmailAddress() (string, bool) {
+	return u.User.Email, u.User.Email != ""
+}
+
+// PictureURL returns the Twitter user's HTTPS profile image URL, if any.
+func (u *providerUser) PictureURL() string {
+	return u.ProfileImageURLHttps
+}
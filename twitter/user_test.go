@@ -0,0 +1,17 @@
+package twitter
+
+import (
+	"testing"
+
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProviderUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &twitter.User{ID: 1234, IDStr: "1234", ScreenName: "gopher"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "twitter", ID: "1234"}, identity)
+}
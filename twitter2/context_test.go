@@ -0,0 +1,50 @@
+package twitter2
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "2244994945", Username: "TwitterDev"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "twitter2: Context missing Twitter User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "2244994945"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "twitter", ID: "2244994945"}, identity)
+}
+
+func TestUser_AccountCreated(t *testing.T) {
+	user := &User{CreatedAt: "2013-12-14T04:35:55.000Z", Verified: true}
+	assert.Equal(t, time.Date(2013, time.December, 14, 4, 35, 55, 0, time.UTC), user.AccountCreated())
+	assert.True(t, user.AccountVerified())
+	assert.True(t, (&User{}).AccountCreated().IsZero())
+}
+
+func TestUser_VerifiedEncodings(t *testing.T) {
+	for _, verified := range []string{`true`, `1`, `"true"`} {
+		user := new(User)
+		err := json.Unmarshal([]byte(`{"id": "2244994945", "verified": `+verified+`}`), user)
+		assert.Nil(t, err)
+		assert.True(t, user.AccountVerified(), verified)
+	}
+}
@@ -0,0 +1,6 @@
+// Package twitter2 provides Twitter OAuth2 (PKCE) login and callback
+// handlers, distinct from the OAuth1 flow of package twitter.
+//
+// Twitter requires PKCE for OAuth2, so StateHandler also issues a PKCE code
+// verifier and LoginHandler and CallbackHandler fail without one.
+package twitter2
@@ -0,0 +1,115 @@
+package twitter2
+
+import (
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/twitter"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Twitter login errors
+var (
+	// ErrUnableToGetTwitterUser is package twitter's error, so apps may
+	// handle both flows alike.
+	ErrUnableToGetTwitterUser = twitter.ErrUnableToGetTwitterUser
+)
+
+// Provider is the Twitter OAuth2 Provider for use with oauth2
+// HandleCallback. Wrap HandleCallback with StateHandler for PKCE.
+var Provider = oauth2Login.Provider{
+	Name:            "twitter2",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInHeader,
+}
+
+// StateHandler checks for state and PKCE cookies. If found, the state value
+// and PKCE code verifier are read and added to the ctx. Otherwise,
+// non-guessable values are added to the ctx and to (short-lived) cookies
+// issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection and RFC 7636 PKCE, which
+// Twitter requires.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, oauth2Login.PKCEHandler(config, success))
+}
+
+// LoginHandler handles Twitter login requests by reading the state value and
+// PKCE code verifier from the ctx and redirecting requests to the AuthURL
+// with the state value and code challenge.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	return requirePKCE(oauth2Login.LoginHandler(config, failure), failure)
+}
+
+// CallbackHandler handles Twitter redirection URI requests and adds the
+// Twitter access token and User to the ctx. The PKCE code verifier from the
+// ctx is sent with the token request. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = twitterHandler(config, success, failure)
+	return requirePKCE(oauth2Login.CallbackHandler(config, success, failure), failure)
+}
+
+// requirePKCE is a ContextHandler which calls the failure handler if the ctx
+// has no PKCE code verifier. Otherwise, the success handler is called.
+func requirePKCE(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if _, err := oauth2Login.PKCEVerifierFromContext(ctx); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// twitterHandler is a ContextHandler that gets the OAuth2 Token from the ctx
+// to get the corresponding Twitter User. If successful, the User is added to
+// the ctx and the success handler is called. Otherwise, the failure handler
+// is called.
+func twitterHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		twitterClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		twitterClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		user, resp, err := twitterClient.Me()
+		err = validateResponse(user, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, user)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given Twitter User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetTwitterUser
+	}
+	if user == nil || user.ID == "" {
+		return ErrUnableToGetTwitterUser
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package twitter2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestLoginHandler(t *testing.T) {
+	config := &oauth2.Config{ClientID: "client-id", Endpoint: Endpoint}
+
+	// StateHandler and LoginHandler, assert that:
+	// - state and PKCE cookies are issued
+	// - the redirect url has the state and S256 code challenge
+	handler := StateHandler(gologin.DebugOnlyCookieConfig, LoginHandler(config, testutils.AssertFailureNotCalled(t)))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/login", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Len(t, w.Result().Cookies(), 2)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "twitter.com", location.Host)
+	assert.NotEmpty(t, location.Query().Get("state"))
+	assert.NotEmpty(t, location.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+}
+
+func TestCallbackHandler(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	jsonData := `{"data": {"id": "2244994945", "name": "Twitter Dev", "username": "TwitterDev", "description": "The voice of the X Dev team", "profile_image_url": "https://pbs.twimg.com/profile_images/dev_normal.jpg", "verified": true, "created_at": "2013-12-14T04:35:55.000Z"}}`
+	expectedUser := &User{
+		ID:              "2244994945",
+		Name:            "Twitter Dev",
+		Username:        "TwitterDev",
+		Description:     "The voice of the X Dev team",
+		ProfileImageURL: "https://pbs.twimg.com/profile_images/dev_normal.jpg",
+		Verified:        true,
+		CreatedAt:       "2013-12-14T04:35:55.000Z",
+	}
+	proxyClient, server := newTwitterTestServer(verifier, jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+	ctx = oauth2Login.WithPKCEVerifier(ctx, verifier)
+
+	config := &oauth2.Config{ClientID: "client-id", Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		twitterUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, twitterUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler with a ctx PKCE code verifier, assert that:
+	// - the code verifier is sent with the token request
+	// - the v2 Twitter User is added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_MissingPKCE(t *testing.T) {
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+	config := &oauth2.Config{Endpoint: Endpoint}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "oauth2: Context missing PKCE verifier", gologin.ErrorFromContext(ctx).Error())
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler without a ctx PKCE code verifier, assert that:
+	// - failure handler is called before exchanging the code
+	handler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestTwitterHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Twitter Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetTwitterUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TwitterHandler cannot get Twitter User, assert that:
+	// - failure handler is called
+	// - error cannot get Twitter User added to the failure handler ctx
+	twitterHandler := twitterHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	twitterHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "2244994945"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateResponse(nil, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateResponse(&User{}, validResponse, nil))
+}
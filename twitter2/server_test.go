@@ -0,0 +1,35 @@
+package twitter2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newTwitterTestServer returns a new httptest.Server which mocks the Twitter
+// OAuth2 token endpoint, which requires the given PKCE code verifier, and
+// the users/me endpoint, which responds with the given json data. It also
+// returns a client which proxies requests to the server. The caller must
+// close the server.
+func newTwitterTestServer(verifier, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/2/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code_verifier") != verifier {
+			http.Error(w, `{"error": "invalid_request", "error_description": "Value passed for the authorization code was invalid."}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token_type": "bearer", "expires_in": 7200, "access_token": "twitter-token", "scope": "users.read tweet.read"}`)
+	})
+	mux.HandleFunc("/2/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer twitter-token" {
+			http.Error(w, `{"title": "Unauthorized", "status": 401}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,99 @@
+package twitter2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const twitterAPI = "https://api.twitter.com/2/"
+
+// userFields are the v2 user fields requested in addition to the defaults.
+const userFields = "created_at,description,profile_image_url,verified"
+
+// Endpoint is the Twitter OAuth2 endpoint. Confidential clients send client
+// credentials with HTTP Basic auth.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://twitter.com/i/oauth2/authorize",
+	TokenURL:  "https://api.twitter.com/2/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInHeader,
+}
+
+func init() {
+	gologin.RegisterEndpoint("twitter2", Endpoint)
+}
+
+// User is a Twitter API v2 user.
+type User struct {
+	ID              string               `json:"id"`
+	Name            string               `json:"name"`
+	Username        string               `json:"username"`
+	Description     string               `json:"description"`
+	ProfileImageURL string               `json:"profile_image_url"`
+	Verified        gologin.FlexibleBool `json:"verified"`
+	CreatedAt       string               `json:"created_at"`
+}
+
+// AccountCreated returns when the Twitter account was created.
+func (u *User) AccountCreated() time.Time {
+	created, _ := gologin.ParseTimestamp(u.CreatedAt, time.RFC3339)
+	return created
+}
+
+// AccountVerified returns true if Twitter has verified the account.
+func (u *User) AccountVerified() bool {
+	return bool(u.Verified)
+}
+
+// Identity returns the Twitter identity keyed by the user ID. Twitter user
+// IDs are the same in OAuth1 and OAuth2, so the identity matches package
+// twitter's.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: "twitter", ID: u.ID}
+}
+
+// PictureURL returns the Twitter user's profile image URL, if any.
+func (u *User) PictureURL() string {
+	return u.ProfileImageURL
+}
+
+// userResponse is a Twitter API v2 user lookup response.
+type userResponse struct {
+	Data *User `json:"data"`
+}
+
+// userParams are the users/me query parameters.
+type userParams struct {
+	UserFields string `url:"user.fields"`
+}
+
+// client is a Twitter API v2 client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(twitterAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User.
+// https://developer.twitter.com/en/docs/twitter-api/users/lookup/api-reference/get-users-me
+func (c *client) Me() (*User, *http.Response, error) {
+	userResp := new(userResponse)
+	params := &userParams{UserFields: userFields}
+	resp, err := c.sling.New().Get(c.userInfoURL).QueryStruct(params).ReceiveSuccess(userResp)
+	return userResp.Data, resp, err
+}
@@ -0,0 +1,192 @@
+package gologin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// Errors which may occur when checking provider users.
+var (
+	ErrAccountSuspended   = errors.New("gologin: provider account is suspended")
+	ErrMissingIdentity    = errors.New("gologin: provider user has no identity")
+	ErrAccountTooNew      = errors.New("gologin: provider account is too new")
+	ErrAccountNotVerified = errors.New("gologin: provider account is not verified")
+)
+
+// Identity is a canonical (provider, id) pair identifying a provider user.
+// The ID is the provider's stable identifier (e.g. Github's numeric ID, not
+// the login, which users may rename), so it is suitable for keying linked
+// accounts.
+type Identity struct {
+	Provider string
+	ID       string
+}
+
+// String returns the identity as "provider:id".
+func (i Identity) String() string {
+	return i.Provider + ":" + i.ID
+}
+
+// Identifiable is implemented by provider users which have a stable provider
+// identifier.
+type Identifiable interface {
+	Identity() Identity
+}
+
+// IdentityFromContext returns the Identity of the provider user in the ctx.
+func IdentityFromContext(ctx context.Context) (Identity, error) {
+	user, err := UserFromContext(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	i, ok := user.(Identifiable)
+	if !ok || i.Identity().ID == "" {
+		return Identity{}, ErrMissingIdentity
+	}
+	return i.Identity(), nil
+}
+
+// Suspendable is implemented by provider users which report whether the
+// provider has suspended or disabled the account.
+type Suspendable interface {
+	Suspended() bool
+}
+
+// RejectSuspended reads the provider user from the ctx and calls the failure
+// handler with ErrAccountSuspended if the provider reports the account as
+// suspended. Users which do not implement Suspendable are passed through to
+// the success handler.
+func RejectSuspended(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		if s, ok := user.(Suspendable); ok && s.Suspended() {
+			ctx = WithError(ctx, ErrAccountSuspended)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// Aged is implemented by provider users which report when the provider
+// account was created. The github, twitter, twitter2, twitch, heroku, fitbit,
+// stripe, zoom, and bitbucket users implement it.
+type Aged interface {
+	AccountCreated() time.Time
+}
+
+// Verifiable is implemented by provider users which report whether the
+// provider has verified the account. The google (verified email), twitter,
+// and twitter2 (verified account) users implement it.
+type Verifiable interface {
+	AccountVerified() bool
+}
+
+// now returns the current time for RequireAccountAge. Tests may replace it.
+// The internal DefaultClock can't be used since internal imports gologin.
+var now = time.Now
+
+// RequireAccountAge reads the provider user from the ctx and calls the
+// failure handler with ErrAccountTooNew unless the provider account was
+// created at least minAge ago. Users which do not implement Aged, or whose
+// creation time is unknown, are rejected too, since their age cannot be
+// checked.
+func RequireAccountAge(minAge time.Duration, success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		a, ok := user.(Aged)
+		if !ok || a.AccountCreated().IsZero() || now().Sub(a.AccountCreated()) < minAge {
+			ctx = WithError(ctx, ErrAccountTooNew)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// RequireVerified reads the provider user from the ctx and calls the failure
+// handler with ErrAccountNotVerified unless the provider reports the account
+// as verified. Users which do not implement Verifiable are rejected too.
+func RequireVerified(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		if v, ok := user.(Verifiable); !ok || !v.AccountVerified() {
+			ctx = WithError(ctx, ErrAccountNotVerified)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// Emailer is implemented by provider users which may have an email address.
+// EmailAddress returns the address and whether the user has one. Whether
+// providers verify the address varies, see RequireVerified.
+type Emailer interface {
+	EmailAddress() (string, bool)
+}
+
+// UserEmail returns the email address of the provider user in the ctx. It
+// returns false if there is no provider user or it has no email address.
+func UserEmail(ctx context.Context) (string, bool) {
+	user, err := UserFromContext(ctx)
+	if err != nil {
+		return "", false
+	}
+	e, ok := user.(Emailer)
+	if !ok {
+		return "", false
+	}
+	return e.EmailAddress()
+}
+
+// Pictured is implemented by provider users which may have a profile picture
+// or avatar. PictureURL returns the image URL or "" if the user has none.
+type Pictured interface {
+	PictureURL() string
+}
+
+// UserPicture returns the profile picture URL of the provider user in the
+// ctx. It returns false if there is no provider user or it has no picture.
+func UserPicture(ctx context.Context) (string, bool) {
+	user, err := UserFromContext(ctx)
+	if err != nil {
+		return "", false
+	}
+	p, ok := user.(Pictured)
+	if !ok || p.PictureURL() == "" {
+		return "", false
+	}
+	return p.PictureURL(), true
+}
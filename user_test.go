@@ -0,0 +1,233 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goji.io"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type suspendableUser struct {
+	suspended bool
+}
+
+func (u suspendableUser) Suspended() bool {
+	return u.suspended
+}
+
+func TestRejectSuspended(t *testing.T) {
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrAccountSuspended, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := RejectSuspended(goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	cases := []struct {
+		user     interface{}
+		expected string
+	}{
+		{suspendableUser{suspended: false}, "success handler called"},
+		{suspendableUser{suspended: true}, "failure handler called"},
+		{struct{}{}, "success handler called"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(WithUser(context.Background(), c.user), w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+func TestRejectSuspended_MissingCtxUser(t *testing.T) {
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "Context missing provider user", err.Error())
+		}
+	}
+	handler := RejectSuspended(success, goji.HandlerFunc(failure))
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), httptest.NewRecorder(), req)
+}
+
+type identifiableUser struct {
+	id string
+}
+
+func (u identifiableUser) Identity() Identity {
+	return Identity{Provider: "example", ID: u.id}
+}
+
+func TestIdentity_String(t *testing.T) {
+	assert.Equal(t, "github:917408", Identity{Provider: "github", ID: "917408"}.String())
+}
+
+func TestIdentityFromContext(t *testing.T) {
+	ctx := WithUser(context.Background(), identifiableUser{id: "42"})
+	identity, err := IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, Identity{Provider: "example", ID: "42"}, identity)
+}
+
+func TestIdentityFromContext_Error(t *testing.T) {
+	cases := []context.Context{
+		context.Background(),
+		WithUser(context.Background(), struct{}{}),
+		WithUser(context.Background(), identifiableUser{}),
+	}
+	for _, ctx := range cases {
+		identity, err := IdentityFromContext(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, Identity{}, identity)
+	}
+}
+
+type agedUser struct {
+	created  time.Time
+	verified bool
+}
+
+func (u agedUser) AccountCreated() time.Time {
+	return u.created
+}
+
+func (u agedUser) AccountVerified() bool {
+	return u.verified
+}
+
+// withFixedNow sets the RequireAccountAge time to the given time and returns
+// a func to restore it.
+func withFixedNow(fixed time.Time) func() {
+	original := now
+	now = func() time.Time { return fixed }
+	return func() { now = original }
+}
+
+func TestRequireAccountAge(t *testing.T) {
+	fixedNow := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	defer withFixedNow(fixedNow)()
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrAccountTooNew, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	minAge := 30 * 24 * time.Hour
+	handler := RequireAccountAge(minAge, goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	cases := []struct {
+		user     interface{}
+		expected string
+	}{
+		// old account
+		{agedUser{created: fixedNow.Add(-365 * 24 * time.Hour)}, "success handler called"},
+		// exactly the minimum age
+		{agedUser{created: fixedNow.Add(-minAge)}, "success handler called"},
+		// too new account
+		{agedUser{created: fixedNow.Add(-minAge + time.Second)}, "failure handler called"},
+		{agedUser{created: fixedNow.Add(-24 * time.Hour)}, "failure handler called"},
+		// unknown creation time
+		{agedUser{}, "failure handler called"},
+		{struct{}{}, "failure handler called"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(WithUser(context.Background(), c.user), w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+func TestRequireVerified(t *testing.T) {
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrAccountNotVerified, ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := RequireVerified(goji.HandlerFunc(success), goji.HandlerFunc(failure))
+	cases := []struct {
+		user     interface{}
+		expected string
+	}{
+		{agedUser{verified: true}, "success handler called"},
+		{agedUser{verified: false}, "failure handler called"},
+		{struct{}{}, "failure handler called"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(WithUser(context.Background(), c.user), w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+}
+
+type emailerUser struct {
+	email string
+}
+
+func (u emailerUser) EmailAddress() (string, bool) {
+	return u.email, u.email != ""
+}
+
+func TestUserEmail(t *testing.T) {
+	cases := []struct {
+		user          interface{}
+		expectedEmail string
+		expectedOK    bool
+	}{
+		{emailerUser{"ada@example.com"}, "ada@example.com", true},
+		{emailerUser{}, "", false},
+		{identifiableUser{"1"}, "", false},
+		{"example-user", "", false},
+	}
+	for _, c := range cases {
+		email, ok := UserEmail(WithUser(context.Background(), c.user))
+		assert.Equal(t, c.expectedEmail, email)
+		assert.Equal(t, c.expectedOK, ok)
+	}
+	// assert false if the ctx has no provider user
+	email, ok := UserEmail(context.Background())
+	assert.Equal(t, "", email)
+	assert.False(t, ok)
+}
+
+type picturedUser struct {
+	url string
+}
+
+func (u picturedUser) PictureURL() string {
+	return u.url
+}
+
+func TestUserPicture(t *testing.T) {
+	cases := []struct {
+		user            interface{}
+		expectedPicture string
+		expectedOK      bool
+	}{
+		{picturedUser{"https://example.com/ada.png"}, "https://example.com/ada.png", true},
+		{picturedUser{}, "", false},
+		{identifiableUser{"1"}, "", false},
+		{"example-user", "", false},
+	}
+	for _, c := range cases {
+		picture, ok := UserPicture(WithUser(context.Background(), c.user))
+		assert.Equal(t, c.expectedPicture, picture)
+		assert.Equal(t, c.expectedOK, ok)
+	}
+	// assert false if the ctx has no provider user
+	picture, ok := UserPicture(context.Background())
+	assert.Equal(t, "", picture)
+	assert.False(t, ok)
+}
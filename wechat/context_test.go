@@ -0,0 +1,38 @@
+package wechat
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{OpenID: "oGZUI0egBJY1zhBYw2KhdUfwVJJE", Nickname: "Bob"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "wechat: Context missing WeChat User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{OpenID: "oGZUI0egBJY1zhBYw2KhdUfwVJJE", UnionID: "o6_bmasdasdsad6_2sgVt7hMZOPfL"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "wechat", ID: "o6_bmasdasdsad6_2sgVt7hMZOPfL"}, identity)
+
+	// users without a unionid are identified by openid
+	ctx = WithUser(context.Background(), &User{OpenID: "oGZUI0egBJY1zhBYw2KhdUfwVJJE"})
+	identity, err = gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "wechat", ID: "oGZUI0egBJY1zhBYw2KhdUfwVJJE"}, identity)
+}
@@ -0,0 +1,6 @@
+// Package wechat provides WeChat OAuth2 login and callback handlers.
+//
+// WeChat token responses carry the user's openid, and the user info API takes
+// the access token and openid as query parameters. WeChat reports API errors
+// as an errcode in an HTTP 200 response.
+package wechat
@@ -0,0 +1,140 @@
+package wechat
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// authCodeURL returns the WeChat authorize URL for the state. WeChat takes
+// the client ID as appid, comma separated scopes (defaulting to
+// snsapi_login), and requires the #wechat_redirect fragment.
+// https://developers.weixin.qq.com/doc/oplatform/en/Website_App/WeChat_Login/Wechat_Login.html
+func authCodeURL(config *oauth2.Config, scopes []string, state string) string {
+	if len(scopes) == 0 {
+		scopes = []string{"snsapi_login"}
+	}
+	params := url.Values{
+		"appid":         {config.ClientID},
+		"redirect_uri":  {config.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(scopes, ",")},
+		"state":         {state},
+	}
+	authURL := config.Endpoint.AuthURL
+	if strings.Contains(authURL, "?") {
+		authURL += "&"
+	} else {
+		authURL += "?"
+	}
+	return authURL + params.Encode() + "#wechat_redirect"
+}
+
+// withTokenTransport returns a copy of ctx whose oauth2 HTTPClient rewrites
+// token requests to the config TokenURL into WeChat's token request.
+func withTokenTransport(ctx context.Context, config *oauth2.Config) context.Context {
+	client, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	wrapped.Transport = &tokenTransport{tokenURL: config.Endpoint.TokenURL, base: client.Transport}
+	return context.WithValue(ctx, oauth2.HTTPClient, &wrapped)
+}
+
+// tokenTransport is an http.RoundTripper which rewrites the standard OAuth2
+// token request (a POST with client_id and client_secret in the body) into
+// WeChat's token request, a GET with appid and secret query parameters.
+// WeChat token responses are JSON, but may be labeled as text/plain, so the
+// response Content-Type is corrected.
+// https://developers.weixin.qq.com/doc/oplatform/en/Website_App/WeChat_Login/Wechat_Login.html
+type tokenTransport struct {
+	tokenURL string
+	base     http.RoundTripper
+}
+
+// RoundTrip rewrites requests to the token URL and calls through to the base
+// RoundTripper (or http.DefaultTransport if nil).
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "POST" || !isTokenURL(req.URL, t.tokenURL) {
+		return t.transport().RoundTrip(req)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{
+		"appid":      {form.Get("client_id")},
+		"secret":     {form.Get("client_secret")},
+		"code":       {form.Get("code")},
+		"grant_type": {"authorization_code"},
+	}
+	u := *req.URL
+	u.RawQuery = params.Encode()
+	r, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Header {
+		if k != "Content-Type" && k != "Authorization" {
+			r.Header[k] = v
+		}
+	}
+	resp, err := t.transport().RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	return jsonTokenResponse(resp)
+}
+
+func (t *tokenTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// isTokenURL returns true if u is the token URL, ignoring query parameters.
+func isTokenURL(u *url.URL, tokenURL string) bool {
+	token, err := url.Parse(tokenURL)
+	if err != nil {
+		return false
+	}
+	return u.Host == token.Host && u.Path == token.Path
+}
+
+// tokenError is a WeChat error response.
+type tokenError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// jsonTokenResponse labels the WeChat token response as JSON. Since WeChat
+// reports errors with an HTTP 200 status and an errcode, error responses are
+// given a 400 status so they are reported as token errors.
+func jsonTokenResponse(resp *http.Response) (*http.Response, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.Header.Set("Content-Type", "application/json")
+	tokenErr := new(tokenError)
+	if json.Unmarshal(body, tokenErr) == nil && tokenErr.ErrCode != 0 {
+		resp.StatusCode = http.StatusBadRequest
+		resp.Status = http.StatusText(http.StatusBadRequest)
+	}
+	return resp, nil
+}
@@ -0,0 +1,133 @@
+package wechat
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// WeChat login errors
+var (
+	ErrUnableToGetWechatUser = errors.New("wechat: unable to get WeChat User")
+)
+
+// Provider is the WeChat OAuth2 Provider for use with oauth2 HandleCallback.
+var Provider = oauth2Login.Provider{
+	Name:            "wechat",
+	CallbackHandler: CallbackHandler,
+	AuthStyle:       oauth2.AuthStyleInParams,
+}
+
+// StateHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a ContextHandler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func StateHandler(config gologin.CookieConfig, success goji.Handler) goji.Handler {
+	return oauth2Login.StateHandler(config, success)
+}
+
+// LoginHandler handles WeChat login requests by reading the state value from
+// the ctx and redirecting requests to the AuthURL with that state value.
+// WeChat expects the config ClientID as the appid parameter and requires a
+// #wechat_redirect fragment, so the AuthURL is built here rather than by the
+// oauth2 LoginHandler.
+func LoginHandler(config *oauth2.Config, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		state, err := oauth2Login.StateFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		scopes := config.Scopes
+		if ctxScopes := gologin.ScopesFromContext(ctx); ctxScopes != nil {
+			scopes = ctxScopes
+		}
+		http.Redirect(w, req, authCodeURL(config, scopes, state), http.StatusFound)
+	}
+	return gologin.MethodHandler([]string{"GET"}, goji.HandlerFunc(fn), failure)
+}
+
+// CallbackHandler handles WeChat redirection URI requests and adds the
+// WeChat access token and User to the ctx. If authentication succeeds,
+// handling delegates to the success handler, otherwise to the failure
+// handler.
+//
+// WeChat's token endpoint expects a GET request with appid and secret query
+// parameters, so the token exchange is rewritten for the config TokenURL.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	config = oauth2Login.Provider{AuthStyle: oauth2.AuthStyleInParams}.Configure(config)
+	success = wechatHandler(success, failure)
+	callback := oauth2Login.CallbackHandler(config, success, failure)
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		callback.ServeHTTP(withTokenTransport(ctx, config), w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// wechatHandler is a ContextHandler that gets the OAuth2 Token from the ctx
+// and reads its openid to get the corresponding WeChat User. If successful,
+// the User is added to the ctx and the success handler is called. Otherwise,
+// the failure handler is called.
+func wechatHandler(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		openID, ok := token.Extra("openid").(string)
+		if !ok || openID == "" {
+			ctx = gologin.WithError(ctx, ErrUnableToGetWechatUser)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		// the access token is a query parameter, not an Authorization header
+		httpClient, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		wechatClient := newClient(httpClient, gologin.UserInfoURLFromContext(ctx))
+		wechatClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		userInfo, resp, err := wechatClient.UserInfo(token.AccessToken, openID)
+		err = validateResponse(userInfo, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithUser(ctx, &userInfo.User)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// validateResponse returns an error if the given WeChat user info, raw
+// http.Response, or error are unexpected. WeChat responds with HTTP 200 and a
+// non-zero errcode on failure. Returns nil if they are valid.
+func validateResponse(userInfo *userInfoResponse, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetWechatUser
+	}
+	if userInfo == nil || userInfo.ErrCode != 0 || userInfo.OpenID == "" {
+		return ErrUnableToGetWechatUser
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package wechat
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"openid": "oGZUI0egBJY1zhBYw2KhdUfwVJJE", "nickname": "Bob", "sex": 1, "province": "Guangdong", "city": "Shenzhen", "country": "CN", "headimgurl": "https://thirdwx.qlogo.cn/mmopen/bob/0", "privilege": [], "unionid": "o6_bmasdasdsad6_2sgVt7hMZOPfL"}`
+	expectedUser := &User{
+		OpenID:     "oGZUI0egBJY1zhBYw2KhdUfwVJJE",
+		UnionID:    "o6_bmasdasdsad6_2sgVt7hMZOPfL",
+		Nickname:   "Bob",
+		Sex:        1,
+		Province:   "Guangdong",
+		City:       "Shenzhen",
+		Country:    "CN",
+		HeadImgURL: "https://thirdwx.qlogo.cn/mmopen/bob/0",
+		Privilege:  []string{},
+	}
+	proxyClient, server := newWechatTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: "wechat-appid", ClientSecret: "wechat-secret", Endpoint: Endpoint}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		wechatUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, wechatUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler gets a token with an openid, assert that:
+	// - the token request sends the appid and secret as query parameters
+	// - the access token and openid are sent as query parameters
+	// - the WeChat User is added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestLoginHandler(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID:    "wechat-appid",
+		RedirectURL: "https://example.com/wechat/callback",
+		Endpoint:    Endpoint,
+	}
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// LoginHandler redirects to the WeChat AuthURL, assert that:
+	// - the ClientID is sent as the appid parameter
+	// - the default snsapi_login scope is requested
+	// - the URL ends with the #wechat_redirect fragment
+	handler := LoginHandler(config, failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "open.weixin.qq.com", location.Host)
+	assert.Equal(t, "/connect/qrconnect", location.Path)
+	assert.Equal(t, "wechat_redirect", location.Fragment)
+	query := location.Query()
+	assert.Equal(t, "wechat-appid", query.Get("appid"))
+	assert.Equal(t, "", query.Get("client_id"))
+	assert.Equal(t, "https://example.com/wechat/callback", query.Get("redirect_uri"))
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "snsapi_login", query.Get("scope"))
+	assert.Equal(t, "d4e5f6", query.Get("state"))
+}
+
+func TestLoginHandler_MissingCtxState(t *testing.T) {
+	config := &oauth2.Config{ClientID: "wechat-appid", Endpoint: Endpoint}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing state value", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LoginHandler cannot get the state from the ctx, assert that:
+	// - failure handler is called
+	handler := LoginHandler(config, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestCallbackHandler_TokenRequest(t *testing.T) {
+	var tokenRequest *http.Request
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequest = r
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, `{"errcode": 40029, "errmsg": "invalid code"}`)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	config := &oauth2.Config{ClientID: "wechat-appid", ClientSecret: "wechat-secret", Endpoint: Endpoint}
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.NotNil(t, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CallbackHandler exchanges the code, assert that:
+	// - the token endpoint receives a GET with appid, secret, code, and grant_type
+	// - no client_id, client_secret, or Authorization header is sent
+	// - a token response with an errcode calls the failure handler
+	handler := CallbackHandler(config, testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	if assert.NotNil(t, tokenRequest) {
+		assert.Equal(t, "GET", tokenRequest.Method)
+		query := tokenRequest.URL.Query()
+		assert.Equal(t, "wechat-appid", query.Get("appid"))
+		assert.Equal(t, "wechat-secret", query.Get("secret"))
+		assert.Equal(t, "any_code", query.Get("code"))
+		assert.Equal(t, "authorization_code", query.Get("grant_type"))
+		assert.Equal(t, "", query.Get("client_id"))
+		assert.Equal(t, "", query.Get("client_secret"))
+		assert.Equal(t, "", tokenRequest.Header.Get("Authorization"))
+	}
+}
+
+func TestWechatHandler_ErrCode(t *testing.T) {
+	proxyClient, server := newWechatTestServer("")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	token := (&oauth2.Token{AccessToken: "expired-token"}).WithExtra(map[string]interface{}{
+		"openid": "oGZUI0egBJY1zhBYw2KhdUfwVJJE",
+	})
+	ctx = oauth2Login.WithToken(ctx, token)
+
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetWechatUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// WechatHandler gets an HTTP 200 response with an errcode, assert that:
+	// - failure handler is called
+	wechatHandler := wechatHandler(testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	wechatHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestWechatHandler_MissingOpenID(t *testing.T) {
+	ctx := oauth2Login.WithToken(context.Background(), &oauth2.Token{AccessToken: "wechat-token"})
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, ErrUnableToGetWechatUser, gologin.ErrorFromContext(ctx))
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// WechatHandler with a token without an openid, assert that:
+	// - failure handler is called
+	wechatHandler := wechatHandler(testutils.AssertSuccessNotCalled(t), goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	wechatHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestWechatHandler_MissingCtxToken(t *testing.T) {
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// WechatHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	wechatHandler := wechatHandler(success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	wechatHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUserInfo := &userInfoResponse{User: User{OpenID: "oGZUI0egBJY1zhBYw2KhdUfwVJJE"}}
+	errUserInfo := &userInfoResponse{ErrCode: 40003, ErrMsg: "invalid openid"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUserInfo, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetWechatUser, validateResponse(validUserInfo, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetWechatUser, validateResponse(validUserInfo, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetWechatUser, validateResponse(errUserInfo, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetWechatUser, validateResponse(&userInfoResponse{}, validResponse, nil))
+}
@@ -0,0 +1,41 @@
+package wechat
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newWechatTestServer returns a new httptest.Server which mocks the WeChat
+// token endpoint, which responds with a token if the appid, secret, and code
+// query parameters are sent, and the user info endpoint, which responds with the given
+// json data if the access_token and openid query parameters are sent. It
+// also returns a client which proxies requests to the server. The caller
+// must close the server.
+func newWechatTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+		// WeChat labels JSON token responses as text/plain
+		w.Header().Set("Content-Type", "text/plain")
+		query := r.URL.Query()
+		if r.Method != "GET" || query.Get("appid") != "wechat-appid" || query.Get("secret") != "wechat-secret" || query.Get("code") != "any_code" || query.Get("grant_type") != "authorization_code" || query.Get("client_id") != "" {
+			// WeChat reports errors with an HTTP 200 status
+			fmt.Fprintf(w, `{"errcode": 40029, "errmsg": "invalid code"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "wechat-token", "expires_in": 7200, "refresh_token": "wechat-refresh", "openid": "oGZUI0egBJY1zhBYw2KhdUfwVJJE", "scope": "snsapi_login"}`)
+	})
+	mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query()
+		if query.Get("access_token") != "wechat-token" || query.Get("openid") != "oGZUI0egBJY1zhBYw2KhdUfwVJJE" {
+			// WeChat reports errors with an HTTP 200 status
+			fmt.Fprintf(w, `{"errcode": 40003, "errmsg": "invalid openid"}`)
+			return
+		}
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
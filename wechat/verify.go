@@ -0,0 +1,92 @@
+package wechat
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const wechatAPI = "https://api.weixin.qq.com/sns/"
+
+// Endpoint is the WeChat website (QR code) login OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://open.weixin.qq.com/connect/qrconnect",
+	TokenURL:  "https://api.weixin.qq.com/sns/oauth2/access_token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+func init() {
+	gologin.RegisterEndpoint("wechat", Endpoint)
+}
+
+// User is a WeChat user.
+type User struct {
+	OpenID     string   `json:"openid"`
+	UnionID    string   `json:"unionid"`
+	Nickname   string   `json:"nickname"`
+	Sex        int      `json:"sex"`
+	Province   string   `json:"province"`
+	City       string   `json:"city"`
+	Country    string   `json:"country"`
+	HeadImgURL string   `json:"headimgurl"`
+	Privilege  []string `json:"privilege"`
+}
+
+// Identity returns the WeChat identity keyed by the unionid, which is shared
+// by an account's apps, or by the app-specific openid if there is none.
+func (u *User) Identity() gologin.Identity {
+	id := u.UnionID
+	if id == "" {
+		id = u.OpenID
+	}
+	return gologin.Identity{Provider: Provider.Name, ID: id}
+}
+
+// PictureURL returns the WeChat user's head image URL, if any.
+func (u *User) PictureURL() string {
+	return u.HeadImgURL
+}
+
+// userInfoResponse is a WeChat user info response, which has an errcode
+// instead of user fields on failure.
+type userInfoResponse struct {
+	User
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// userInfoParams are the WeChat user info query parameters.
+type userInfoParams struct {
+	AccessToken string `url:"access_token"`
+	OpenID      string `url:"openid"`
+}
+
+// client is a WeChat client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(wechatAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "userinfo"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// UserInfo gets the User with the given openid.
+// https://developers.weixin.qq.com/doc/oplatform/en/Website_App/WeChat_Login/Authorized_Interface_Calling_UnionID.html
+func (c *client) UserInfo(accessToken, openID string) (*userInfoResponse, *http.Response, error) {
+	userInfo := new(userInfoResponse)
+	params := &userInfoParams{AccessToken: accessToken, OpenID: openID}
+	resp, err := c.sling.New().Get(c.userInfoURL).QueryStruct(params).ReceiveSuccess(userInfo)
+	return userInfo, resp, err
+}
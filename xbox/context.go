@@ -0,0 +1,28 @@
+package xbox
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	profileKey key = iota
+)
+
+// WithProfile returns a copy of ctx that stores the Xbox Profile.
+func WithProfile(ctx context.Context, profile *Profile) context.Context {
+	return context.WithValue(ctx, profileKey, profile)
+}
+
+// ProfileFromContext returns the Xbox Profile from the ctx.
+func ProfileFromContext(ctx context.Context) (*Profile, error) {
+	profile, ok := ctx.Value(profileKey).(*Profile)
+	if !ok {
+		return nil, fmt.Errorf("xbox: Context missing Xbox Profile")
+	}
+	return profile, nil
+}
@@ -0,0 +1,30 @@
+package xbox
+
+import (
+	"testing"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextProfile(t *testing.T) {
+	expectedProfile := &Profile{XUID: "2535405290370290", Gamertag: "MajorNelson"}
+	ctx := WithProfile(context.Background(), expectedProfile)
+	profile, err := ProfileFromContext(ctx)
+	assert.Equal(t, expectedProfile, profile)
+	assert.Nil(t, err)
+}
+
+func TestContextProfile_Error(t *testing.T) {
+	profile, err := ProfileFromContext(context.Background())
+	assert.Nil(t, profile)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "xbox: Context missing Xbox Profile", err.Error())
+	}
+}
+
+func TestProfile_Identity(t *testing.T) {
+	profile := &Profile{XUID: "2535405290370290"}
+	assert.Equal(t, gologin.Identity{Provider: "xbox", ID: "2535405290370290"}, profile.Identity())
+}
@@ -0,0 +1,8 @@
+// Package xbox resolves the Xbox Live gamertag of a Microsoft personal
+// account after Microsoft (live) login, for game backends.
+//
+// Resolution exchanges the Microsoft access token for an Xbox Live user
+// token, the user token for an XSTS token, and the XSTS token for the
+// gamertag profile setting. The access token must be granted the
+// XboxLive.signin scope.
+package xbox
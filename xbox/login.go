@@ -0,0 +1,61 @@
+package xbox
+
+import (
+	"errors"
+	"net/http"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// Xbox errors
+var (
+	ErrUnableToGetXboxProfile = errors.New("xbox: unable to get Xbox Profile")
+)
+
+// CallbackHandler handles Microsoft redirection URI requests and adds the
+// Microsoft access token and the Xbox Profile to the ctx. If authentication
+// succeeds, handling delegates to the success handler, otherwise to the
+// failure handler.
+//
+// To also get the Microsoft account User, wrap a ProfileHandler with the
+// live CallbackHandler instead.
+func CallbackHandler(config *oauth2.Config, success, failure goji.Handler) goji.Handler {
+	success = ProfileHandler(success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// ProfileHandler is a ContextHandler that gets the Microsoft OAuth2 Token
+// from the ctx to resolve the Xbox Profile. If successful, the Profile is
+// added to the ctx and the success handler is called. Otherwise, the failure
+// handler is called with ErrUnableToGetXboxProfile.
+func ProfileHandler(success, failure goji.Handler) goji.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		// Xbox Live sends the access token in request bodies, not as a Bearer
+		httpClient, _ := internal.WithUserAgentClient(ctx, oauth2.HTTPClient).Value(oauth2.HTTPClient).(*http.Client)
+		xboxClient := newClient(httpClient)
+		xboxClient.sling.ResponseDecoder(internal.NewJSONDecoder(ctx))
+		profile, err := xboxClient.Profile(token.AccessToken)
+		if err != nil || profile.XUID == "" || profile.Gamertag == "" {
+			ctx = gologin.WithError(ctx, ErrUnableToGetXboxProfile)
+			failure.ServeHTTP(ctx, w, req)
+			return
+		}
+		ctx = WithProfile(ctx, profile)
+		success.ServeHTTP(ctx, w, req)
+	}
+	return goji.HandlerFunc(fn)
+}
@@ -0,0 +1,99 @@
+package xbox
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const profileJSON = `{"profileUsers": [{"id": "2535405290370290", "hostId": "2535405290370290", "settings": [{"id": "Gamertag", "value": "MajorNelson"}], "isSponsoredUser": false}]}`
+
+func TestProfileHandler(t *testing.T) {
+	proxyClient, server := newXboxTestServer(profileJSON, "")
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	expectedProfile := &Profile{XUID: "2535405290370290", Gamertag: "MajorNelson"}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		profile, err := ProfileFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedProfile, profile)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// ProfileHandler assert that:
+	// - the access token is exchanged for a user token, then an XSTS token
+	// - the gamertag profile setting is fetched with the XSTS token
+	// - the Xbox Profile is added to the ctx of the success handler
+	handler := ProfileHandler(goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestProfileHandler_MissingCtxToken(t *testing.T) {
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// ProfileHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	handler := ProfileHandler(success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestProfileHandler_ExchangeErrors(t *testing.T) {
+	cases := []struct {
+		accessToken string
+		profileJSON string
+		// failPath is the endpoint which rejects the exchange
+		failPath string
+	}{
+		{"invalid-token", profileJSON, ""},
+		{"any-token", profileJSON, "/user/authenticate"},
+		{"any-token", profileJSON, "/xsts/authorize"},
+		{"any-token", profileJSON, "/users/me/profile/settings"},
+		// profile without users or a gamertag
+		{"any-token", `{"profileUsers": []}`, ""},
+		{"any-token", `{"profileUsers": [{"id": "2535405290370290", "settings": []}]}`, ""},
+	}
+	for _, c := range cases {
+		proxyClient, server := newXboxTestServer(c.profileJSON, c.failPath)
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: c.accessToken})
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, ErrUnableToGetXboxProfile, gologin.ErrorFromContext(ctx))
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// ProfileHandler exchange fails, assert that:
+		// - failure handler is called with ErrUnableToGetXboxProfile
+		handler := ProfileHandler(success, goji.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(ctx, w, req)
+		assert.Equal(t, "failure handler called", w.Body.String(), c.failPath)
+		server.Close()
+	}
+}
@@ -0,0 +1,65 @@
+package xbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newXboxTestServer returns a new httptest.Server which mocks the Xbox Live
+// user token, XSTS token, and profile endpoints and a client which proxies
+// requests to the server. Each endpoint checks the token of the previous
+// step of the exchange. Requests to the failPath, if any, are rejected with
+// an XErr, like Xbox Live rejects accounts without an Xbox profile. The
+// caller must close the server.
+func newXboxTestServer(profileJSON, failPath string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	if failPath != "" {
+		mux.HandleFunc(failPath, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"Identity": "0", "XErr": 2148916233}`)
+		})
+	}
+	handleUnlessFailing(mux, failPath, "/user/authenticate", func(w http.ResponseWriter, r *http.Request) {
+		var body tokenRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != "POST" || body.Properties["RpsTicket"] != "d=any-token" {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"Token": "user-token", "DisplayClaims": {"xui": [{"uhs": "user-hash"}]}}`)
+	})
+	handleUnlessFailing(mux, failPath, "/xsts/authorize", func(w http.ResponseWriter, r *http.Request) {
+		var body tokenRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		userTokens, _ := body.Properties["UserTokens"].([]interface{})
+		if r.Method != "POST" || len(userTokens) != 1 || userTokens[0] != "user-token" || body.RelyingParty != "http://xboxlive.com" {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"Token": "xsts-token", "DisplayClaims": {"xui": [{"uhs": "user-hash"}]}}`)
+	})
+	handleUnlessFailing(mux, failPath, "/users/me/profile/settings", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "XBL3.0 x=user-hash;xsts-token" || r.URL.Query().Get("settings") != "Gamertag" {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, profileJSON)
+	})
+	return client, server
+}
+
+// handleUnlessFailing registers the handler for the pattern, unless the
+// pattern is the failPath.
+func handleUnlessFailing(mux *http.ServeMux, failPath, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	if pattern != failPath {
+		mux.HandleFunc(pattern, handler)
+	}
+}
@@ -0,0 +1,162 @@
+package xbox
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+)
+
+// Xbox Live token exchange and profile URLs.
+var (
+	UserAuthenticateURL = "https://user.auth.xboxlive.com/user/authenticate"
+	XSTSAuthorizeURL    = "https://xsts.auth.xboxlive.com/xsts/authorize"
+	ProfileURL          = "https://profile.xboxlive.com/users/me/profile/settings"
+)
+
+// Scopes are the Microsoft scopes required to sign in to Xbox Live.
+var Scopes = []string{"XboxLive.signin", "offline_access"}
+
+// Profile is an Xbox Live profile.
+type Profile struct {
+	// XUID is the Xbox user ID
+	XUID     string
+	Gamertag string
+}
+
+// Identity returns the Xbox identity keyed by the XUID.
+func (p *Profile) Identity() gologin.Identity {
+	return gologin.Identity{Provider: "xbox", ID: p.XUID}
+}
+
+// tokenRequest is an Xbox Live user or XSTS token request.
+type tokenRequest struct {
+	Properties   map[string]interface{} `json:"Properties"`
+	RelyingParty string                 `json:"RelyingParty"`
+	TokenType    string                 `json:"TokenType"`
+}
+
+// tokenResponse is an Xbox Live user or XSTS token response. The user hash
+// (uhs) claim identifies the user in the XBL3.0 authorization header.
+type tokenResponse struct {
+	Token         string `json:"Token"`
+	DisplayClaims struct {
+		XUI []struct {
+			UserHash string `json:"uhs"`
+		} `json:"xui"`
+	} `json:"DisplayClaims"`
+}
+
+// userHash returns the user hash claim of the token response.
+func (t *tokenResponse) userHash() string {
+	if len(t.DisplayClaims.XUI) == 0 {
+		return ""
+	}
+	return t.DisplayClaims.XUI[0].UserHash
+}
+
+// profileResponse is an Xbox Live profile settings response.
+type profileResponse struct {
+	ProfileUsers []struct {
+		ID       string `json:"id"`
+		Settings []struct {
+			ID    string `json:"id"`
+			Value string `json:"value"`
+		} `json:"settings"`
+	} `json:"profileUsers"`
+}
+
+// client is an Xbox Live client for exchanging a Microsoft access token for
+// the user's Profile.
+type client struct {
+	sling *sling.Sling
+}
+
+func newClient(httpClient *http.Client) *client {
+	base := sling.New().Client(httpClient).ResponseDecoder(internal.JSONDecoder{})
+	base.Set("Accept", "application/json")
+	return &client{sling: base}
+}
+
+// Profile exchanges the Microsoft access token for an Xbox Live user token,
+// the user token for an XSTS token, and gets the Profile with the XSTS token.
+func (c *client) Profile(accessToken string) (*Profile, error) {
+	userToken, err := c.authenticate(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	xstsToken, err := c.authorize(userToken.Token)
+	if err != nil {
+		return nil, err
+	}
+	return c.profile(xstsToken)
+}
+
+// authenticate exchanges the Microsoft access token for a user token.
+func (c *client) authenticate(accessToken string) (*tokenResponse, error) {
+	body := &tokenRequest{
+		Properties: map[string]interface{}{
+			"AuthMethod": "RPS",
+			"SiteName":   "user.auth.xboxlive.com",
+			"RpsTicket":  "d=" + accessToken,
+		},
+		RelyingParty: "http://auth.xboxlive.com",
+		TokenType:    "JWT",
+	}
+	return c.token(UserAuthenticateURL, body)
+}
+
+// authorize exchanges the user token for an XSTS token for Xbox Live
+// services.
+func (c *client) authorize(userToken string) (*tokenResponse, error) {
+	body := &tokenRequest{
+		Properties: map[string]interface{}{
+			"SandboxId":  "RETAIL",
+			"UserTokens": []string{userToken},
+		},
+		RelyingParty: "http://xboxlive.com",
+		TokenType:    "JWT",
+	}
+	return c.token(XSTSAuthorizeURL, body)
+}
+
+func (c *client) token(url string, body *tokenRequest) (*tokenResponse, error) {
+	token := new(tokenResponse)
+	resp, err := c.sling.New().Post(url).Set("x-xbl-contract-version", "1").BodyJSON(body).ReceiveSuccess(token)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || token.Token == "" || token.userHash() == "" {
+		return nil, fmt.Errorf("xbox: unexpected token response status %d", resp.StatusCode)
+	}
+	return token, nil
+}
+
+// profile gets the gamertag profile setting with the XSTS token.
+func (c *client) profile(xstsToken *tokenResponse) (*Profile, error) {
+	settings := new(profileResponse)
+	params := &struct {
+		Settings string `url:"settings"`
+	}{"Gamertag"}
+	authorization := fmt.Sprintf("XBL3.0 x=%s;%s", xstsToken.userHash(), xstsToken.Token)
+	resp, err := c.sling.New().Get(ProfileURL).QueryStruct(params).
+		Set("Authorization", authorization).
+		Set("x-xbl-contract-version", "2").
+		ReceiveSuccess(settings)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || len(settings.ProfileUsers) == 0 {
+		return nil, fmt.Errorf("xbox: unexpected profile response status %d", resp.StatusCode)
+	}
+	user := settings.ProfileUsers[0]
+	profile := &Profile{XUID: user.ID}
+	for _, setting := range user.Settings {
+		if setting.ID == "Gamertag" {
+			profile.Gamertag = setting.Value
+		}
+	}
+	return profile, nil
+}
@@ -0,0 +1,6 @@
+// Package yandex provides Yandex OAuth2 login and callback handlers.
+//
+// The Yandex ID API authorizes requests with the "OAuth" scheme rather than
+// "Bearer", so the User is fetched with an "Authorization: OAuth <token>"
+// header.
+package yandex
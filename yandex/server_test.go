@@ -0,0 +1,31 @@
+package yandex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newYandexTestServer returns a new httptest.Server which mocks the Yandex
+// token endpoint and the info endpoint, which requires the "OAuth"
+// authorization scheme and responds with the given json data. It also
+// returns a client which proxies requests to the server. The caller must
+// close the server.
+func newYandexTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "yandex-token", "token_type": "bearer", "expires_in": 31536000}`)
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "OAuth yandex-token" || r.URL.Query().Get("format") != "json" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,74 @@
+package yandex
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const yandexAPI = "https://login.yandex.ru/"
+
+// Endpoint is the Yandex OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://oauth.yandex.ru/authorize",
+	TokenURL: "https://oauth.yandex.ru/token",
+}
+
+func init() {
+	gologin.RegisterEndpoint("yandex", Endpoint)
+}
+
+// User is a Yandex user.
+type User struct {
+	ID           string `json:"id"`
+	Login        string `json:"login"`
+	DefaultEmail string `json:"default_email"`
+	DisplayName  string `json:"display_name"`
+}
+
+// Identity returns the Yandex identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Yandex user's default email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.DefaultEmail, u.DefaultEmail != ""
+}
+
+// infoParams are the Yandex ID info request parameters.
+type infoParams struct {
+	Format string `url:"format"`
+}
+
+// client is a Yandex client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+// newClient returns a Yandex client which authorizes requests with the
+// access token using the "OAuth" scheme.
+func newClient(httpClient *http.Client, accessToken, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(yandexAPI).
+		Set("Authorization", "OAuth "+accessToken).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "info"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Info gets the authenticated User.
+// https://yandex.com/dev/id/doc/en/user-information
+func (c *client) Info() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).QueryStruct(&infoParams{Format: "json"}).ReceiveSuccess(user)
+	return user, resp, err
+}
@@ -0,0 +1,43 @@
+package zoom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quasor/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{ID: "KDcuGIm1QgePTO8WbOqwIQ", FirstName: "Jill"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "zoom: Context missing Zoom User", err.Error())
+	}
+}
+
+func TestUser_Identity(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "KDcuGIm1QgePTO8WbOqwIQ"})
+	identity, err := gologin.IdentityFromContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, gologin.Identity{Provider: "zoom", ID: "KDcuGIm1QgePTO8WbOqwIQ"}, identity)
+}
+
+func TestUser_AccountCreated(t *testing.T) {
+	// RFC 3339 created at timestamps
+	user := new(User)
+	err := json.Unmarshal([]byte(`{"id": "KDcuGIm1QgePTO8WbOqwIQ", "created_at": "2019-06-01T07:58:03Z"}`), user)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2019, time.June, 1, 7, 58, 3, 0, time.UTC), user.AccountCreated())
+	assert.True(t, (&User{}).AccountCreated().IsZero())
+}
@@ -0,0 +1,5 @@
+// Package zoom provides Zoom OAuth2 login and callback handlers.
+//
+// Zoom requires client credentials be sent with HTTP Basic auth on token
+// exchange.
+package zoom
@@ -0,0 +1,138 @@
+package zoom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goji.io"
+	"github.com/quasor/gologin"
+	oauth2Login "github.com/quasor/gologin/oauth2"
+	"github.com/quasor/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestCallbackHandler(t *testing.T) {
+	jsonData := `{"id": "KDcuGIm1QgePTO8WbOqwIQ", "email": "jill@example.com", "first_name": "Jill", "last_name": "Chill", "account_id": "q6gBJVO5TzexKYTb_I2rpg"}`
+	expectedUser := &User{
+		ID:        "KDcuGIm1QgePTO8WbOqwIQ",
+		Email:     "jill@example.com",
+		FirstName: "Jill",
+		LastName:  "Chill",
+		AccountID: "q6gBJVO5TzexKYTb_I2rpg",
+	}
+	proxyClient, server := newZoomTestServer(jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+
+	// Endpoint without an AuthStyle, so the Zoom default must be applied
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: Endpoint.AuthURL, TokenURL: Endpoint.TokenURL},
+	}
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		zoomUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, zoomUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler exchanges the code with Basic auth, assert that:
+	// - the Zoom User is added to the ctx of the success handler
+	handler := CallbackHandler(config, goji.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestZoomHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// ZoomHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	zoomHandler := zoomHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	zoomHandler.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestZoomHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Zoom Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetZoomUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// ZoomHandler cannot get Zoom User, assert that:
+	// - failure handler is called
+	// - error cannot get Zoom User added to the failure handler ctx
+	zoomHandler := zoomHandler(config, success, goji.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	zoomHandler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: "KDcuGIm1QgePTO8WbOqwIQ"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetZoomUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetZoomUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetZoomUser, validateResponse(&User{}, validResponse, nil))
+}
+
+func TestZoomHandler_UserInfoURL(t *testing.T) {
+	server := testutils.NewTestServerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/users/me", r.URL.Path)
+		assert.Equal(t, "Bearer any-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "KDcuGIm1QgePTO8WbOqwIQ", "email": "jill@example.com"}`)
+	})
+	defer server.Close()
+	// the userinfo URL points at the local test server directly
+	ctx := gologin.WithUserInfoURL(context.Background(), server.URL+"/v2/users/me")
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	success := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		zoomUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "KDcuGIm1QgePTO8WbOqwIQ", zoomUser.ID)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := zoomHandler(&oauth2.Config{}, goji.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(ctx, w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
@@ -0,0 +1,34 @@
+package zoom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/quasor/gologin/testutils"
+)
+
+// newZoomTestServer returns a new httptest.Server which mocks the Zoom token
+// endpoint, requiring Basic auth client credentials, and the users/me
+// endpoint, which responds with the given json data. It also returns a client
+// which proxies requests to the server. The caller must close the server.
+func newZoomTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client-id" || secret != "client-secret" {
+			http.Error(w, `{"reason": "Invalid client_id or client_secret", "error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "zoom-token", "token_type": "bearer", "expires_in": 3599}`)
+	})
+	mux.HandleFunc("/v2/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer zoom-token" {
+			http.Error(w, `{"code": 124, "message": "Invalid access token."}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,75 @@
+package zoom
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/quasor/gologin"
+	"github.com/quasor/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const zoomAPI = "https://api.zoom.us/v2/"
+
+// Endpoint is the Zoom OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://zoom.us/oauth/authorize",
+	TokenURL:  "https://zoom.us/oauth/token",
+	AuthStyle: oauth2.AuthStyleInHeader,
+}
+
+func init() {
+	gologin.RegisterEndpoint("zoom", Endpoint)
+}
+
+// User is a Zoom user.
+type User struct {
+	ID        string            `json:"id"`
+	Email     string            `json:"email"`
+	FirstName string            `json:"first_name"`
+	LastName  string            `json:"last_name"`
+	AccountID string            `json:"account_id"`
+	CreatedAt gologin.Timestamp `json:"created_at"`
+}
+
+// AccountCreated returns when the Zoom user was created.
+func (u *User) AccountCreated() time.Time {
+	return u.CreatedAt.Time
+}
+
+// Identity returns the Zoom identity keyed by the user ID.
+func (u *User) Identity() gologin.Identity {
+	return gologin.Identity{Provider: Provider.Name, ID: u.ID}
+}
+
+// EmailAddress returns the Zoom user's email address, if any.
+func (u *User) EmailAddress() (string, bool) {
+	return u.Email, u.Email != ""
+}
+
+// client is a Zoom client for obtaining a User.
+type client struct {
+	sling *sling.Sling
+	// userInfoURL is the userinfo URL or path relative to the API base
+	userInfoURL string
+}
+
+func newClient(httpClient *http.Client, userInfoURL string) *client {
+	base := sling.New().Client(httpClient).Base(zoomAPI).ResponseDecoder(internal.JSONDecoder{})
+	if userInfoURL == "" {
+		userInfoURL = "users/me"
+	}
+	return &client{
+		sling:       base,
+		userInfoURL: userInfoURL,
+	}
+}
+
+// Me gets the authenticated User.
+// https://developers.zoom.us/docs/api/rest/reference/user/methods/#operation/user
+func (c *client) Me() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get(c.userInfoURL).ReceiveSuccess(user)
+	return user, resp, err
+}